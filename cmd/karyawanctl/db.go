@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"karyawan-app/internal/backup"
+	repo "karyawan-app/internal/repository"
+)
+
+func migrate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("migrate", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := repo.Migrate(a.db, a.dialect); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "database is at schema version %s\n", repo.SchemaVersion())
+	return nil
+}
+
+func dbBackup(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("db backup", "[-o file]")
+	output := fs.String("o", "", "archive `file`, - for stdout (default karyawan-backup-<time>.tar.gz)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		*output = "karyawan-backup-" + time.Now().UTC().Format("20060102-150405") + ".tar.gz"
+	}
+
+	if *output == "-" {
+		m, err := backup.Backup(ctx, a.db, a.dialect, a.stdout)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "backed up %s (schema %s)\n", summary(m), m.SchemaVersion)
+		return nil
+	}
+
+	// Written next to the target and renamed, so an interrupted backup
+	// never leaves a truncated archive under the final name
+	f, err := os.CreateTemp(filepath.Dir(*output), ".karyawan-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	m, err := backup.Backup(ctx, a.db, a.dialect, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), *output); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "backed up %s (schema %s) to %s\n", summary(m), m.SchemaVersion, *output)
+	return nil
+}
+
+func dbVerify(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("db verify", "<file>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m, err := backup.Verify(f)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "%s: %s from %s at %s, schema %s, checksums OK\n",
+		fs.Arg(0), summary(m), m.Dialect, m.CreatedAt.Format(time.RFC3339), m.SchemaVersion)
+	return nil
+}
+
+func dbRestore(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("db restore", "[-employee id [-replace]] <file>")
+	employeeID := fs.Int("employee", 0, "restore only the employee with this `id`")
+	replace := fs.Bool("replace", false, "with -employee, overwrite the employee if it exists")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *employeeID < 0 || (*replace && *employeeID == 0) {
+		fs.Usage()
+		return errUsage
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m, err := backup.Restore(ctx, a.db, a.dialect, f, backup.RestoreOptions{EmployeeID: *employeeID, Replace: *replace})
+	if errors.Is(err, backup.ErrEmployeeExists) {
+		return fmt.Errorf("%w; pass -replace to overwrite it", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *employeeID != 0 {
+		fmt.Fprintf(a.stdout, "restored employee %d from %s\n", *employeeID, fs.Arg(0))
+	} else {
+		fmt.Fprintf(a.stdout, "restored %s from %s\n", summary(m), fs.Arg(0))
+	}
+	return nil
+}
+
+// summary describes the contents of a backup.
+func summary(m *backup.Manifest) string {
+	var employees, audit int
+	if t := m.Table("employees"); t != nil {
+		employees = t.Rows
+	}
+	if t := m.Table("employee_audit_log"); t != nil {
+		audit = t.Rows
+	}
+	return fmt.Sprintf("%d employees and %d audit entries", employees, audit)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"karyawan-app/internal/domain"
+)
+
+func employeeList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("employee list", "[-format table|json]")
+	format := fs.String("format", "table", "output `format`: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	employees, err := a.employee.GetAllEmployees(ctx)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		return writeJSON(a.stdout, employees)
+	case "table":
+		tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tEMAIL\tPOSITION\tROLE\tPHONE")
+		for _, e := range employees {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.Name, e.Email, e.Position, e.Role, e.Phone)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format %q, expected table or json", *format)
+	}
+}
+
+func employeeGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("employee get", "<id>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	employee, err := a.findEmployee(ctx, fs)
+	if err != nil {
+		return err
+	}
+	return writeJSON(a.stdout, employee)
+}
+
+func employeeCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("employee create", "[-file employee.json | -name ... -email ...]")
+	fields := employeeFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var employee domain.Employee
+	if err := fields.apply(fs, &employee, a.stdin); err != nil {
+		return err
+	}
+	if err := a.employee.CreateEmployee(ctx, &employee); err != nil {
+		return err
+	}
+	return writeJSON(a.stdout, employee)
+}
+
+func employeeUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("employee update", "<id> [-file employee.json | -name ... -email ...]")
+	fields := employeeFlags(fs)
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	employee, err := a.findEmployee(ctx, fs)
+	if err != nil {
+		return err
+	}
+
+	// Only the given fields change, like a PATCH
+	if err := fields.apply(fs, employee, a.stdin); err != nil {
+		return err
+	}
+	if err := a.employee.UpdateEmployee(ctx, employee); err != nil {
+		return err
+	}
+	return writeJSON(a.stdout, employee)
+}
+
+func employeeDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("employee delete", "<id>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	employee, err := a.findEmployee(ctx, fs)
+	if err != nil {
+		return err
+	}
+	if err := a.employee.DeleteEmployee(ctx, employee.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "deleted employee %d\n", employee.ID)
+	return nil
+}
+
+// findEmployee loads the employee whose ID is the single argument left in
+// fs.
+func (a *app) findEmployee(ctx context.Context, fs *flag.FlagSet) (*domain.Employee, error) {
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return nil, errUsage
+	}
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("invalid employee ID %q", fs.Arg(0))
+	}
+	employee, err := a.employee.GetEmployee(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, fmt.Errorf("employee %d not found", id)
+	}
+	return employee, nil
+}
+
+// parseInterspersed parses flags given before or after the positional
+// arguments, so "employee update 7 -role admin" works too.
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+// employeeFields are the flags setting employee fields.
+type employeeFields struct {
+	file     *string
+	name     *string
+	email    *string
+	position *string
+	role     *string
+	phone    *string
+	alamat   *string
+}
+
+func employeeFlags(fs *flag.FlagSet) employeeFields {
+	return employeeFields{
+		file:     fs.String("file", "", "read the employee from a JSON `file` (- for stdin); other flags override it"),
+		name:     fs.String("name", "", "full name"),
+		email:    fs.String("email", "", "email address"),
+		position: fs.String("position", "", "job title"),
+		role:     fs.String("role", "", "role, e.g. staff or manager"),
+		phone:    fs.String("phone", "", "phone number"),
+		alamat:   fs.String("alamat", "", "free-text address"),
+	}
+}
+
+// apply sets the fields given on the command line on employee, reading
+// -file first. The ID is kept.
+func (f employeeFields) apply(fs *flag.FlagSet, employee *domain.Employee, stdin io.Reader) error {
+	id := employee.ID
+	if *f.file != "" {
+		if err := readJSON(*f.file, stdin, employee); err != nil {
+			return err
+		}
+		employee.ID = id
+	}
+
+	values := map[string]*string{
+		"name": f.name, "email": f.email, "position": f.position,
+		"role": f.role, "phone": f.phone, "alamat": f.alamat,
+	}
+	targets := map[string]*string{
+		"name": &employee.Name, "email": &employee.Email, "position": &employee.Position,
+		"role": &employee.Role, "phone": &employee.Phone, "alamat": &employee.Alamat,
+	}
+	changed := *f.file != ""
+	fs.Visit(func(fl *flag.Flag) {
+		if target, ok := targets[fl.Name]; ok {
+			*target = *values[fl.Name]
+			changed = true
+		}
+	})
+	if !changed {
+		fs.Usage()
+		return errUsage
+	}
+
+	// A free-text address replaces the structured one, which would
+	// otherwise be rendered over it
+	if *f.alamat != "" {
+		employee.Address = nil
+	}
+	return nil
+}
+
+// readJSON decodes the JSON file at path, or stdin for "-", into v.
+func readJSON(path string, stdin io.Reader, v interface{}) error {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("%s: invalid JSON at offset %d: %w", path, syntaxErr.Offset, err)
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
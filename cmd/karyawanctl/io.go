@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// csvColumns are the columns written by export -format csv. Import reads
+// the employee fields among them by header name, in any order.
+var csvColumns = []string{"id", "name", "email", "position", "role", "phone", "alamat", "created_at", "updated_at"}
+
+func importEmployees(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("import", "[-continue] <file.json|file.csv|->")
+	format := fs.String("format", "", "input `format`: json or csv (default from the file extension, json for stdin)")
+	keepGoing := fs.Bool("continue", false, "import the remaining rows when one fails")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = "json"
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			*format = "csv"
+		}
+	}
+
+	var employees []domain.Employee
+	switch *format {
+	case "json":
+		if err := readJSON(path, a.stdin, &employees); err != nil {
+			return err
+		}
+	case "csv":
+		var err error
+		if employees, err = readCSV(path, a.stdin); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q, expected json or csv", *format)
+	}
+
+	// Every row goes through the service, so it is validated and audited
+	// like a POST /api/employees
+	var created, failed int
+	for i := range employees {
+		employee := employees[i]
+		employee.ID = 0
+		if err := a.employee.CreateEmployee(ctx, &employee); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			fmt.Fprintf(a.stderr, "record %d (%s): %v\n", i+1, employee.Email, err)
+			if !*keepGoing {
+				return fmt.Errorf("import stopped after %d of %d employees; use -continue to skip failing records", created, len(employees))
+			}
+			continue
+		}
+		created++
+	}
+	fmt.Fprintf(a.stdout, "imported %d employees\n", created)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d employees failed to import", failed, len(employees))
+	}
+	return nil
+}
+
+func exportEmployees(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("export", "[-format json|csv] [-o file]")
+	format := fs.String("format", "json", "output `format`: json or csv")
+	output := fs.String("o", "-", "output `file`, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q, expected json or csv", *format)
+	}
+
+	employees, err := a.employee.GetAllEmployees(ctx)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		if *format == "csv" {
+			return writeCSV(w, employees)
+		}
+		return writeJSON(w, employees)
+	}
+	if *output == "-" {
+		return write(a.stdout)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "exported %d employees to %s\n", len(employees), *output)
+	return nil
+}
+
+func writeCSV(w io.Writer, employees []domain.Employee) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, e := range employees {
+		var updated string
+		if !e.UpdatedAt.IsZero() {
+			updated = e.UpdatedAt.Format(time.RFC3339)
+		}
+		record := []string{
+			strconv.Itoa(e.ID), e.Name, e.Email, e.Position, e.Role, e.Phone, e.Alamat,
+			e.CreatedAt.Format(time.RFC3339), updated,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readCSV reads employees from a CSV file with a header row. Columns other
+// than the employee fields, such as id, are ignored.
+func readCSV(path string, stdin io.Reader) ([]domain.Employee, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: missing header row", path)
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%s: missing %q column", path, required)
+		}
+	}
+
+	var employees []domain.Employee
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		get := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		employees = append(employees, domain.Employee{
+			Name:     get("name"),
+			Email:    get("email"),
+			Position: get("position"),
+			Role:     get("role"),
+			Phone:    get("phone"),
+			Alamat:   get("alamat"),
+		})
+	}
+	return employees, nil
+}
@@ -0,0 +1,219 @@
+// Command karyawanctl administers the employee database from the command
+// line: managing employees, importing and exporting them, running
+// migrations, seeding test data and backing up and restoring the database.
+//
+// Employee changes go through the same service as the HTTP API, so they are
+// validated, encrypted and audited the same way. Configuration is read like
+// the server's: from -config, the environment and flags given before the
+// command, e.g.
+//
+//	karyawanctl -database.driver sqlite employee list
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"karyawan-app/internal/config"
+	"karyawan-app/internal/database"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/logging"
+	"karyawan-app/internal/pii"
+	"karyawan-app/internal/region"
+	repo "karyawan-app/internal/repository"
+	service "karyawan-app/internal/service"
+)
+
+// errUsage is returned after usage has been printed for a bad invocation.
+var errUsage = errors.New("invalid usage")
+
+// command is a subcommand, named by one or two words.
+type command struct {
+	name string
+	args string
+	help string
+	run  func(ctx context.Context, a *app, args []string) error
+	// noDB commands run without a database; migrates commands bring its
+	// schema up to date themselves
+	noDB     bool
+	migrates bool
+}
+
+var commands = []command{
+	{name: "employee list", args: "[-format table|json]", help: "list employees", run: employeeList},
+	{name: "employee get", args: "<id>", help: "print an employee as JSON", run: employeeGet},
+	{name: "employee create", args: "[-file employee.json | -name ... -email ...]", help: "create an employee", run: employeeCreate},
+	{name: "employee update", args: "<id> [-file employee.json | -name ... -email ...]", help: "change the given fields of an employee", run: employeeUpdate},
+	{name: "employee delete", args: "<id>", help: "delete an employee", run: employeeDelete},
+	{name: "import", args: "[-continue] <file.json|file.csv|->", help: "create employees from a JSON array or CSV file", run: importEmployees},
+	{name: "export", args: "[-format json|csv] [-o file]", help: "write every employee as JSON or CSV", run: exportEmployees},
+	{name: "migrate", help: "apply pending database migrations", run: migrate, migrates: true},
+	{name: "db backup", args: "[-o file]", help: "write a compressed logical backup", run: dbBackup},
+	{name: "db verify", args: "<file>", help: "check a backup's format and checksums", run: dbVerify, noDB: true},
+	{name: "db restore", args: "[-employee id [-replace]] <file>", help: "restore a backup into an empty database, or one employee", run: dbRestore, migrates: true},
+	{name: "seed", args: "[-count n] [-seed n] [-batch n]", help: "create reproducible dummy employees", run: seedEmployees},
+}
+
+func main() {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: failed to load .env: %v", err)
+	}
+
+	// Cancel on Ctrl+C; batches in progress are rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	switch {
+	case errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "karyawanctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// app is the state shared by the commands of one invocation.
+type app struct {
+	cfg    *config.Config
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	db        *sql.DB
+	dialect   repo.Dialect
+	regions   *region.Directory
+	employees domain.EmployeeRepository
+	employee  domain.EmployeeService
+}
+
+// run executes the command line args, which exclude the program name.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("karyawanctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(stderr) }
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	cmd, rest := findCommand(fs.Args())
+	if cmd == nil {
+		if fs.NArg() > 0 {
+			fmt.Fprintf(stderr, "karyawanctl: unknown command %q\n\n", strings.Join(fs.Args(), " "))
+		}
+		usage(stderr)
+		return errUsage
+	}
+
+	// Logs go to stderr so they never mix with command output
+	slog.SetDefault(logging.New(stderr, logging.Config{Level: cfg.Log.Level, Sample2xx: 1}))
+
+	a := &app{cfg: cfg, stdin: stdin, stdout: stdout, stderr: stderr}
+	if !cmd.noDB {
+		if err := a.open(ctx, !cmd.migrates); err != nil {
+			return err
+		}
+		defer a.db.Close()
+	}
+	return cmd.run(ctx, a, rest)
+}
+
+// findCommand matches the longest command name at the start of args.
+func findCommand(args []string) (*command, []string) {
+	for n := 2; n >= 1; n-- {
+		if len(args) < n {
+			continue
+		}
+		name := strings.Join(args[:n], " ")
+		for i := range commands {
+			if commands[i].name == name {
+				return &commands[i], args[n:]
+			}
+		}
+	}
+	return nil, nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: karyawanctl [-config file] [-<setting> value ...] <command> [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = fmt.Sprintf("  %-20s %s", c.name, c.help)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintln(w, n)
+	}
+	fmt.Fprintln(w, "\nRun \"karyawanctl <command> -h\" for the arguments of a command.")
+	fmt.Fprintln(w, "Settings are those of the server; see \"go run ./cmd/server config print\".")
+}
+
+// open connects to the database and builds the employee service. Unless
+// migrating, the schema must be up to date, so the CLI never writes to a
+// database the server would migrate differently.
+func (a *app) open(ctx context.Context, requireSchema bool) error {
+	db, dialect, err := database.Open(a.cfg.Database)
+	if err != nil {
+		return err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	a.db, a.dialect = db, dialect
+	if requireSchema {
+		if err := repo.CheckSchemaVersion(ctx, db, dialect); err != nil {
+			db.Close()
+			return fmt.Errorf("%w; run \"karyawanctl migrate\" first", err)
+		}
+	}
+
+	regions, err := region.Default()
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to load region dataset: %w", err)
+	}
+	keys, err := pii.LoadKeyring(a.cfg.PII)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to load PII keys: %w", err)
+	}
+	if keys == nil {
+		slog.Warn("PII_MASTER_KEY/PII_KEY_FILE not set, personal data will be stored unencrypted")
+	}
+	a.regions = regions
+	a.employees = repo.NewEmployeeRepository(db, dialect, keys)
+	a.employee = service.NewEmployeeService(a.employees, repo.NewAuditRepository(db, dialect), regions)
+	return nil
+}
+
+// flags returns a flag set for the command's own arguments, reporting
+// errors and -h on stderr.
+func (a *app) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("karyawanctl "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: karyawanctl %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"karyawan-app/internal/domain"
+)
+
+// ctl runs karyawanctl against its own SQLite database.
+type ctl struct {
+	t  *testing.T
+	db string
+}
+
+func newCtl(t *testing.T) *ctl {
+	t.Helper()
+	c := &ctl{t: t, db: filepath.Join(t.TempDir(), "karyawan.db")}
+	c.run("migrate")
+	return c
+}
+
+func (c *ctl) exec(stdin string, args ...string) (string, string, error) {
+	c.t.Helper()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-database.driver", "sqlite", "-database.path", c.db}, args...)
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func (c *ctl) run(args ...string) string {
+	c.t.Helper()
+	out, stderr, err := c.exec("", args...)
+	if err != nil {
+		c.t.Fatalf("karyawanctl %s: %v\n%s", strings.Join(args, " "), err, stderr)
+	}
+	return out
+}
+
+func (c *ctl) employees() []domain.Employee {
+	c.t.Helper()
+	var employees []domain.Employee
+	if err := json.Unmarshal([]byte(c.run("employee", "list", "-format", "json")), &employees); err != nil {
+		c.t.Fatal(err)
+	}
+	return employees
+}
+
+func TestEmployeeCommands(t *testing.T) {
+	c := newCtl(t)
+
+	out := c.run("employee", "create", "-name", "Siti Rahayu", "-email", "siti@example.com",
+		"-position", "Engineer", "-role", "staff", "-phone", "081234567890", "-alamat", "Jl. Merdeka 1, Bandung")
+	var created domain.Employee
+	if err := json.Unmarshal([]byte(out), &created); err != nil || created.ID == 0 {
+		t.Fatalf("employee create output %q: %v", out, err)
+	}
+
+	// Validation is the service's, as in the API
+	if _, _, err := c.exec("", "employee", "create", "-name", "X", "-email", "not-an-email",
+		"-position", "P", "-role", "staff", "-phone", "1", "-alamat", "A"); err == nil || !strings.Contains(err.Error(), "invalid email") {
+		t.Errorf("creating an invalid employee error = %v", err)
+	}
+
+	// Flags after the ID; only the given fields change
+	c.run("employee", "update", "1", "-role", "manager")
+	got := c.employees()
+	if len(got) != 1 || got[0].Role != "manager" || got[0].Position != "Engineer" {
+		t.Errorf("employees after update = %+v", got)
+	}
+
+	out = c.run("employee", "get", "1")
+	if !strings.Contains(out, `"email": "siti@example.com"`) {
+		t.Errorf("employee get output:\n%s", out)
+	}
+
+	if _, _, err := c.exec("", "employee", "delete", "42"); err == nil || !strings.Contains(err.Error(), "employee 42 not found") {
+		t.Errorf("deleting a missing employee error = %v", err)
+	}
+	c.run("employee", "delete", "1")
+	if got := c.employees(); len(got) != 0 {
+		t.Errorf("employees after delete = %+v", got)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	c := newCtl(t)
+	path := filepath.Join(t.TempDir(), "employees.csv")
+	csv := "name,email,position,role,phone,alamat\n" +
+		"Budi Santoso,budi@example.com,Accountant,staff,081111111111,Surabaya\n" +
+		"Bad Row,not-an-email,Accountant,staff,082222222222,Medan\n" +
+		"Dewi Lestari,dewi@example.com,Manager,manager,083333333333,Yogyakarta\n"
+	if err := os.WriteFile(path, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stops at the first failing record unless told to continue
+	if _, _, err := c.exec("", "import", path); err == nil {
+		t.Error("import with an invalid record succeeded")
+	}
+	if got := len(c.employees()); got != 1 {
+		t.Fatalf("%d employees after a stopped import, expected 1", got)
+	}
+	c.run("employee", "delete", "1")
+	out, stderr, err := c.exec("", "import", "-continue", path)
+	if err == nil || !strings.Contains(out, "imported 2 employees") || !strings.Contains(stderr, "record 2") {
+		t.Errorf("import -continue: err %v, stdout %q, stderr %q", err, out, stderr)
+	}
+
+	// A JSON export imports into another database unchanged
+	exported := c.run("export")
+	other := newCtl(t)
+	if _, stderr, err := other.exec(exported, "import", "-"); err != nil {
+		t.Fatalf("importing the export: %v\n%s", err, stderr)
+	}
+	byEmail := make(map[string]domain.Employee)
+	for _, e := range other.employees() {
+		byEmail[e.Email] = e
+	}
+	if len(byEmail) != 2 || byEmail["dewi@example.com"].Role != "manager" || byEmail["budi@example.com"].Alamat != "Surabaya" {
+		t.Errorf("re-imported employees = %+v", byEmail)
+	}
+
+	out = c.run("export", "-format", "csv")
+	if !strings.HasPrefix(out, "id,name,email,") || !strings.Contains(out, "Dewi Lestari,dewi@example.com") {
+		t.Errorf("CSV export:\n%s", out)
+	}
+}
+
+func TestSeedIsDeterministic(t *testing.T) {
+	a, b := newCtl(t), newCtl(t)
+	a.run("seed", "-count", "5", "-seed", "7")
+	b.run("seed", "-count", "5", "-seed", "7")
+	ea, eb := a.employees(), b.employees()
+	if len(ea) != 5 || len(eb) != 5 {
+		t.Fatalf("seeded %d and %d employees, expected 5", len(ea), len(eb))
+	}
+	for i := range ea {
+		if ea[i].Name != eb[i].Name || ea[i].Email != eb[i].Email || ea[i].Alamat != eb[i].Alamat {
+			t.Errorf("employee %d differs: %+v vs %+v", i, ea[i], eb[i])
+		}
+	}
+
+	if _, _, err := a.exec("", "seed", "-seed", "7"); err == nil || !strings.Contains(err.Error(), "5 of the 20 employees") {
+		t.Errorf("loading a seed twice error = %v", err)
+	}
+	a.run("seed", "-count", "5", "-seed", "8", "-batch", "2")
+	if got := len(a.employees()); got != 10 {
+		t.Errorf("%d employees after a second seed, expected 10", got)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	c := &ctl{t: t, db: filepath.Join(t.TempDir(), "karyawan.db")}
+	if _, stderr, err := c.exec("", "frobnicate"); !errors.Is(err, errUsage) || !strings.Contains(stderr, "employee list") {
+		t.Errorf("unknown command: err %v, stderr %q", err, stderr)
+	}
+	if _, _, err := c.exec("", "employee", "list"); err == nil || !strings.Contains(err.Error(), "karyawanctl migrate") {
+		t.Errorf("listing before migrating error = %v", err)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	c := newCtl(t)
+	c.run("seed", "-count", "5")
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if out := c.run("db", "backup", "-o", archive); !strings.Contains(out, "backed up 5 employees") {
+		t.Errorf("db backup output %q", out)
+	}
+	if out := c.run("db", "verify", archive); !strings.Contains(out, "checksums OK") {
+		t.Errorf("db verify output %q", out)
+	}
+
+	// Into a database that was never migrated
+	fresh := &ctl{t: t, db: filepath.Join(t.TempDir(), "restored.db")}
+	fresh.run("db", "restore", archive)
+	want, got := c.employees(), fresh.employees()
+	if len(got) != 5 || got[0].Email != want[0].Email || got[4].Alamat != want[4].Alamat {
+		t.Errorf("restored employees = %+v, expected %+v", got, want)
+	}
+
+	c.run("employee", "delete", "3")
+	c.run("db", "restore", archive, "-employee", "3")
+	if len(c.employees()) != 5 {
+		t.Error("employee 3 was not restored")
+	}
+	if _, _, err := c.exec("", "db", "restore", "-employee", "3", archive); err == nil || !strings.Contains(err.Error(), "-replace") {
+		t.Errorf("restoring an existing employee error = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Command rotate-keys re-encrypts employee PII under the current master key.
+//
+// To rotate, generate a new key with -generate-key, make it PII_MASTER_KEY,
+// move the previous one to PII_RETIRED_MASTER_KEYS (or update PII_KEY_FILE
+// accordingly) and run this command. Plaintext rows written before
+// encryption was enabled are encrypted by the same run. Once it completes
+// the retired key can be removed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"karyawan-app/internal/config"
+	"karyawan-app/internal/database"
+	"karyawan-app/internal/pii"
+	repo "karyawan-app/internal/repository"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 500, "rows re-encrypted per transaction")
+	generate := flag.Bool("generate-key", false, "print a new random base64 key and exit")
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found, using environment variables")
+	}
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+
+	// Generating a key needs no valid configuration
+	if *generate {
+		key, err := pii.GenerateKey()
+		if err != nil {
+			log.Fatalf("Error generating key: %v", err)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	keys, err := pii.LoadKeyring(cfg.PII)
+	if err != nil {
+		log.Fatalf("Error loading PII keys: %v", err)
+	}
+	if keys == nil {
+		log.Fatalf("PII_MASTER_KEY or PII_KEY_FILE must be set")
+	}
+
+	db, dialect, err := database.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Error pinging database: %v", err)
+	}
+
+	// Cancel on Ctrl+C; the current batch is rolled back and a
+	// later run picks up where this one stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Rotating employee PII to master key %q in batches of %d", keys.CurrentKeyID(), *batchSize)
+	rotated, err := repo.RotateEmployeeKeys(ctx, db, dialect, keys, *batchSize, func(n int) {
+		log.Printf("%d rows re-encrypted", n)
+	})
+	if err != nil {
+		log.Fatalf("Key rotation failed after %d rows: %v", rotated, err)
+	}
+	log.Printf("Key rotation complete, %d rows re-encrypted", rotated)
+}
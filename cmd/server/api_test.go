@@ -0,0 +1,1312 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"karyawan-app/internal/config"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/graphapi"
+	"karyawan-app/internal/grpcapi/karyawanv1"
+	handler "karyawan-app/internal/handler"
+	"karyawan-app/internal/logging"
+	"karyawan-app/internal/metrics"
+	"karyawan-app/internal/openapi"
+	"karyawan-app/internal/region"
+	"karyawan-app/internal/repository/memory"
+	"karyawan-app/internal/service"
+	"karyawan-app/internal/tracing/spantest"
+)
+
+func validEmployee() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     "Dewi Lestari",
+		"email":    "dewi@example.com",
+		"position": "HR Specialist",
+		"role":     "HR",
+		"phone":    "081234567890",
+		"alamat":   "Jl. Kebon Sirih No. 15, Jakarta Pusat",
+	}
+}
+
+func TestEmployeeCRUD(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	var created domain.Employee
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, &created)
+	if created.ID == 0 || created.Name != "Dewi Lestari" {
+		t.Fatalf("unexpected created employee: %+v", created)
+	}
+
+	var list []domain.Employee
+	s.expect(s.do("GET", "/api/employees", nil, nil), http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Fatalf("GET /api/employees = %+v", list)
+	}
+
+	update := validEmployee()
+	update["position"] = "HR Manager"
+	var updated domain.Employee
+	s.expect(s.do("PUT", "/api/employees/1", update, nil), http.StatusOK, &updated)
+
+	var fetched domain.Employee
+	s.expect(s.do("GET", "/api/employees/1", nil, nil), http.StatusOK, &fetched)
+	if fetched.Position != "HR Manager" {
+		t.Errorf("position after update = %q", fetched.Position)
+	}
+
+	var byEmail []domain.Employee
+	s.expect(s.do("GET", "/api/employees?email=dewi@example.com", nil, nil), http.StatusOK, &byEmail)
+	if len(byEmail) != 1 {
+		t.Errorf("lookup by email returned %d employees", len(byEmail))
+	}
+
+	s.expect(s.do("DELETE", "/api/employees/1", nil, nil), http.StatusOK, nil)
+	s.expect(s.do("GET", "/api/employees/1", nil, nil), http.StatusNotFound, nil)
+	s.expect(s.do("PUT", "/api/employees/1", update, nil), http.StatusNotFound, nil)
+	s.expect(s.do("DELETE", "/api/employees/1", nil, nil), http.StatusNotFound, nil)
+}
+
+func TestEmployeeValidation(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]interface{})
+	}{
+		{"missing name", func(e map[string]interface{}) { e["name"] = " " }},
+		{"invalid email", func(e map[string]interface{}) { e["email"] = "not-an-email" }},
+		{"missing position", func(e map[string]interface{}) { delete(e, "position") }},
+		{"postal code outside kecamatan", func(e map[string]interface{}) {
+			e["address"] = map[string]string{
+				"street": "Jl. Asia Afrika", "kelurahan": "Senayan", "kecamatan": "Kebayoran Baru",
+				"kota_kabupaten": "Kota Jakarta Selatan", "provinsi": "DKI Jakarta", "kode_pos": "40111",
+			}
+		}},
+	}
+	for _, test := range tests {
+		e := validEmployee()
+		test.mutate(e)
+		var body map[string]string
+		s.expect(s.do("POST", "/api/employees", e, nil), http.StatusBadRequest, &body)
+		if body["error"] == "" {
+			t.Errorf("%s: expected an error message", test.name)
+		}
+	}
+
+	s.expect(s.do("GET", "/api/employees/abc", nil, nil), http.StatusBadRequest, nil)
+	s.expect(s.do("POST", "/api/employees", nil, nil), http.StatusBadRequest, nil)
+
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, nil)
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusBadRequest, nil)
+}
+
+func TestStructuredAddress(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	e := validEmployee()
+	e["address"] = map[string]string{
+		"street": "Jl. Asia Afrika No. 8", "rt": "001", "rw": "002", "kelurahan": "gelora",
+		"kecamatan": "tanah abang", "kota_kabupaten": "kota jakarta pusat", "provinsi": "dki jakarta", "kode_pos": "10270",
+	}
+	var created domain.Employee
+	s.expect(s.do("POST", "/api/employees", e, nil), http.StatusCreated, &created)
+
+	want := "Jl. Asia Afrika No. 8, RT 001/RW 002, Kel. Gelora, Kec. Tanah Abang, Kota Jakarta Pusat, DKI Jakarta 10270"
+	if created.Alamat != want {
+		t.Errorf("alamat = %q, expected %q", created.Alamat, want)
+	}
+
+	var villages []map[string]string
+	s.expect(s.do("GET", "/api/regions/districts/31.71.01/villages", nil, nil), http.StatusOK, &villages)
+	if len(villages) == 0 || villages[0]["postal_code"] == "" {
+		t.Errorf("villages = %v", villages)
+	}
+	s.expect(s.do("GET", "/api/regions/provinces/99/cities", nil, nil), http.StatusNotFound, nil)
+}
+
+func TestDataExportAndAnonymize(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+	s.expect(s.do("POST", "/api/employees", validEmployee(), map[string]string{"Idempotency-Key": "create-1"}), http.StatusCreated, nil)
+
+	resp := s.do("GET", "/api/employees/1/data-export", nil, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/zip" {
+		t.Fatalf("data export = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	data, _ := io.ReadAll(resp.Body)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("data export is not a zip: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	if files["employee.json"] == nil || files["audit_log.json"] == nil {
+		t.Errorf("data export files = %v", files)
+	}
+	var logged []domain.EmployeeEvent
+	if err := json.Unmarshal(files["events.json"], &logged); err != nil || len(logged) != 1 || logged[0].Type != domain.EventCreated {
+		t.Errorf("events.json = %s, expected the creation", files["events.json"])
+	}
+	var stored []domain.StoredResponse
+	if err := json.Unmarshal(files["stored_responses.json"], &stored); err != nil || len(stored) != 1 || !strings.Contains(stored[0].Body, "dewi@example.com") {
+		t.Errorf("stored_responses.json = %s, expected the idempotent create", files["stored_responses.json"])
+	}
+
+	var anonymized domain.Employee
+	s.expect(s.do("POST", "/api/employees/1/anonymize", nil, nil), http.StatusOK, &anonymized)
+	if anonymized.AnonymizedAt == nil || anonymized.Phone != "" || anonymized.Name == "Dewi Lestari" {
+		t.Errorf("anonymized employee = %+v", anonymized)
+	}
+	s.expect(s.do("PUT", "/api/employees/1", validEmployee(), nil), http.StatusConflict, nil)
+}
+
+func TestRateLimiting(t *testing.T) {
+	s := newTestServer(t, routerConfig{RateLimiter: rateLimiter(handler.RateLimits{Default: 3})})
+
+	for i := 0; i < 3; i++ {
+		resp := s.do("GET", "/api/employees", nil, nil)
+		s.expect(resp, http.StatusOK, nil)
+		if got := resp.Header.Get("RateLimit-Remaining"); got != strconv.Itoa(2-i) {
+			t.Errorf("request %d: RateLimit-Remaining = %q, expected %d", i, got, 2-i)
+		}
+		if resp.Header.Get("RateLimit-Limit") != "3" || resp.Header.Get("RateLimit-Reset") == "" {
+			t.Errorf("request %d: missing rate limit headers: %v", i, resp.Header)
+		}
+	}
+
+	// A new connection comes from a different port but the same client
+	s.Client().CloseIdleConnections()
+	resp := s.do("GET", "/api/employees", nil, nil)
+	s.expect(resp, http.StatusTooManyRequests, nil)
+	if retry, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retry < 1 || retry > 20 {
+		t.Errorf("Retry-After = %q, expected about 20s", resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestRateLimitSharedStore(t *testing.T) {
+	// Two replicas counting in the same store share one quota
+	store := memory.NewRateLimitStore()
+	replica := func() *testServer {
+		return newTestServer(t, routerConfig{RateLimiter: handler.NewRateLimiter(handler.RateLimits{Default: 2}, store)})
+	}
+	a, b := replica(), replica()
+
+	a.expect(a.do("GET", "/api/employees", nil, nil), http.StatusOK, nil)
+	b.expect(b.do("GET", "/api/employees", nil, nil), http.StatusOK, nil)
+	a.expect(a.do("GET", "/api/employees", nil, nil), http.StatusTooManyRequests, nil)
+	b.expect(b.do("GET", "/api/employees", nil, nil), http.StatusTooManyRequests, nil)
+}
+
+// failingRateLimitStore is a store whose backend is unreachable.
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, int, time.Duration) (domain.RateLimitResult, error) {
+	return domain.RateLimitResult{}, errors.New("connection refused")
+}
+
+func (failingRateLimitStore) Cleanup(ctx context.Context) { <-ctx.Done() }
+
+func TestRateLimitStoreFailsOpen(t *testing.T) {
+	s := newTestServer(t, routerConfig{RateLimiter: handler.NewRateLimiter(handler.RateLimits{Default: 1}, failingRateLimitStore{})})
+
+	for i := 0; i < 3; i++ {
+		resp := s.do("GET", "/api/employees", nil, nil)
+		s.expect(resp, http.StatusOK, nil)
+		if resp.Header.Get("RateLimit-Limit") != "" {
+			t.Errorf("RateLimit-Limit = %q without a working store", resp.Header.Get("RateLimit-Limit"))
+		}
+	}
+}
+
+func TestRateLimitTiersAndRoutes(t *testing.T) {
+	s := newTestServer(t, routerConfig{RateLimiter: rateLimiter(handler.RateLimits{
+		Default: 2,
+		Tiers:   map[string]int{"premium": 5},
+		APIKeys: map[string]string{"premium-key": "premium"},
+		Routes:  map[string]int{"employees.create": 1, "employees.create@premium": 2},
+	})})
+	premium := map[string]string{"X-API-Key": "premium-key"}
+
+	// Per-route quotas have their own bucket
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, nil)
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusTooManyRequests, nil)
+	resp := s.do("GET", "/api/employees", nil, nil)
+	s.expect(resp, http.StatusOK, nil)
+	if resp.Header.Get("RateLimit-Limit") != "2" {
+		t.Errorf("RateLimit-Limit = %q for an anonymous client", resp.Header.Get("RateLimit-Limit"))
+	}
+
+	// API keys get their tier's quota, separate from the IP's
+	for i := 0; i < 5; i++ {
+		resp := s.do("GET", "/api/employees", nil, premium)
+		s.expect(resp, http.StatusOK, nil)
+		if resp.Header.Get("RateLimit-Limit") != "5" {
+			t.Fatalf("RateLimit-Limit = %q for the premium tier", resp.Header.Get("RateLimit-Limit"))
+		}
+	}
+	s.expect(s.do("GET", "/api/employees", nil, premium), http.StatusTooManyRequests, nil)
+
+	// Unknown keys do not get a fresh bucket
+	s.expect(s.do("GET", "/api/employees", nil, map[string]string{"X-API-Key": "made-up"}), http.StatusOK, nil)
+	s.expect(s.do("GET", "/api/employees", nil, map[string]string{"X-API-Key": "made-up-too"}), http.StatusTooManyRequests, nil)
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	loopback, _ := handler.ParseTrustedProxies("127.0.0.1")
+	s := newTestServer(t, routerConfig{RateLimiter: rateLimiter(handler.RateLimits{Default: 1, TrustedProxies: loopback})})
+
+	// Behind a trusted proxy every forwarded client has its own bucket
+	for _, ip := range []string{"203.0.113.7", "203.0.113.8"} {
+		s.expect(s.do("GET", "/api/employees", nil, map[string]string{"X-Forwarded-For": ip}), http.StatusOK, nil)
+	}
+	// A client prepending a fake hop is still identified by the address
+	// the proxy saw
+	s.expect(s.do("GET", "/api/employees", nil, map[string]string{"X-Forwarded-For": "10.9.9.9, 203.0.113.7"}), http.StatusTooManyRequests, nil)
+
+	untrusted := newTestServer(t, routerConfig{RateLimiter: rateLimiter(handler.RateLimits{Default: 1})})
+	untrusted.expect(untrusted.do("GET", "/api/employees", nil, map[string]string{"X-Forwarded-For": "203.0.113.7"}), http.StatusOK, nil)
+	untrusted.expect(untrusted.do("GET", "/api/employees", nil, map[string]string{"X-Forwarded-For": "203.0.113.8"}), http.StatusTooManyRequests, nil)
+}
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t, routerConfig{RateLimiter: rateLimiter(handler.RateLimits{Default: 3})})
+
+	var created domain.Employee
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, &created)
+	s.expect(s.do("GET", fmt.Sprintf("/api/employees/%d", created.ID), nil, nil), http.StatusOK, nil)
+	s.expect(s.do("GET", "/api/employees/999", nil, nil), http.StatusNotFound, nil)
+	s.expect(s.do("GET", "/api/employees", nil, nil), http.StatusTooManyRequests, nil)
+
+	body := s.scrape()
+	for _, want := range []string{
+		`karyawan_http_requests_total{method="POST",route="/api/employees",status="201"} 1`,
+		`karyawan_http_requests_total{method="GET",route="/api/employees/{id}",status="200"} 1`,
+		`karyawan_http_requests_total{method="GET",route="/api/employees/{id}",status="404"} 1`,
+		`karyawan_http_request_duration_seconds_count{method="GET",route="/api/employees/{id}"} 2`,
+		`karyawan_db_query_duration_seconds_count{method="Create",repository="employee"} 1`,
+		`karyawan_db_query_duration_seconds_count{method="Record",repository="audit"} 1`,
+		`karyawan_rate_limit_rejections_total 1`,
+		`karyawan_employees_headcount{role="HR"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics is missing %s", want)
+		}
+	}
+	if strings.Contains(body, `route="/api/employees/999"`) {
+		t.Error("/metrics labels requests by raw URI")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logging.New(&logs, logging.Config{Level: slog.LevelInfo}))
+
+	s := newTestServer(t, routerConfig{})
+
+	resp := s.do("GET", "/api/employees", nil, map[string]string{"X-Request-ID": "client-id-1"})
+	s.expect(resp, http.StatusOK, nil)
+	if got := resp.Header.Get("X-Request-ID"); got != "client-id-1" {
+		t.Errorf("X-Request-ID = %q, expected the client's ID", got)
+	}
+
+	for _, header := range []map[string]string{nil, {"X-Request-ID": "has spaces\tand tabs"}} {
+		resp = s.do("GET", "/api/employees", nil, header)
+		s.expect(resp, http.StatusOK, nil)
+		if got := resp.Header.Get("X-Request-ID"); len(got) != 32 {
+			t.Errorf("generated X-Request-ID = %q", got)
+		}
+	}
+
+	var line struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		Status    int    `json:"status"`
+	}
+	if err := json.Unmarshal(bytes.SplitN(logs.Bytes(), []byte("\n"), 2)[0], &line); err != nil {
+		t.Fatalf("access log is not JSON: %s", logs.String())
+	}
+	if line.Msg != "request" || line.RequestID != "client-id-1" || line.Status != http.StatusOK {
+		t.Errorf("unexpected access log line: %+v", line)
+	}
+}
+
+func TestGRPCTracing(t *testing.T) {
+	recorder := spantest.Install(t)
+	broker := events.NewBroker()
+	svc := service.NewEmployeeService(memory.NewEmployeeRepository(), memory.NewAuditRepository(), nil)
+	srv := newGRPCServer(svc, routerConfig{
+		RateLimiter: rateLimiter(handler.RateLimits{Default: 1000}),
+		Metrics:     metrics.New(),
+		Broker:      broker,
+	})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error: %v", err)
+	}
+	go srv.Serve(lis)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		broker.Close()
+		srv.Stop()
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	if _, err := karyawanv1.NewEmployeeServiceClient(conn).ListEmployees(ctx, &karyawanv1.ListEmployeesRequest{}); err != nil {
+		t.Fatalf("ListEmployees() error: %v", err)
+	}
+
+	spans := recorder.Spans()
+	server := spantest.Find(spans, "karyawan.v1.EmployeeService/ListEmployees")
+	if server == nil || server.TraceID != traceID {
+		t.Fatalf("server span = %+v, expected one in the caller's trace %s", server, traceID)
+	}
+	if call := spantest.Find(spans, "EmployeeService.ListEmployees"); call == nil || call.ParentSpanID != server.SpanID {
+		t.Errorf("service span = %+v, expected a child of the server span", call)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := spantest.Install(t)
+	s := newTestServer(t, routerConfig{})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	s.expect(s.do("GET", "/api/employees", nil, map[string]string{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	}), http.StatusOK, nil)
+
+	spans := recorder.Spans()
+	byID := make(map[string]spantest.Span)
+	for _, span := range spans {
+		if span.TraceID != traceID {
+			t.Errorf("span %s has trace %s, expected the caller's %s", span.Name, span.TraceID, traceID)
+		}
+		byID[span.SpanID] = span
+	}
+
+	// Each span is nested in the previous one, from the server span down to
+	// the service call
+	chain := []string{
+		"HTTP GET",
+		"middleware RequestID",
+		"middleware Logging",
+		"middleware CORS",
+		"middleware RateLimit",
+		"middleware JSONContentType",
+		"EmployeeHandler.GetAllEmployees",
+		"EmployeeService.GetAllEmployees",
+	}
+	for i, name := range chain {
+		span := spantest.Find(spans, name)
+		if span == nil {
+			t.Fatalf("no %q span in %+v", name, spans)
+		}
+		if i == 0 {
+			if span.ParentSpanID != "00f067aa0ba902b7" {
+				t.Errorf("%s parent = %s, expected the caller's span", name, span.ParentSpanID)
+			}
+			continue
+		}
+		if parent := byID[span.ParentSpanID]; parent.Name != chain[i-1] {
+			t.Errorf("%s parent = %q, expected %q", name, parent.Name, chain[i-1])
+		}
+	}
+
+	handlerSpan := spantest.Find(spans, "EmployeeHandler.GetAllEmployees")
+	if handlerSpan.Attributes["http.route"] != "/api/employees" {
+		t.Errorf("http.route = %v", handlerSpan.Attributes["http.route"])
+	}
+}
+
+func TestCORS(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	resp := s.do("OPTIONS", "/api/employees", nil, map[string]string{
+		"Origin":                        "http://localhost:3000",
+		"Access-Control-Request-Method": "POST",
+	})
+	s.expect(resp, http.StatusOK, nil)
+	if resp.Header.Get("Access-Control-Allow-Origin") == "" {
+		t.Error("missing Access-Control-Allow-Origin on preflight")
+	}
+	if resp.Header.Get("Access-Control-Allow-Methods") == "" {
+		t.Error("missing Access-Control-Allow-Methods on preflight")
+	}
+
+	get := s.do("GET", "/api/employees", nil, map[string]string{"Origin": "http://localhost:3000"})
+	s.expect(get, http.StatusOK, nil)
+	if get.Header.Get("Access-Control-Allow-Origin") == "" {
+		t.Error("missing Access-Control-Allow-Origin on simple request")
+	}
+}
+
+func TestCORSPolicy(t *testing.T) {
+	policy := handler.DefaultCORSPolicy()
+	policy.AllowedOrigins = []string{"https://hr.example.com", "https://*.karyawan.id"}
+	policy.AllowCredentials = true
+	exportPolicy := policy
+	exportPolicy.AllowedOrigins = []string{"https://hr.example.com"}
+	s := newTestServer(t, routerConfig{CORS: handler.CORSConfig{
+		Default: policy,
+		Routes:  map[string]handler.CORSPolicy{"employees.data_export": exportPolicy},
+	}})
+
+	for _, origin := range []string{"https://hr.example.com", "https://app.karyawan.id", "https://a.b.karyawan.id"} {
+		resp := s.do("GET", "/api/employees", nil, map[string]string{"Origin": origin})
+		s.expect(resp, http.StatusOK, nil)
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q", origin, got)
+		}
+		if resp.Header.Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("%s: credentials not allowed", origin)
+		}
+		exposed := resp.Header.Get("Access-Control-Expose-Headers")
+		if !strings.Contains(exposed, "ETag") || !strings.Contains(exposed, "RateLimit-Remaining") {
+			t.Errorf("%s: Access-Control-Expose-Headers = %q", origin, exposed)
+		}
+		if !strings.Contains(strings.Join(resp.Header.Values("Vary"), ","), "Origin") {
+			t.Errorf("%s: missing Vary: Origin", origin)
+		}
+	}
+
+	// Other origins are served, but the browser withholds the response
+	for _, origin := range []string{"https://evil.com", "https://karyawan.id", "https://evilkaryawan.id", "http://app.karyawan.id"} {
+		resp := s.do("GET", "/api/employees", nil, map[string]string{"Origin": origin})
+		s.expect(resp, http.StatusOK, nil)
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, expected none", origin, got)
+		}
+	}
+
+	preflight := func(origin, method, path, headers string) *http.Response {
+		h := map[string]string{"Origin": origin, "Access-Control-Request-Method": method}
+		if headers != "" {
+			h["Access-Control-Request-Headers"] = headers
+		}
+		return s.do("OPTIONS", path, nil, h)
+	}
+	resp := preflight("https://app.karyawan.id", "POST", "/api/employees", "content-type, x-request-id")
+	s.expect(resp, http.StatusOK, nil)
+	if resp.Header.Get("Access-Control-Allow-Origin") != "https://app.karyawan.id" || resp.Header.Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("unexpected preflight headers: %v", resp.Header)
+	}
+	s.expect(preflight("https://evil.com", "POST", "/api/employees", ""), http.StatusForbidden, nil)
+	s.expect(preflight("https://app.karyawan.id", "PATCH", "/api/employees", ""), http.StatusForbidden, nil)
+	s.expect(preflight("https://app.karyawan.id", "POST", "/api/employees", "X-Evil"), http.StatusForbidden, nil)
+
+	// The data export route only allows the HR origin
+	s.expect(preflight("https://app.karyawan.id", "GET", "/api/employees/1/data-export", ""), http.StatusForbidden, nil)
+	s.expect(preflight("https://hr.example.com", "GET", "/api/employees/1/data-export", ""), http.StatusOK, nil)
+}
+
+func TestLoadCORS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cors.json")
+	file := `{
+		"allowed_origins": ["https://hr.example.com"],
+		"allow_credentials": true,
+		"max_age": "10m",
+		"routes": {"employees.data_export": {"allowed_origins": ["https://dpo.example.com"]}}
+	}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CORS_CONFIG_FILE", path)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://hr.example.com, https://*.example.com")
+	loaded, err := config.Load(flag.NewFlagSet("test", flag.ContinueOnError), nil)
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+
+	cfg := corsConfig(loaded)
+	if got := cfg.Default.AllowedOrigins; len(got) != 2 || got[1] != "https://*.example.com" {
+		t.Errorf("default origins = %v, expected the environment to override the file", got)
+	}
+	export := cfg.For("employees.data_export")
+	if len(export.AllowedOrigins) != 1 || export.AllowedOrigins[0] != "https://dpo.example.com" {
+		t.Errorf("route origins = %v", export.AllowedOrigins)
+	}
+	if !export.AllowCredentials || export.MaxAge != 10*time.Minute || len(export.AllowedMethods) == 0 {
+		t.Errorf("route policy = %+v, expected unset fields from the file and defaults", export)
+	}
+}
+
+// slowRepository blocks FindAll until the request context is done, like a
+// query stuck on a locked table.
+type slowRepository struct {
+	domain.EmployeeRepository
+}
+
+func (r slowRepository) FindAll(ctx context.Context) ([]domain.Employee, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDatabaseTimeout(t *testing.T) {
+	s := newTestServerWithRepository(t, routerConfig{
+		Timeouts: handler.RouteTimeouts{
+			Default: time.Second,
+			Routes:  map[string]time.Duration{"employees.list": 50 * time.Millisecond},
+		},
+	}, slowRepository{memory.NewEmployeeRepository()})
+
+	start := time.Now()
+	var body map[string]string
+	s.expect(s.do("GET", "/api/employees", nil, nil), http.StatusGatewayTimeout, &body)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("route timeout not applied, request took %s", elapsed)
+	}
+	if body["error"] != "Database operation timed out" {
+		t.Errorf("error = %q", body["error"])
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	s := newTestServer(t, routerConfig{RateLimiter: rateLimiter(handler.RateLimits{Default: 1})})
+
+	// Probes are exempt from rate limiting
+	for i := 0; i < 3; i++ {
+		s.expect(s.do("GET", "/readyz", nil, nil), http.StatusOK, nil)
+	}
+
+	s.lc.SetReady(false)
+	s.expect(s.do("GET", "/readyz", nil, nil), http.StatusServiceUnavailable, nil)
+
+	// Liveness does not depend on readiness
+	s.expect(s.do("GET", "/healthz", nil, nil), http.StatusOK, nil)
+}
+
+func TestReadinessChecks(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	s := newTestServer(t, routerConfig{ReadinessChecks: []handler.HealthCheck{
+		{Name: "migrations", Check: func(ctx context.Context) error { return nil }},
+		{Name: "database", Check: func(ctx context.Context) error { return dbErr }},
+	}})
+
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	s.expect(s.do("GET", "/readyz", nil, nil), http.StatusServiceUnavailable, &body)
+	if body.Checks["database"] != dbErr.Error() || body.Checks["migrations"] != "ok" {
+		t.Errorf("checks = %v", body.Checks)
+	}
+
+	dbErr = nil
+	s.expect(s.do("GET", "/readyz", nil, nil), http.StatusOK, &body)
+	if body.Status != "ready" {
+		t.Errorf("status = %q, expected ready", body.Status)
+	}
+}
+
+func TestDebugStatus(t *testing.T) {
+	s := newTestServer(t, routerConfig{Debug: handler.DebugStatus{
+		Token:   "s3cret",
+		Started: time.Now().Add(-time.Minute),
+		Config: map[string]string{
+			"DB_DRIVER":    "postgres",
+			"DB_PASSWORD":  "hunter2",
+			"DATABASE_URL": "postgres://karyawan:hunter2@db:5432/karyawan",
+		},
+	}})
+
+	s.expect(s.do("GET", "/debug/status", nil, nil), http.StatusUnauthorized, nil)
+	s.expect(s.do("GET", "/debug/status", nil, map[string]string{"Authorization": "Bearer wrong"}), http.StatusUnauthorized, nil)
+
+	var status struct {
+		Uptime string            `json:"uptime"`
+		Build  map[string]string `json:"build"`
+		Config map[string]string `json:"config"`
+	}
+	s.expect(s.do("GET", "/debug/status", nil, map[string]string{"Authorization": "Bearer s3cret"}), http.StatusOK, &status)
+	if status.Build["go_version"] == "" || status.Uptime == "" {
+		t.Errorf("status missing build info or uptime: %+v", status)
+	}
+	if status.Config["DB_DRIVER"] != "postgres" {
+		t.Errorf("DB_DRIVER = %q", status.Config["DB_DRIVER"])
+	}
+	for k, v := range status.Config {
+		if strings.Contains(v, "hunter2") {
+			t.Errorf("%s leaks the password: %q", k, v)
+		}
+	}
+
+	// Without a token the endpoint does not exist
+	s = newTestServer(t, routerConfig{})
+	s.expect(s.do("GET", "/debug/status", nil, nil), http.StatusNotFound, nil)
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("openapi.Load() error: %v", err)
+	}
+	regions, _ := region.Default()
+	r := mux.NewRouter()
+	handler.NewStreamHandler(nil, handler.StreamConfig{}).RegisterRoutes(r)
+	handler.NewEmployeeHandler(nil).RegisterRoutes(r)
+	handler.NewRegionHandler(regions).RegisterRoutes(r)
+
+	routes := map[string]bool{}
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			op, ok := spec.Operation(route.GetName())
+			if !ok || op.Method != method || op.Path != path {
+				t.Errorf("route %s %s (%s) is not documented, the document has %+v", method, path, route.GetName(), op)
+			}
+		}
+		routes[route.GetName()] = true
+		return nil
+	})
+	for _, op := range spec.Operations() {
+		if !routes[op.ID] {
+			t.Errorf("operation %s %s (%s) has no route", op.Method, op.Path, op.ID)
+		}
+	}
+}
+
+func TestOpenAPIDocs(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	s.expect(s.do("GET", "/api/openapi.json", nil, nil), http.StatusOK, &doc)
+	if doc.OpenAPI != "3.1.0" || doc.Paths["/employees/{id}"] == nil {
+		t.Errorf("GET /api/openapi.json = %+v", doc)
+	}
+
+	resp := s.do("GET", "/api/docs", nil, nil)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || !strings.Contains(string(body), `url: "openapi.json"`) {
+		t.Errorf("GET /api/docs = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]interface{})
+		want   string
+	}{
+		{"wrong type", func(e map[string]interface{}) { e["phone"] = 81234567890 }, "/phone: got number, want string"},
+		{"unknown field", func(e map[string]interface{}) { e["salary"] = "10000000" }, "additional properties 'salary' not allowed"},
+		{"no address at all", func(e map[string]interface{}) { delete(e, "alamat") }, "missing property 'alamat'"},
+		{"address without street", func(e map[string]interface{}) {
+			e["address"] = map[string]string{"kelurahan": "Gelora"}
+		}, "/address: missing property 'street'"},
+	}
+	for _, test := range tests {
+		e := validEmployee()
+		test.mutate(e)
+		var body map[string]string
+		s.expect(s.do("POST", "/api/employees", e, nil), http.StatusBadRequest, &body)
+		if !strings.Contains(body["error"], test.want) {
+			t.Errorf("%s: error = %q, expected it to mention %q", test.name, body["error"], test.want)
+		}
+	}
+
+	var body map[string]string
+	s.expect(s.do("GET", "/api/employees/abc", nil, nil), http.StatusBadRequest, &body)
+	if body["error"] != `Invalid request: path parameter "id" must be an integer` {
+		t.Errorf("error = %q", body["error"])
+	}
+
+	req, _ := http.NewRequest("POST", s.URL+"/api/employees", strings.NewReader("name=Dewi"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("form encoded POST = %d, expected 415", resp.StatusCode)
+	}
+
+	// A fetched employee, read-only fields and all, can be sent back
+	var created map[string]interface{}
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, &created)
+	created["position"] = "HR Manager"
+	s.expect(s.do("PUT", "/api/employees/1", created, nil), http.StatusOK, nil)
+}
+
+func TestAPIVersions(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	// v1 keeps the contract of the original server
+	var created domain.Employee
+	s.expect(s.do("POST", "/api/v1/employees", validEmployee(), nil), http.StatusCreated, &created)
+	var legacy handler.LegacyErrorResponse
+	s.expect(s.do("GET", "/api/v1/employees/42", nil, nil), http.StatusNotFound, &legacy)
+	if legacy != (handler.LegacyErrorResponse{Error: "Not Found", Message: "Employee not found", Code: 404}) {
+		t.Errorf("v1 error = %+v", legacy)
+	}
+	s.expect(s.do("PUT", "/api/v1/employees/abc", validEmployee(), nil), http.StatusBadRequest, &legacy)
+	if legacy.Code != http.StatusBadRequest || legacy.Message == "" {
+		t.Errorf("v1 error = %+v", legacy)
+	}
+	resp := s.do("DELETE", fmt.Sprintf("/api/v1/employees/%d", created.ID), nil, nil)
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusNoContent || len(body) != 0 {
+		t.Errorf("v1 delete = %d %q, expected 204 without a body", resp.StatusCode, body)
+	}
+	s.expect(s.do("POST", "/api/v1/employees/1/anonymize", nil, nil), http.StatusNotFound, nil)
+
+	// v2 is the current contract
+	s.expect(s.do("POST", "/api/v2/employees", validEmployee(), nil), http.StatusCreated, &created)
+	var body map[string]string
+	s.expect(s.do("GET", "/api/v2/employees/42", nil, nil), http.StatusNotFound, &body)
+	if len(body) != 1 || body["error"] != "Employee not found" {
+		t.Errorf("v2 error = %v", body)
+	}
+	s.expect(s.do("DELETE", fmt.Sprintf("/api/v2/employees/%d", created.ID), nil, nil), http.StatusOK, &body)
+	if body["message"] == "" {
+		t.Errorf("v2 delete = %v", body)
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	v1 := apiDeprecations["v1"]
+	resp := s.do("GET", "/api/v1/employees/7", nil, nil)
+	if got := resp.Header.Get("Deprecation"); got != fmt.Sprintf("@%d", v1.Since.Unix()) {
+		t.Errorf("v1 Deprecation = %q", got)
+	}
+	if got := resp.Header.Get("Sunset"); got != v1.Sunset.Format(http.TimeFormat) {
+		t.Errorf("v1 Sunset = %q", got)
+	}
+	if got := resp.Header.Get("Link"); got != `</api/v2/employees/7>; rel="successor-version"` {
+		t.Errorf("v1 Link = %q", got)
+	}
+
+	resp = s.do("GET", "/api/employees", nil, nil)
+	if resp.Header.Get("Deprecation") == "" || resp.Header.Get("Sunset") != "" ||
+		resp.Header.Get("Link") != `</api/v2/employees>; rel="successor-version"` {
+		t.Errorf("unversioned route headers = %v", resp.Header)
+	}
+
+	resp = s.do("GET", "/api/v2/employees", nil, nil)
+	if resp.Header.Get("Deprecation") != "" || resp.Header.Get("Sunset") != "" {
+		t.Errorf("v2 route is marked deprecated: %v", resp.Header)
+	}
+
+	// Single routes can be deprecated too
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	s = newTestServer(t, routerConfig{Deprecations: handler.Deprecations{
+		"v2 employees.list": {Since: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Sunset: sunset},
+	}})
+	resp = s.do("GET", "/api/v2/employees", nil, nil)
+	if resp.Header.Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" || resp.Header.Get("Link") != "" {
+		t.Errorf("deprecated route headers = %v", resp.Header)
+	}
+	if resp = s.do("GET", "/api/v2/regions/provinces", nil, nil); resp.Header.Get("Deprecation") != "" {
+		t.Errorf("other v2 route is marked deprecated: %v", resp.Header)
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	s := newTestServer(t, routerConfig{GraphQL: graphapi.Limits{MaxDepth: 10, MaxComplexity: 100}})
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, nil)
+
+	var result struct {
+		Data struct {
+			Employees struct {
+				Edges []struct {
+					Node struct {
+						Name     string
+						AuditLog []struct{ Action string }
+					}
+				}
+			}
+		}
+		Errors []struct {
+			Message    string
+			Extensions map[string]string
+		}
+	}
+	query := map[string]string{"query": `{ employees(first: 2) { edges { node { name auditLog { action } } } } }`}
+	s.expect(s.do("POST", "/graphql", query, nil), http.StatusOK, &result)
+	edges := result.Data.Employees.Edges
+	if len(result.Errors) > 0 || len(edges) != 1 || edges[0].Node.Name != "Dewi Lestari" || len(edges[0].Node.AuditLog) != 1 {
+		t.Fatalf("employees = %+v, errors %+v", edges, result.Errors)
+	}
+
+	result.Errors = nil
+	query["query"] = `{ employees(first: 100) { edges { node { name } } } }`
+	s.expect(s.do("POST", "/graphql", query, nil), http.StatusOK, &result)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "QUERY_TOO_COMPLEX" {
+		t.Errorf("errors = %+v, expected the query to be too complex", result.Errors)
+	}
+
+	s.expect(s.do("POST", "/graphql", map[string]string{"query": ""}, nil), http.StatusBadRequest, nil)
+
+	resp := s.do("GET", "/graphql/schema", nil, nil)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "type EmployeeConnection") {
+		t.Errorf("GET /graphql/schema = %d: %.100s", resp.StatusCode, body)
+	}
+}
+
+// sseEvent is an event or, with only comment set, a comment of a server-sent
+// event stream.
+type sseEvent struct {
+	id, name, data, comment string
+}
+
+// openStream requests the employee stream and returns its events as they
+// arrive, once the subscription is established.
+func (s *testServer) openStream(query string, headers map[string]string) (<-chan sseEvent, func()) {
+	s.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", s.URL+"/api/employees/stream"+query, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("GET /api/employees/stream error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		body, _ := io.ReadAll(resp.Body)
+		s.t.Fatalf("GET /api/employees/stream = %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var e sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				events <- e
+				e = sseEvent{}
+				continue
+			}
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "":
+				e.comment = value
+			case "id":
+				e.id = value
+			case "event":
+				e.name = value
+			case "data":
+				e.data = value
+			}
+		}
+	}()
+	if e := <-events; e.comment != "connected" {
+		s.t.Fatalf("stream opened with %+v, expected the connected comment", e)
+	}
+	return events, func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+// nextEvent returns the next event of the stream, skipping heartbeats.
+func nextEvent(t *testing.T, events <-chan sseEvent) (sseEvent, domain.EmployeeEvent) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("stream ended")
+			}
+			if e.comment != "" {
+				continue
+			}
+			var event domain.EmployeeEvent
+			if err := json.Unmarshal([]byte(e.data), &event); err != nil {
+				t.Fatalf("invalid event data %q: %v", e.data, err)
+			}
+			return e, event
+		case <-timeout:
+			t.Fatal("no event within 2s")
+		}
+	}
+}
+
+func TestEmployeeStream(t *testing.T) {
+	// A write timeout far shorter than the test, which streams outlive
+	s := newTestServer(t, routerConfig{Stream: handler.StreamConfig{
+		Heartbeat:    20 * time.Millisecond,
+		WriteTimeout: 50 * time.Millisecond,
+	}})
+
+	hr, closeHR := s.openStream("?role=HR", nil)
+	developer := validEmployee()
+	developer["email"] = "budi@example.com"
+	developer["phone"] = "081298765432"
+	developer["role"] = "Developer"
+	s.expect(s.do("POST", "/api/employees", developer, nil), http.StatusCreated, nil)
+	var created domain.Employee
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, &created)
+
+	raw, event := nextEvent(t, hr)
+	if raw.name != "created" || event.EmployeeID != created.ID || event.Employee == nil || event.Employee.Name != "Dewi Lestari" {
+		t.Fatalf("first HR event = %+v %+v, expected Dewi's creation", raw, event)
+	}
+	if raw.id != strconv.FormatInt(event.ID, 10) {
+		t.Errorf("event id %q does not match its data %d", raw.id, event.ID)
+	}
+	createdID := raw.id
+
+	// Heartbeats keep the stream going past the write timeout
+	time.Sleep(150 * time.Millisecond)
+	var heartbeats int
+	for len(hr) > 0 {
+		if e := <-hr; e.comment == "heartbeat" {
+			heartbeats++
+		}
+	}
+	if heartbeats == 0 {
+		t.Error("no heartbeat on an idle stream")
+	}
+	update := validEmployee()
+	update["position"] = "HR Manager"
+	s.expect(s.do("PUT", "/api/employees/"+strconv.Itoa(created.ID), update, nil), http.StatusOK, nil)
+	if raw, event := nextEvent(t, hr); raw.name != "updated" || event.Employee.Position != "HR Manager" {
+		t.Errorf("second HR event = %+v %+v, expected the update", raw, event)
+	}
+	closeHR()
+
+	// Resuming replays what followed the given event from the log
+	all, closeAll := s.openStream("", map[string]string{"Last-Event-ID": createdID})
+	defer closeAll()
+	if raw, event := nextEvent(t, all); raw.name != "updated" || event.EmployeeID != created.ID || event.Employee == nil {
+		t.Errorf("replayed event = %+v %+v, expected the update", raw, event)
+	}
+	s.expect(s.do("DELETE", "/api/employees/"+strconv.Itoa(created.ID), nil, nil), http.StatusOK, nil)
+	if raw, event := nextEvent(t, all); raw.name != "deleted" || event.Role != "HR" || event.Employee != nil {
+		t.Errorf("live event = %+v %+v, expected the deletion", raw, event)
+	}
+
+	s.expect(s.do("GET", "/api/employees/stream", nil, map[string]string{"Last-Event-ID": "abc"}), http.StatusBadRequest, nil)
+}
+
+func TestEmployeeStreamAcrossReplicas(t *testing.T) {
+	// Two replicas sharing the database, each with its own broker
+	employees := memory.NewEmployeeRepository()
+	cfg := routerConfig{EventLog: memory.NewEventLog(), EventPollInterval: 20 * time.Millisecond}
+	a := newTestServerWithRepository(t, cfg, employees)
+	b := newTestServerWithRepository(t, cfg, employees)
+
+	stream, closeStream := b.openStream("", nil)
+	defer closeStream()
+	var created domain.Employee
+	a.expect(a.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, &created)
+	if raw, event := nextEvent(t, stream); raw.name != "created" || event.EmployeeID != created.ID || event.Employee == nil {
+		t.Errorf("event on the other replica = %+v %+v, expected the creation", raw, event)
+	}
+}
+
+func TestBulkEmployees(t *testing.T) {
+	eventLog := memory.NewEventLog()
+	s := newTestServer(t, routerConfig{MaxBulkOperations: 4, EventLog: eventLog})
+
+	var existing domain.Employee
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, &existing)
+
+	withEmail := func(email string) map[string]interface{} {
+		e := validEmployee()
+		e["email"] = email
+		return e
+	}
+	type result struct {
+		Index    int              `json:"index"`
+		Status   int              `json:"status"`
+		ID       int              `json:"id"`
+		Employee *domain.Employee `json:"employee"`
+		Error    string           `json:"error"`
+	}
+	var resp struct {
+		Applied int      `json:"applied"`
+		Failed  int      `json:"failed"`
+		Results []result `json:"results"`
+	}
+	statuses := func() []int {
+		var got []int
+		for _, r := range resp.Results {
+			got = append(got, r.Status)
+		}
+		return got
+	}
+
+	// Atomic: one invalid operation and nothing is applied
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{
+		"operations": []map[string]interface{}{
+			{"op": "create", "employee": withEmail("budi@example.com")},
+			{"op": "create", "employee": withEmail("BUDI@example.com")},
+		},
+	}, nil), http.StatusUnprocessableEntity, &resp)
+	if got := statuses(); fmt.Sprint(got) != "[424 400]" || resp.Applied != 0 || resp.Failed != 2 {
+		t.Fatalf("atomic bulk with a duplicate email = %+v", resp)
+	}
+	var list []domain.Employee
+	s.expect(s.do("GET", "/api/employees", nil, nil), http.StatusOK, &list)
+	if len(list) != 1 {
+		t.Fatalf("failed atomic bulk left %d employees, expected 1", len(list))
+	}
+
+	// Best effort: failures are reported and the rest is applied
+	update := validEmployee()
+	update["position"] = "HR Manager"
+	resp.Results = nil
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{
+		"mode": "best_effort",
+		"operations": []map[string]interface{}{
+			{"op": "create", "employee": withEmail("budi@example.com")},
+			{"op": "update", "id": existing.ID, "employee": update},
+			{"op": "delete", "id": 999},
+			{"op": "create", "employee": validEmployee()},
+		},
+	}, nil), http.StatusOK, &resp)
+	if got := statuses(); fmt.Sprint(got) != "[201 200 404 400]" || resp.Applied != 2 || resp.Failed != 2 {
+		t.Fatalf("best effort bulk = %+v", resp)
+	}
+	created := resp.Results[0]
+	if created.ID == 0 || created.Employee == nil || created.Employee.Email != "budi@example.com" {
+		t.Fatalf("created result = %+v", created)
+	}
+	var fetched domain.Employee
+	s.expect(s.do("GET", fmt.Sprintf("/api/employees/%d", existing.ID), nil, nil), http.StatusOK, &fetched)
+	if fetched.Position != "HR Manager" {
+		t.Errorf("position after bulk update = %q", fetched.Position)
+	}
+
+	// Atomic success; an employee may only be targeted once
+	resp.Results = nil
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{
+		"operations": []map[string]interface{}{
+			{"op": "delete", "id": existing.ID},
+			{"op": "delete", "id": created.ID},
+		},
+	}, nil), http.StatusOK, &resp)
+	if got := statuses(); fmt.Sprint(got) != "[200 200]" {
+		t.Fatalf("atomic bulk delete = %+v", resp)
+	}
+	s.expect(s.do("GET", "/api/employees", nil, nil), http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Errorf("%d employees left after bulk delete", len(list))
+	}
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{
+		"mode": "best_effort",
+		"operations": []map[string]interface{}{
+			{"op": "create", "employee": validEmployee()},
+			{"op": "delete", "id": 1},
+		},
+	}, nil), http.StatusOK, &resp)
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{
+		"mode": "best_effort",
+		"operations": []map[string]interface{}{
+			{"op": "update", "id": 3, "employee": validEmployee()},
+			{"op": "delete", "id": 3},
+		},
+	}, nil), http.StatusOK, &resp)
+	if got := statuses(); fmt.Sprint(got) != "[200 400]" {
+		t.Errorf("bulk targeting an employee twice = %+v", resp)
+	}
+
+	// Every applied operation is published, like single requests
+	events, err := eventLog.FindAfter(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatalf("FindAfter() error: %v", err)
+	}
+	if len(events) != 1+2+2+1+1 {
+		t.Errorf("event log holds %d events, expected 7", len(events))
+	}
+
+	// Request level errors
+	tooMany := make([]map[string]interface{}, 5)
+	for i := range tooMany {
+		tooMany[i] = map[string]interface{}{"op": "delete", "id": i + 1}
+	}
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{"operations": tooMany}, nil), http.StatusRequestEntityTooLarge, nil)
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{"operations": []interface{}{}}, nil), http.StatusBadRequest, nil)
+	s.expect(s.do("POST", "/api/employees/bulk", map[string]interface{}{"mode": "sometimes", "operations": tooMany[:1]}, nil), http.StatusBadRequest, nil)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+	key := map[string]string{"Idempotency-Key": "create-dewi"}
+
+	var created, replayed domain.Employee
+	s.expect(s.do("POST", "/api/employees", validEmployee(), key), http.StatusCreated, &created)
+	resp := s.do("POST", "/api/employees", validEmployee(), key)
+	s.expect(resp, http.StatusCreated, &replayed)
+	if resp.Header.Get("Idempotent-Replayed") != "true" || replayed.ID != created.ID {
+		t.Fatalf("retry = %+v (replayed %q), expected employee %d replayed", replayed, resp.Header.Get("Idempotent-Replayed"), created.ID)
+	}
+	var list []domain.Employee
+	s.expect(s.do("GET", "/api/employees", nil, nil), http.StatusOK, &list)
+	if len(list) != 1 {
+		t.Fatalf("%d employees after a retry, expected 1", len(list))
+	}
+
+	// The key is bound to its request
+	other := validEmployee()
+	other["email"] = "budi@example.com"
+	s.expect(s.do("POST", "/api/employees", other, key), http.StatusUnprocessableEntity, nil)
+	s.expect(s.do("POST", "/api/v2/employees", validEmployee(), key), http.StatusUnprocessableEntity, nil)
+
+	// Keys are scoped per API key: this one is a new request, rejected as
+	// the email is taken
+	resp = s.do("POST", "/api/employees", validEmployee(), map[string]string{"Idempotency-Key": "create-dewi", "X-API-Key": "partner"})
+	s.expect(resp, http.StatusBadRequest, nil)
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("request with another API key was replayed")
+	}
+
+	// Client errors are kept too
+	invalid := map[string]string{"Idempotency-Key": "invalid"}
+	s.expect(s.do("POST", "/api/employees", validEmployee(), invalid), http.StatusBadRequest, nil)
+	s.expect(s.do("DELETE", fmt.Sprintf("/api/employees/%d", created.ID), nil, nil), http.StatusOK, nil)
+	resp = s.do("POST", "/api/employees", validEmployee(), invalid)
+	s.expect(resp, http.StatusBadRequest, nil)
+	if resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("rejected request was not replayed")
+	}
+
+	// Requests without a key, and other methods, are left alone
+	s.expect(s.do("POST", "/api/employees", validEmployee(), nil), http.StatusCreated, nil)
+	resp = s.do("GET", "/api/employees", nil, key)
+	s.expect(resp, http.StatusOK, nil)
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("GET was replayed")
+	}
+	long := map[string]string{"Idempotency-Key": strings.Repeat("k", 256)}
+	s.expect(s.do("POST", "/api/employees", other, long), http.StatusBadRequest, nil)
+}
+
+func TestIdempotencyKeyExpiry(t *testing.T) {
+	s := newTestServer(t, routerConfig{IdempotencyTTL: 50 * time.Millisecond})
+	key := map[string]string{"Idempotency-Key": "k"}
+
+	s.expect(s.do("POST", "/api/employees", validEmployee(), key), http.StatusCreated, nil)
+	time.Sleep(100 * time.Millisecond)
+	other := validEmployee()
+	other["email"] = "budi@example.com"
+	resp := s.do("POST", "/api/employees", other, key)
+	s.expect(resp, http.StatusCreated, nil)
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("expired key was replayed")
+	}
+}
+
+func TestIdempotencyKeyForgetsErasedEmployees(t *testing.T) {
+	s := newTestServer(t, routerConfig{})
+
+	// Deleting and anonymizing drop the stored responses holding the
+	// employee, so their retries are processed anew
+	for _, erase := range []string{"DELETE /api/employees/%d", "POST /api/employees/%d/anonymize"} {
+		method, path, _ := strings.Cut(erase, " ")
+		key := map[string]string{"Idempotency-Key": method}
+		employee := validEmployee()
+		employee["email"] = strings.ToLower(method) + "@example.com"
+
+		var created domain.Employee
+		s.expect(s.do("POST", "/api/employees", employee, key), http.StatusCreated, &created)
+		s.expect(s.do(method, fmt.Sprintf(path, created.ID), nil, nil), http.StatusOK, nil)
+		resp := s.do("POST", "/api/employees", employee, key)
+		var retried domain.Employee
+		s.expect(resp, http.StatusCreated, &retried)
+		if resp.Header.Get("Idempotent-Replayed") != "" || retried.ID == created.ID {
+			t.Errorf("retry after %s replayed employee %d", method, created.ID)
+		}
+	}
+}
+
+// gatedRepository holds creates until release is closed or their deadline
+// passes, announcing each on entered.
+type gatedRepository struct {
+	domain.EmployeeRepository
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (r gatedRepository) Create(ctx context.Context, employee *domain.Employee) error {
+	r.entered <- struct{}{}
+	select {
+	case <-r.release:
+		return r.EmployeeRepository.Create(ctx, employee)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestIdempotencyKeyInProgress(t *testing.T) {
+	repo := gatedRepository{memory.NewEmployeeRepository(), make(chan struct{}, 1), make(chan struct{})}
+	s := newTestServerWithRepository(t, routerConfig{}, repo)
+	key := map[string]string{"Idempotency-Key": "k"}
+
+	first := make(chan *http.Response)
+	go func() { first <- s.do("POST", "/api/employees", validEmployee(), key) }()
+	<-repo.entered
+	s.expect(s.do("POST", "/api/employees", validEmployee(), key), http.StatusConflict, nil)
+
+	close(repo.release)
+	var created, replayed domain.Employee
+	s.expect(<-first, http.StatusCreated, &created)
+	s.expect(s.do("POST", "/api/employees", validEmployee(), key), http.StatusCreated, &replayed)
+	if replayed.ID != created.ID {
+		t.Errorf("retry created employee %d, expected %d replayed", replayed.ID, created.ID)
+	}
+}
+
+func TestIdempotencyKeyServerError(t *testing.T) {
+	repo := gatedRepository{memory.NewEmployeeRepository(), make(chan struct{}, 2), make(chan struct{})}
+	s := newTestServerWithRepository(t, routerConfig{
+		Timeouts: handler.RouteTimeouts{Default: 50 * time.Millisecond},
+	}, repo)
+	key := map[string]string{"Idempotency-Key": "k"}
+
+	// A timed out request is not kept, so its retry is processed
+	s.expect(s.do("POST", "/api/employees", validEmployee(), key), http.StatusGatewayTimeout, nil)
+	close(repo.release)
+	resp := s.do("POST", "/api/employees", validEmployee(), key)
+	s.expect(resp, http.StatusCreated, nil)
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("retry of a failed request was replayed")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/grpcapi"
+	"karyawan-app/internal/tracing"
+)
+
+// newGRPCServer serves the gRPC API on the same service as newRouter, with
+// the same quotas and deadlines. It shares the router's rate limiter, so a
+// client's HTTP requests and gRPC calls share one quota. Watchers read
+// the same broker and event log as /api/employees/stream. Every call gets a
+// server span, continuing the caller's trace like TracingMiddleware does.
+func newGRPCServer(employeeService domain.EmployeeService, cfg routerConfig) *grpc.Server {
+	traced := tracing.TraceEmployeeService(employeeService)
+	feed := events.NewFeed(cfg.Broker, cfg.EventLog, traced, events.FeedConfig{
+		PollInterval: cfg.EventPollInterval,
+		QueryTimeout: cfg.Timeouts.For("employees.watch"),
+	})
+	return grpcapi.NewServer(traced, feed, grpcapi.Config{
+		RateLimiter: cfg.RateLimiter,
+		OnReject:    cfg.Metrics.RateLimited,
+		Timeouts:    cfg.Timeouts,
+	}, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	handler "karyawan-app/internal/handler"
+	"karyawan-app/internal/lifecycle"
+	"karyawan-app/internal/metrics"
+	"karyawan-app/internal/openapi"
+	"karyawan-app/internal/region"
+	"karyawan-app/internal/repository/memory"
+	service "karyawan-app/internal/service"
+)
+
+// testServer boots the full router and middleware chain from newRouter on
+// top of in-memory repositories, so API tests need no database.
+type testServer struct {
+	*httptest.Server
+	lc *lifecycle.Lifecycle
+	t  *testing.T
+}
+
+func newTestServer(t *testing.T, cfg routerConfig) *testServer {
+	t.Helper()
+	return newTestServerWithRepository(t, cfg, memory.NewEmployeeRepository())
+}
+
+// rateLimiter returns a limiter enforcing limits with counts kept in
+// memory.
+func rateLimiter(limits handler.RateLimits) *handler.RateLimiter {
+	return handler.NewRateLimiter(limits, memory.NewRateLimitStore())
+}
+
+// newTestServerWithRepository is newTestServer with a caller supplied
+// employee repository, e.g. one that injects failures or latency.
+func newTestServerWithRepository(t *testing.T, cfg routerConfig, employees domain.EmployeeRepository) *testServer {
+	t.Helper()
+
+	regions, err := region.Default()
+	if err != nil {
+		t.Fatalf("region.Default() error: %v", err)
+	}
+	if cfg.RateLimiter == nil {
+		cfg.RateLimiter = rateLimiter(handler.RateLimits{Default: 1000})
+	}
+	if cfg.CORS.Default.AllowedOrigins == nil {
+		cfg.CORS.Default = handler.DefaultCORSPolicy()
+	}
+	if cfg.IdempotencyStore == nil {
+		cfg.IdempotencyStore = memory.NewIdempotencyStore()
+	}
+	if cfg.IdempotencyTTL == 0 {
+		cfg.IdempotencyTTL = time.Hour
+	}
+
+	if cfg.Metrics == nil {
+		cfg.Metrics = metrics.New()
+	}
+	if cfg.OpenAPI == nil {
+		spec, err := openapi.Load()
+		if err != nil {
+			t.Fatalf("openapi.Load() error: %v", err)
+		}
+		cfg.OpenAPI = spec
+	}
+	if cfg.Deprecations == nil {
+		cfg.Deprecations = apiDeprecations
+	}
+	// Every response of every API test is checked against the document
+	cfg.ValidateResponses = true
+
+	lc := lifecycle.New()
+	lc.SetReady(true)
+	employees = metrics.InstrumentEmployeeRepository(employees, cfg.Metrics)
+	audit := metrics.InstrumentAuditRepository(memory.NewAuditRepository(), cfg.Metrics)
+	if cfg.Broker == nil {
+		cfg.Broker = events.NewBroker()
+	}
+	if cfg.EventLog == nil {
+		cfg.EventLog = memory.NewEventLog()
+	}
+	employeeService := events.PublishEmployeeChanges(service.NewEmployeeService(employees, audit, regions), cfg.Broker, cfg.EventLog)
+	employeeService = service.ForgetErasedEmployees(employeeService, cfg.IdempotencyStore)
+	router, err := newRouter(lc, employeeService, regions, cfg)
+	if err != nil {
+		t.Fatalf("newRouter() error: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(router)
+	// Like the server in main, whose WriteTimeout event streams replace
+	srv.Config.WriteTimeout = cfg.Stream.WriteTimeout
+	srv.Start()
+	t.Cleanup(func() {
+		cfg.Broker.Close()
+		srv.Close()
+		lc.Shutdown(context.Background())
+	})
+	return &testServer{Server: srv, lc: lc, t: t}
+}
+
+// do sends a request with an optional JSON body and extra headers.
+func (s *testServer) do(method, path string, body interface{}, headers map[string]string) *http.Response {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		s.t.Fatalf("failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	s.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// expect asserts the status code and decodes the JSON body into v when v
+// is not nil.
+func (s *testServer) expect(resp *http.Response, status int, v interface{}) {
+	s.t.Helper()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != status {
+		s.t.Fatalf("%s %s = %d, expected %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, status, body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(body, v); err != nil {
+			s.t.Fatalf("failed to decode response %s: %v", body, err)
+		}
+	}
+}
+
+// scrape fetches /metrics and returns the exposition text.
+func (s *testServer) scrape() string {
+	s.t.Helper()
+
+	resp := s.do("GET", "/metrics", nil, nil)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		s.t.Fatalf("GET /metrics = %d: %s", resp.StatusCode, body)
+	}
+	return string(body)
+}
@@ -1,19 +1,39 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	_ "github.com/go-sql-driver/mysql"
+	"google.golang.org/grpc"
 
+	"karyawan-app/internal/config"
+	"karyawan-app/internal/database"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/graphapi"
 	handler "karyawan-app/internal/handler"
+	"karyawan-app/internal/lifecycle"
+	"karyawan-app/internal/logging"
+	"karyawan-app/internal/metrics"
+	"karyawan-app/internal/openapi"
+	"karyawan-app/internal/pii"
+	"karyawan-app/internal/region"
 	repo "karyawan-app/internal/repository"
+	"karyawan-app/internal/repository/memory"
 	service "karyawan-app/internal/service"
+	"karyawan-app/internal/tracing"
 )
 
 func main() {
@@ -22,79 +42,282 @@ func main() {
 		log.Printf("Warning: .env file not found, using environment variables")
 	}
 
-	// Initialize database connection
-	db := initDB()
-	defer db.Close()
+	// "config print" shows the effective configuration instead of serving
+	args := os.Args[1:]
+	printConfig := len(args) >= 2 && args[0] == "config" && args[1] == "print"
+	if printConfig {
+		args = args[2:]
+	}
+	cfg, err := config.Load(flag.CommandLine, args)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if printConfig {
+		if err := cfg.Write(os.Stdout); err != nil {
+			log.Fatalf("Error printing configuration: %v", err)
+		}
+		return
+	}
 
-	// Initialize repository, service, and handler
-	employeeRepo := repo.NewEmployeeRepository(db)
-	employeeService := service.NewEmployeeService(employeeRepo)
-	employeeHandler := handler.NewEmployeeHandler(employeeService)
+	// Structured JSON logs; the standard log package is routed through it
+	slog.SetDefault(logging.New(os.Stdout, logging.Config{Level: cfg.Log.Level, Sample2xx: cfg.Log.Sample2xx}))
 
-	// Create router
-	r := mux.NewRouter()
+	// Tracing must be set up before the database is opened so SQL
+	// statements are traced too
+	flushTraces, err := tracing.Setup(context.Background(), os.Stdout, cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("Error configuring tracing: %v", err)
+	}
 
-	// Apply middleware
-	middleware := handler.NewChain(
-		handler.CORSMiddleware,
-		handler.RateLimitMiddleware(100), // 100 requests per minute
-		handler.LoggingMiddleware,
-		handler.JSONContentTypeMiddleware,
-	)
+	// Initialize database connection
+	db, dialect := initDB(cfg.Database)
 
-	// Register routes
-	api := r.PathPrefix("/api").Subrouter()
-	employeeHandler.RegisterRoutes(api)
+	// Load the region reference dataset used for address validation
+	regions, err := region.Default()
+	if err != nil {
+		log.Fatalf("Error loading region dataset: %v", err)
+	}
 
-	// Serve static files from the frontend directory
-	frontendDir := "./frontend"
-	if _, err := os.Stat(frontendDir); !os.IsNotExist(err) {
-		r.PathPrefix("/").Handler(http.FileServer(http.Dir(frontendDir)))
+	// Load the API description requests are validated against
+	spec, err := openapi.Load()
+	if err != nil {
+		log.Fatalf("Error loading OpenAPI document: %v", err)
 	}
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Load the keyring used to encrypt PII columns at rest
+	keys, err := pii.LoadKeyring(cfg.PII)
+	if err != nil {
+		log.Fatalf("Error loading PII keys: %v", err)
+	}
+	if keys == nil {
+		slog.Warn("PII_MASTER_KEY/PII_KEY_FILE not set, personal data will be stored unencrypted")
+	}
+	// Email uniqueness rests on the blind index, so rows missing one are
+	// indexed before any request is served
+	if n, err := repo.BackfillBlindIndexes(context.Background(), db, dialect, keys); err != nil {
+		log.Fatalf("Error filling blind indexes: %v", err)
+	} else if n > 0 {
+		slog.Info("filled blind indexes", "employees", n)
 	}
 
+	// Initialize repository, service, and router
+	lc := lifecycle.New()
+	m := metrics.New()
+	m.RegisterDB(db, string(dialect))
+	employeeRepo := metrics.InstrumentEmployeeRepository(repo.NewEmployeeRepository(db, dialect, keys), m)
+	auditRepo := metrics.InstrumentAuditRepository(repo.NewAuditRepository(db, dialect), m)
+	eventLog := metrics.InstrumentEventLog(repo.NewEventLog(db, dialect), m)
+	idempotencyStore := metrics.InstrumentIdempotencyStore(repo.NewIdempotencyStore(db, dialect, keys), m)
+	broker := events.NewBroker()
+	employeeService := events.PublishEmployeeChanges(service.NewEmployeeService(employeeRepo, auditRepo, regions), broker, eventLog)
+	employeeService = service.ForgetErasedEmployees(employeeService, idempotencyStore)
+	lc.Go("event-log-prune", func(ctx context.Context) {
+		events.PruneLog(ctx, eventLog, cfg.Events.Retention)
+	})
+	routes := routerConfig{
+		RateLimiter:       handler.NewRateLimiter(rateLimits(cfg.RateLimit), rateLimitStore(cfg.RateLimit, db, dialect)),
+		CORS:              corsConfig(cfg),
+		FrontendDir:       cfg.Server.FrontendDir,
+		Timeouts:          handler.RouteTimeouts{Default: cfg.Database.Timeout, Routes: cfg.Database.RouteTimeouts},
+		Metrics:           m,
+		LogSample2xx:      cfg.Log.Sample2xx,
+		OpenAPI:           spec,
+		Deprecations:      apiDeprecations,
+		GraphQL:           graphapi.Limits{MaxDepth: cfg.GraphQL.MaxDepth, MaxComplexity: cfg.GraphQL.MaxComplexity},
+		Broker:            broker,
+		EventLog:          eventLog,
+		EventPollInterval: cfg.Events.PollInterval,
+		Stream:            handler.StreamConfig{Heartbeat: cfg.Events.Heartbeat, WriteTimeout: cfg.Server.WriteTimeout},
+		MaxBulkOperations: cfg.Bulk.MaxOperations,
+		IdempotencyStore:  idempotencyStore,
+		IdempotencyTTL:    cfg.Idempotency.TTL,
+		ReadinessChecks: []handler.HealthCheck{
+			{Name: "database", Check: db.PingContext},
+			{Name: "migrations", Check: func(ctx context.Context) error {
+				return repo.CheckSchemaVersion(ctx, db, dialect)
+			}},
+		},
+		Debug: handler.DebugStatus{
+			Token:   cfg.Debug.Token,
+			DB:      db,
+			Started: time.Now(),
+			Config:  cfg.Values(),
+		},
+	}
+	if lag := cfg.Events.MaxBacklogLag; lag > 0 {
+		routes.ReadinessChecks = append(routes.ReadinessChecks, handler.HealthCheck{
+			Name: "event_log", Check: events.CheckBacklog(eventLog, cfg.Events.Retention, lag),
+		})
+	}
+	router, err := newRouter(lc, employeeService, regions, routes)
+	if err != nil {
+		log.Fatalf("Error creating router: %v", err)
+	}
+
+	// Start server
 	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      middleware.Then(r),
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	// Both ports are bound before readiness is reported, so no probe
+	// passes while connections would still be refused
+	lis, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatalf("Error listening: %v", err)
 	}
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("server starting", "addr", server.Addr)
+		serverErr <- server.Serve(lis)
+	}()
 
-	log.Printf("Server starting on port %s\n", port)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Error starting server: %v\n", err)
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != 0 {
+		grpcServer = newGRPCServer(employeeService, routes)
+		addr := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.GRPCPort))
+		grpcLis, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("Error listening for gRPC: %v", err)
+		}
+		go func() {
+			slog.Info("gRPC server starting", "addr", addr)
+			serverErr <- grpcServer.Serve(grpcLis)
+		}()
 	}
+	lc.SetReady(true)
+
+	// Wait for SIGTERM/SIGINT or for the listener to fail
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("server failed", "error", err)
+		lc.Shutdown(context.Background())
+		db.Close()
+		os.Exit(1)
+	case <-ctx.Done():
+		stop()
+	}
+
+	shutdown(server, grpcServer, broker, lc, db, flushTraces, cfg.Server)
 }
 
-func initDB() *sql.DB {
-	dbUser := os.Getenv("DB_USER")
-	dbPass := os.Getenv("DB_PASSWORD")
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
+// shutdown stops the server in order: fail readiness so the load balancer
+// stops sending traffic, wait the drain delay for it to notice, let
+// in-flight HTTP requests and gRPC calls finish, ending watch streams,
+// stop background workers, close the database
+// pool and finally flush pending spans. Everything after the drain delay
+// shares the shutdown timeout.
+func shutdown(server *http.Server, grpcServer *grpc.Server, broker *events.Broker, lc *lifecycle.Lifecycle, db *sql.DB, flushTraces func(context.Context) error, cfg config.Server) {
+	slog.Info("shutting down")
+	lc.SetReady(false)
 
-	// Default values if not set
-	if dbUser == "" {
-		dbUser = "root"
+	if delay := cfg.ShutdownDrainDelay; delay > 0 {
+		slog.Info("waiting for load balancers to drain traffic", "delay", delay)
+		time.Sleep(delay)
 	}
-	if dbHost == "" {
-		dbHost = "localhost"
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Event and watch streams only end when their events stop, and both
+	// servers wait for them
+	broker.Close()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("failed to shut down HTTP server", "error", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+	if err := lc.Shutdown(ctx); err != nil {
+		slog.Error("failed to stop background workers", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+	if err := flushTraces(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// stopGRPC lets in-flight calls finish until ctx is done, then cancels the
+// rest.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("failed to shut down gRPC server", "error", ctx.Err())
+		s.Stop()
+	}
+}
+
+// rateLimitStore picks where request counts are kept: "memory" counts per
+// replica, "sql" shares them through the database so quotas hold across
+// every replica.
+func rateLimitStore(cfg config.RateLimit, db *sql.DB, dialect repo.Dialect) domain.RateLimitStore {
+	if cfg.Store == "sql" {
+		return repo.NewRateLimitStore(db, dialect)
+	}
+	return memory.NewRateLimitStore()
+}
+
+// rateLimits converts the validated quotas for the limiter.
+func rateLimits(cfg config.RateLimit) handler.RateLimits {
+	proxies, err := handler.ParseTrustedProxies(strings.Join(cfg.TrustedProxies, ","))
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	return handler.RateLimits{
+		Default:        cfg.Requests,
+		Tiers:          cfg.Tiers,
+		APIKeys:        cfg.APIKeys,
+		Routes:         cfg.Routes,
+		TrustedProxies: proxies,
+	}
+}
+
+// corsConfig builds the CORS policy from the policy file, when set, and
+// applies the configured origins, credentials and max age to its default
+// policy. Without a file, unset settings keep their defaults too.
+func corsConfig(cfg *config.Config) handler.CORSConfig {
+	policy := handler.CORSConfig{Default: handler.DefaultCORSPolicy()}
+	fromFile := cfg.CORS.PolicyFile != ""
+	if fromFile {
+		var err error
+		if policy, err = handler.LoadCORSConfigFile(cfg.CORS.PolicyFile); err != nil {
+			log.Fatalf("Invalid CORS policy file: %v", err)
+		}
+	}
+	set := func(key string) bool { return !fromFile || cfg.IsSet("cors."+key) }
+	if set("allowed_origins") {
+		policy.Default.AllowedOrigins = cfg.CORS.AllowedOrigins
+	}
+	if set("allow_credentials") {
+		policy.Default.AllowCredentials = cfg.CORS.AllowCredentials
 	}
-	if dbPort == "" {
-		dbPort = "3306"
+	if set("max_age") {
+		policy.Default.MaxAge = cfg.CORS.MaxAge
 	}
-	if dbName == "" {
-		dbName = "karyawan_db"
+
+	if err := policy.Validate(); err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
 	}
+	return policy
+}
 
-	dsn := dbUser + ":" + dbPass + "@tcp(" + dbHost + ":" + dbPort + ")/" + dbName + "?parseTime=true"
-	db, err := sql.Open("mysql", dsn)
+func initDB(cfg config.Database) (*sql.DB, repo.Dialect) {
+	db, dialect, err := database.Open(cfg)
 	if err != nil {
 		log.Fatalf("Error connecting to database: %v", err)
 	}
@@ -105,32 +328,16 @@ func initDB() *sql.DB {
 	}
 
 	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
+	if dialect != repo.SQLite {
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(25)
+	}
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	// Run migrations
-	runMigrations(db)
-
-	return db
-}
-
-func runMigrations(db *sql.DB) {
-	// Create employees table if not exists
-	query := `
-	CREATE TABLE IF NOT EXISTS employees (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		name VARCHAR(100) NOT NULL,
-		email VARCHAR(100) NOT NULL UNIQUE,
-		role VARCHAR(50) NOT NULL,
-		phone VARCHAR(20) NOT NULL,
-		alamat TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-	`
-
-	if _, err := db.Exec(query); err != nil {
-		log.Fatalf("Error creating employees table: %v", err)
+	if err := repo.Migrate(db, dialect); err != nil {
+		log.Fatalf("Error running migrations: %v", err)
 	}
+
+	return db, dialect
 }
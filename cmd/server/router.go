@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/graphapi"
+	handler "karyawan-app/internal/handler"
+	"karyawan-app/internal/lifecycle"
+	"karyawan-app/internal/metrics"
+	"karyawan-app/internal/openapi"
+	"karyawan-app/internal/region"
+	"karyawan-app/internal/tracing"
+)
+
+// apiDeprecations is the registry of deprecated API versions and routes,
+// see handler.Deprecations. The unversioned /api routes predate versioning
+// and alias v2; v1 keeps the contract of the original server for clients
+// that still depend on it.
+var apiDeprecations = handler.Deprecations{
+	"v1": {
+		Since:     time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC),
+		Successor: "/api/v2",
+	},
+	"unversioned": {
+		Since:     time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Successor: "/api/v2",
+	},
+}
+
+// routerConfig holds the settings that differ between production and the
+// test harness.
+type routerConfig struct {
+	// RateLimiter enforces the per-client quotas. The gRPC server shares
+	// it, so both count against the same quotas; newRouter sets its HTTP
+	// hooks.
+	RateLimiter *handler.RateLimiter
+	// CORS is the cross-origin policy, per route.
+	CORS handler.CORSConfig
+	// FrontendDir is served at / when it exists. Empty disables it.
+	FrontendDir string
+	// Timeouts are the per-route deadlines for service and database work.
+	Timeouts handler.RouteTimeouts
+	// ReadinessChecks are the dependency checks behind /readyz.
+	ReadinessChecks []handler.HealthCheck
+	// LogSample2xx logs one in every LogSample2xx successful requests.
+	LogSample2xx int
+	// Metrics records request, query and rate limit metrics and is served
+	// at /metrics.
+	Metrics *metrics.Metrics
+	// Debug configures the authenticated /debug/status endpoint.
+	Debug handler.DebugStatus
+	// OpenAPI is the API description requests are validated against. It
+	// is served at /api/openapi.json with Swagger UI at /api/docs.
+	OpenAPI *openapi.Spec
+	// ValidateResponses checks every API response against OpenAPI as well.
+	// The test harness enables it.
+	ValidateResponses bool
+	// Deprecations lists the deprecated API versions and routes, which are
+	// answered with Deprecation and Sunset headers.
+	Deprecations handler.Deprecations
+	// GraphQL bounds the queries served at /graphql.
+	GraphQL graphapi.Limits
+	// Broker carries the employee changes made through this server, and
+	// EventLog those made through every replica, which are served at
+	// /api/employees/stream. EventLog is read every EventPollInterval, and
+	// with the employees.stream deadline of Timeouts.
+	Broker            *events.Broker
+	EventLog          domain.EventLog
+	EventPollInterval time.Duration
+	// Stream configures /api/employees/stream.
+	Stream handler.StreamConfig
+	// MaxBulkOperations caps the operations of a request to
+	// /api/employees/bulk.
+	MaxBulkOperations int
+	// IdempotencyStore keeps the responses of API requests sent with an
+	// Idempotency-Key for IdempotencyTTL.
+	IdempotencyStore domain.IdempotencyStore
+	IdempotencyTTL   time.Duration
+}
+
+// newRouter wires the HTTP handlers and the middleware chain around the
+// employee service. It is the complete request path of the server, minus
+// the listener. Background work it starts is owned by lc.
+func newRouter(lc *lifecycle.Lifecycle, employeeService domain.EmployeeService, regions *region.Directory, cfg routerConfig) (http.Handler, error) {
+	cfg.Metrics.RegisterHeadcount(employeeService.HeadcountByRole)
+
+	traced := tracing.TraceEmployeeService(employeeService)
+	employeeHandler := handler.NewEmployeeHandler(traced)
+	employeeHandler.MaxBulkOperations = cfg.MaxBulkOperations
+	regionHandler := handler.NewRegionHandler(regions)
+	feed := events.NewFeed(cfg.Broker, cfg.EventLog, traced, events.FeedConfig{
+		PollInterval: cfg.EventPollInterval,
+		QueryTimeout: cfg.Timeouts.For("employees.stream"),
+	})
+	streamHandler := handler.NewStreamHandler(feed, cfg.Stream)
+	schema, err := graphapi.New(traced, cfg.GraphQL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create router
+	r := mux.NewRouter()
+
+	// routeName resolves the route for middleware running before mux
+	routeName := func(req *http.Request) string {
+		var match mux.RouteMatch
+		if r.Match(req, &match) && match.Route != nil {
+			return match.Route.GetName()
+		}
+		return ""
+	}
+
+	cors := handler.NewCORS(cfg.CORS)
+	cors.RouteName = routeName
+
+	rateLimiter := cfg.RateLimiter
+	rateLimiter.OnReject = func(*http.Request) { cfg.Metrics.RateLimited() }
+	rateLimiter.RouteName = routeName
+	lc.Go("rate-limit-cleanup", rateLimiter.Cleanup)
+
+	idempotency := handler.NewIdempotency(cfg.IdempotencyStore, cfg.IdempotencyTTL)
+	lc.Go("idempotency-cleanup", idempotency.Cleanup)
+
+	// Apply middleware
+	middleware := handler.NewChain(
+		handler.NamedMiddleware{Name: "RequestID", Middleware: handler.RequestIDMiddleware},
+		handler.NamedMiddleware{Name: "Logging", Middleware: handler.NewLoggingMiddleware(cfg.LogSample2xx)},
+		handler.NamedMiddleware{Name: "CORS", Middleware: cors.Middleware},
+		handler.NamedMiddleware{Name: "RateLimit", Middleware: rateLimiter.Middleware},
+		handler.NamedMiddleware{Name: "JSONContentType", Middleware: handler.JSONContentTypeMiddleware},
+	)
+
+	// Register routes. Every API version has its own subrouter; v1 serves
+	// the current handlers through V1Middleware, which translates their
+	// responses to the original contract, so it must run outside the
+	// validation of the v2 contract. The unversioned routes alias v2.
+	r.Use(cfg.Metrics.Middleware)
+	api := r.PathPrefix("/api").Subrouter()
+	v1 := api.PathPrefix("/v1").Subrouter()
+	v2 := api.PathPrefix("/v2").Subrouter()
+	unversioned := api.NewRoute().Subrouter()
+	v1.Use(handler.V1Middleware)
+	for _, version := range []struct {
+		name, prefix string
+		router       *mux.Router
+	}{
+		{"v1", "/api/v1", v1},
+		{"v2", "/api/v2", v2},
+		{"unversioned", "/api", unversioned},
+	} {
+		version.router.Use(
+			handler.DeprecationMiddleware(version.name, version.prefix, cfg.Deprecations),
+			handler.TimeoutMiddleware(cfg.Timeouts),
+			idempotency.Middleware,
+			handler.ValidationMiddleware(cfg.OpenAPI, cfg.ValidateResponses),
+		)
+	}
+	employeeHandler.RegisterV1Routes(v1)
+	for _, router := range []*mux.Router{v2, unversioned} {
+		streamHandler.RegisterRoutes(router)
+		employeeHandler.RegisterRoutes(router)
+		regionHandler.RegisterRoutes(router)
+		handler.NewDocsHandler().RegisterRoutes(router)
+	}
+
+	// GraphQL is not versioned by path; the schema evolves by adding
+	// fields and deprecating old ones
+	graphql := r.NewRoute().Subrouter()
+	graphql.Use(handler.TimeoutMiddleware(cfg.Timeouts))
+	handler.NewGraphQLHandler(schema).RegisterRoutes(graphql)
+
+	// Serve static files from the frontend directory
+	if cfg.FrontendDir != "" {
+		if _, err := os.Stat(cfg.FrontendDir); !os.IsNotExist(err) {
+			r.PathPrefix("/").Handler(http.FileServer(http.Dir(cfg.FrontendDir)))
+		}
+	}
+
+	// Probes and metrics bypass the middleware chain so they are never rate
+	// limited
+	healthHandler := handler.NewHealthHandler(lc, cfg.ReadinessChecks...)
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", healthHandler.Liveness)
+	root.HandleFunc("GET /readyz", healthHandler.Readiness)
+	root.Handle("GET /metrics", cfg.Metrics.Handler())
+	if cfg.Debug.Token != "" {
+		root.Handle("GET /debug/status", handler.DebugStatusHandler(cfg.Debug))
+	}
+	root.Handle("/", handler.TracingMiddleware(middleware.Then(r)))
+	return root, nil
+}
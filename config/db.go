@@ -3,29 +3,37 @@ package config
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
-	"os"
+	"strconv"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
+
+	appconfig "karyawan-app/internal/config"
 )
 
 var DB *sql.DB
 
-func ConnectDB() error {
-	// Load environment variables
-	err := godotenv.Load()
-	if err != nil {
-		return fmt.Errorf("error loading .env file: %w", err)
+// Load reads the shared configuration from .env, CONFIG_FILE and the
+// environment. The .env file is optional.
+func Load() (*appconfig.Config, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found, using environment variables")
 	}
+	return appconfig.Load(flag.NewFlagSet("karyawan-app", flag.ContinueOnError), nil)
+}
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
+// ConnectDB opens DB with the database settings of cfg, as returned by
+// Load, and runs the migrations.
+func ConnectDB(cfg *appconfig.Config) error {
+	host := cfg.Database.Host
+	port := strconv.Itoa(cfg.Database.Port)
+	user := cfg.Database.User
+	password := cfg.Database.Password
+	dbName := cfg.Database.Name
 
 	// First connect without database to create it if needed
 	rootDB, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/", user, password, host, port))
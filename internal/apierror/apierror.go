@@ -0,0 +1,132 @@
+// Package apierror classifies service errors for the REST and gRPC APIs,
+// so both answer the same failure with the same status and message.
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	"karyawan-app/internal/domain"
+)
+
+// Kind is a class of failure, mapped to an HTTP status and a gRPC code.
+type Kind int
+
+const (
+	Internal Kind = iota
+	Invalid
+	NotFound
+	Conflict
+	Timeout
+	// Canceled means the client went away; nothing is sent back.
+	Canceled
+	RateLimited
+	// Aborted is an operation of an atomic bulk request rolled back
+	// because another one failed.
+	Aborted
+)
+
+// Messages shared by both APIs.
+const (
+	MsgNotFound    = "Employee not found"
+	MsgTimeout     = "Database operation timed out"
+	MsgRateLimited = "Rate limit exceeded"
+)
+
+// Describe classifies err and picks the message for the client. Deadlines,
+// cancellations, edits of anonymized employees and the bulk operation
+// errors of domain are recognized; anything else is of kind fallback and
+// reported as message.
+func Describe(err error, fallback Kind, message string) (Kind, string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout, MsgTimeout
+	case errors.Is(err, context.Canceled):
+		return Canceled, err.Error()
+	case errors.Is(err, domain.ErrEmployeeAnonymized):
+		return Conflict, err.Error()
+	case errors.Is(err, domain.ErrEmployeeNotFound):
+		return NotFound, MsgNotFound
+	case errors.Is(err, domain.ErrBulkAborted):
+		return Aborted, err.Error()
+	default:
+		return fallback, message
+	}
+}
+
+// HTTPStatus returns the status code REST responses of kind k carry.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case Invalid:
+		return http.StatusBadRequest
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict:
+		return http.StatusConflict
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case Canceled:
+		// nginx's "client closed request", never actually sent
+		return 499
+	case RateLimited:
+		return http.StatusTooManyRequests
+	case Aborted:
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode returns the status code gRPC responses of kind k carry.
+func (k Kind) GRPCCode() codes.Code {
+	switch k {
+	case Invalid:
+		return codes.InvalidArgument
+	case NotFound:
+		return codes.NotFound
+	case Conflict:
+		return codes.FailedPrecondition
+	case Timeout:
+		return codes.DeadlineExceeded
+	case Canceled:
+		return codes.Canceled
+	case RateLimited:
+		return codes.ResourceExhausted
+	case Aborted:
+		return codes.Aborted
+	default:
+		return codes.Internal
+	}
+}
+
+// String returns the name of kind k, e.g. NOT_FOUND, which GraphQL errors
+// carry as extensions.code.
+func (k Kind) String() string {
+	switch k {
+	case Invalid:
+		return "INVALID_ARGUMENT"
+	case NotFound:
+		return "NOT_FOUND"
+	case Conflict:
+		return "CONFLICT"
+	case Timeout:
+		return "TIMEOUT"
+	case Canceled:
+		return "CANCELED"
+	case RateLimited:
+		return "RATE_LIMITED"
+	case Aborted:
+		return "ABORTED"
+	default:
+		return "INTERNAL"
+	}
+}
+
+// ServerError reports whether kind k is the server's fault and worth
+// logging as an error.
+func (k Kind) ServerError() bool {
+	return k == Internal || k == Timeout
+}
@@ -0,0 +1,47 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"karyawan-app/internal/domain"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fallback Kind
+		kind     Kind
+		message  string
+		status   int
+		code     codes.Code
+	}{
+		{"validation", errors.New("name is required"), Invalid, Invalid, "name is required", http.StatusBadRequest, codes.InvalidArgument},
+		{"database failure", errors.New("connection refused"), Internal, Internal, "connection refused", http.StatusInternalServerError, codes.Internal},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), Invalid, Timeout, MsgTimeout, http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{"anonymized", domain.ErrEmployeeAnonymized, Invalid, Conflict, domain.ErrEmployeeAnonymized.Error(), http.StatusConflict, codes.FailedPrecondition},
+		{"not found", domain.ErrEmployeeNotFound, Invalid, NotFound, MsgNotFound, http.StatusNotFound, codes.NotFound},
+		{"bulk aborted", domain.ErrBulkAborted, Invalid, Aborted, domain.ErrBulkAborted.Error(), http.StatusFailedDependency, codes.Aborted},
+		{"canceled", context.Canceled, Internal, Canceled, context.Canceled.Error(), 499, codes.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, message := Describe(tt.err, tt.fallback, tt.err.Error())
+			if kind != tt.kind || message != tt.message {
+				t.Errorf("Describe() = %v, %q, expected %v, %q", kind, message, tt.kind, tt.message)
+			}
+			if kind.HTTPStatus() != tt.status {
+				t.Errorf("HTTPStatus() = %d, expected %d", kind.HTTPStatus(), tt.status)
+			}
+			if kind.GRPCCode() != tt.code {
+				t.Errorf("GRPCCode() = %v, expected %v", kind.GRPCCode(), tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+// Package backup writes and restores logical backups of the employee
+// database.
+//
+// An archive is a gzip compressed tar file holding manifest.json followed
+// by one NDJSON file per table, one row per line keyed by column name. The
+// manifest records the archive format, the schema version of the source
+// database and a row count and SHA-256 checksum per table. Rows are copied
+// as stored, so encrypted PII stays encrypted and restoring it requires
+// the same PII keys. Values are independent of the SQL dialect, so an
+// archive can be restored into any supported backend.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	repo "karyawan-app/internal/repository"
+)
+
+// FormatVersion is the archive layout written by Backup. Restore rejects
+// archives of other versions.
+const FormatVersion = 1
+
+const manifestName = "manifest.json"
+
+// Manifest describes an archive.
+type Manifest struct {
+	FormatVersion int             `json:"format_version"`
+	SchemaVersion string          `json:"schema_version"`
+	Dialect       string          `json:"dialect"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Tables        []TableManifest `json:"tables"`
+}
+
+// TableManifest describes the NDJSON file of one table.
+type TableManifest struct {
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Columns []string `json:"columns"`
+	Rows    int      `json:"rows"`
+	SHA256  string   `json:"sha256"`
+}
+
+// Table returns the manifest of the named table, or nil.
+func (m *Manifest) Table(name string) *TableManifest {
+	for i := range m.Tables {
+		if m.Tables[i].Name == name {
+			return &m.Tables[i]
+		}
+	}
+	return nil
+}
+
+type columnKind int
+
+const (
+	intColumn columnKind = iota
+	stringColumn
+	timeColumn
+)
+
+type column struct {
+	name string
+	kind columnKind
+}
+
+type table struct {
+	name    string
+	columns []column
+}
+
+// tables are the tables backed up, in restore order. rate_limit_counters
+// only holds the current minute's request counts, employee_events is a
+// short-lived change feed and idempotency_keys holds responses kept for
+// client retries, so they are left out.
+var tables = []table{
+	{name: "employees", columns: []column{
+		{"id", intColumn},
+		{"name", stringColumn},
+		{"email", stringColumn},
+		{"position", stringColumn},
+		{"role", stringColumn},
+		{"phone", stringColumn},
+		{"alamat", stringColumn},
+		{"address_street", stringColumn},
+		{"address_rt", stringColumn},
+		{"address_rw", stringColumn},
+		{"address_kelurahan", stringColumn},
+		{"address_kecamatan", stringColumn},
+		{"address_kota", stringColumn},
+		{"address_provinsi", stringColumn},
+		{"address_kode_pos", stringColumn},
+		{"email_bidx", stringColumn},
+		{"phone_bidx", stringColumn},
+		{"created_at", timeColumn},
+		{"updated_at", timeColumn},
+		{"anonymized_at", timeColumn},
+	}},
+	{name: "employee_audit_log", columns: []column{
+		{"id", intColumn},
+		{"employee_id", intColumn},
+		{"action", stringColumn},
+		{"fields", stringColumn},
+		{"created_at", timeColumn},
+	}},
+}
+
+func (t table) column(name string) (column, bool) {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// Backup writes an archive of db to w. The tables are read in one
+// transaction, so the archive is a consistent snapshot even while the
+// application keeps writing. The schema must be at the version this build
+// migrates to.
+func Backup(ctx context.Context, db *sql.DB, d repo.Dialect, w io.Writer) (*Manifest, error) {
+	opts := &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead}
+	if d == repo.SQLite {
+		// SQLite transactions are always serializable
+		opts = &sql.TxOptions{}
+	}
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var version sql.NullString
+	if err := tx.QueryRowContext(ctx, "SELECT MAX(version) FROM migrations").Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version.String != repo.SchemaVersion() {
+		return nil, fmt.Errorf("database schema is at %q, expected %s; run the migrations first", version.String, repo.SchemaVersion())
+	}
+
+	m := &Manifest{
+		FormatVersion: FormatVersion,
+		SchemaVersion: version.String,
+		Dialect:       string(d),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	// Tar headers carry the size, so every table is staged in a temporary
+	// file before the archive is written
+	files := make([]*os.File, 0, len(tables))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	for _, t := range tables {
+		f, err := os.CreateTemp("", "karyawan-backup-*.ndjson")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		tm, err := dumpTable(ctx, tx, t, f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", t.name, err)
+		}
+		m.Tables = append(m.Tables, tm)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, manifestName, int64(len(manifest)), m.CreatedAt, bytes.NewReader(manifest)); err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		size, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := writeEntry(tw, m.Tables[i].File, size, m.CreatedAt, f); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o600,
+		Size:     size,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// dumpTable writes the rows of t to w as NDJSON, in id order.
+func dumpTable(ctx context.Context, tx *sql.Tx, t table, w io.Writer) (TableManifest, error) {
+	names := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		names[i] = c.name
+	}
+	tm := TableManifest{Name: t.name, File: t.name + ".ndjson", Columns: names}
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+strings.Join(names, ", ")+" FROM "+t.name+" ORDER BY id")
+	if err != nil {
+		return tm, err
+	}
+	defer rows.Close()
+
+	h := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(w, h))
+	enc := json.NewEncoder(bw)
+	dest := make([]interface{}, len(t.columns))
+	for i, c := range t.columns {
+		switch c.kind {
+		case intColumn:
+			dest[i] = new(sql.NullInt64)
+		case stringColumn:
+			dest[i] = new(sql.NullString)
+		case timeColumn:
+			dest[i] = new(sql.NullTime)
+		}
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return tm, err
+		}
+		row := make(map[string]interface{}, len(dest))
+		for i, c := range t.columns {
+			row[c.name] = jsonValue(dest[i])
+		}
+		if err := enc.Encode(row); err != nil {
+			return tm, err
+		}
+		tm.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return tm, err
+	}
+	if err := bw.Flush(); err != nil {
+		return tm, err
+	}
+	tm.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return tm, nil
+}
+
+// jsonValue converts a scanned column to its NDJSON value. Times are
+// written in UTC with nanoseconds so no backend loses precision on the
+// way through.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *sql.NullInt64:
+		if v.Valid {
+			return v.Int64
+		}
+	case *sql.NullString:
+		if v.Valid {
+			return v.String
+		}
+	case *sql.NullTime:
+		if v.Valid {
+			return v.Time.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return nil
+}
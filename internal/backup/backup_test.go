@@ -0,0 +1,288 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "karyawan-app/internal/database" // registers the SQL drivers
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
+	repo "karyawan-app/internal/repository"
+)
+
+var ctx = context.Background()
+
+// openDB returns an empty, unmigrated SQLite database, or a cleared MySQL
+// or PostgreSQL database when TEST_MYSQL_DSN or TEST_POSTGRES_DSN is set.
+func openDB(t *testing.T, d repo.Dialect) *sql.DB {
+	t.Helper()
+	var dsn string
+	switch d {
+	case repo.SQLite:
+		dsn = "file:" + filepath.Join(t.TempDir(), "test.db")
+	case repo.MySQL:
+		dsn = os.Getenv("TEST_MYSQL_DSN")
+	case repo.Postgres:
+		dsn = os.Getenv("TEST_POSTGRES_DSN")
+	}
+	if dsn == "" {
+		t.Skipf("no test database configured for %s", d)
+	}
+	db, err := sql.Open(d.DriverName(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if d != repo.SQLite {
+		if err := repo.Migrate(db, d); err != nil {
+			t.Fatal(err)
+		}
+		for _, table := range []string{"employees", "employee_audit_log"} {
+			if _, err := db.Exec("DELETE FROM " + table); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return db
+}
+
+func testKeyring(t *testing.T) *pii.Keyring {
+	t.Helper()
+	keys, err := pii.NewKeyring("test", map[string][]byte{"test": bytes.Repeat([]byte{1}, 32)}, bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// source returns a SQLite database holding three encrypted employees, one
+// of them anonymized, with their audit entries.
+func source(t *testing.T) *sql.DB {
+	t.Helper()
+	db := openDB(t, repo.SQLite)
+	if err := repo.Migrate(db, repo.SQLite); err != nil {
+		t.Fatal(err)
+	}
+	employees := repo.NewEmployeeRepository(db, repo.SQLite, testKeyring(t))
+	audit := repo.NewAuditRepository(db, repo.SQLite)
+	for i, name := range []string{"Siti Rahayu", "Budi Santoso", "Dewi Lestari"} {
+		e := &domain.Employee{
+			Name: name, Email: strings.ToLower(strings.Fields(name)[0]) + "@example.com",
+			Position: "Akuntan", Role: "Staff", Phone: "08123456789" + string(rune('0'+i)), Alamat: "Jl. Braga No. 1, Bandung",
+		}
+		if i == 1 {
+			e.Address = &domain.Address{Street: "Jl. Braga No. 1", RT: "001", RW: "002", Kelurahan: "Braga",
+				Kecamatan: "Sumur Bandung", KotaKabupaten: "Kota Bandung", Provinsi: "Jawa Barat", KodePos: "40111"}
+		}
+		if err := employees.Create(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		if err := audit.Record(ctx, &domain.AuditEntry{EmployeeID: e.ID, Action: domain.AuditCreate, Fields: []string{"name", "email"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e, _ := employees.FindByID(ctx, 3)
+	e.Name, e.Email = "Anonim", "anon-3@invalid"
+	if err := employees.Anonymize(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func backup(t *testing.T, db *sql.DB) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	m, err := Backup(ctx, db, repo.SQLite, &buf)
+	if err != nil {
+		t.Fatalf("Backup() error: %v", err)
+	}
+	if m.SchemaVersion != repo.SchemaVersion() || m.Table("employees").Rows != 3 || m.Table("employee_audit_log").Rows != 3 {
+		t.Fatalf("Backup() manifest = %+v", m)
+	}
+	return buf.Bytes()
+}
+
+// snapshot returns every employee and audit entry, decrypted.
+func snapshot(t *testing.T, db *sql.DB, d repo.Dialect) ([]domain.Employee, []domain.AuditEntry) {
+	t.Helper()
+	employees, err := repo.NewEmployeeRepository(db, d, testKeyring(t)).FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []domain.AuditEntry
+	audit := repo.NewAuditRepository(db, d)
+	for _, e := range employees {
+		got, err := audit.FindByEmployee(ctx, e.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, got...)
+	}
+	for i := range employees {
+		// Backends differ in timestamp precision
+		employees[i].CreatedAt = employees[i].CreatedAt.Truncate(1e9).UTC()
+		employees[i].UpdatedAt = employees[i].UpdatedAt.Truncate(1e9).UTC()
+		if at := employees[i].AnonymizedAt; at != nil {
+			*at = at.Truncate(1e9).UTC()
+		}
+	}
+	for i := range entries {
+		entries[i].CreatedAt = entries[i].CreatedAt.Truncate(1e9).UTC()
+	}
+	return employees, entries
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := source(t)
+	archive := backup(t, src)
+	wantEmployees, wantAudit := snapshot(t, src, repo.SQLite)
+
+	for _, d := range []repo.Dialect{repo.SQLite, repo.MySQL, repo.Postgres} {
+		t.Run(string(d), func(t *testing.T) {
+			dst := openDB(t, d)
+			if _, err := Restore(ctx, dst, d, bytes.NewReader(archive), RestoreOptions{}); err != nil {
+				t.Fatalf("Restore() error: %v", err)
+			}
+			gotEmployees, gotAudit := snapshot(t, dst, d)
+			if !reflect.DeepEqual(gotEmployees, wantEmployees) {
+				t.Errorf("restored employees:\n%+v\nexpected:\n%+v", gotEmployees, wantEmployees)
+			}
+			if !reflect.DeepEqual(gotAudit, wantAudit) {
+				t.Errorf("restored audit log:\n%+v\nexpected:\n%+v", gotAudit, wantAudit)
+			}
+
+			// New rows continue after the restored IDs
+			e := &domain.Employee{Name: "Baru", Email: "baru@example.com", Position: "P", Role: "Staff", Phone: "0811111111", Alamat: "A"}
+			if err := repo.NewEmployeeRepository(dst, d, testKeyring(t)).Create(ctx, e); err != nil || e.ID != 4 {
+				t.Errorf("Create() after restore = id %d, %v, expected id 4", e.ID, err)
+			}
+		})
+	}
+}
+
+// rewrite returns archive with the content of file replaced by edit.
+func rewrite(t *testing.T, archive []byte, file string, edit func([]byte) []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(tr)
+		if hdr.Name == file {
+			content = edit(content)
+		}
+		hdr.Size = int64(len(content))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(content)
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestVerifyRejectsDamagedArchives(t *testing.T) {
+	archive := backup(t, source(t))
+	if _, err := Verify(bytes.NewReader(archive)); err != nil {
+		t.Fatalf("Verify() error on an intact archive: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		file string
+		edit func([]byte) []byte
+		want string
+	}{
+		"tampered row": {"employees.ndjson", func(b []byte) []byte {
+			return bytes.Replace(b, []byte("Budi"), []byte("Joko"), 1)
+		}, "checksum mismatch for employees.ndjson"},
+		"newer schema": {"manifest.json", func(b []byte) []byte {
+			return bytes.Replace(b, []byte(repo.SchemaVersion()), []byte("999_future"), 1)
+		}, "newer than"},
+		"unknown column": {"manifest.json", func(b []byte) []byte {
+			return bytes.Replace(b, []byte(`"anonymized_at"`), []byte(`"salary"`), 1)
+		}, "unknown column employees.salary"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			damaged := rewrite(t, archive, tc.file, tc.edit)
+			if _, err := Verify(bytes.NewReader(damaged)); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Verify() error = %v, expected %q", err, tc.want)
+			}
+
+			// Nothing is written, not even the schema
+			dst := openDB(t, repo.SQLite)
+			if _, err := Restore(ctx, dst, repo.SQLite, bytes.NewReader(damaged), RestoreOptions{}); err == nil {
+				t.Error("Restore() accepted a damaged archive")
+			}
+			var n int
+			if err := dst.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&n); err != nil || n != 0 {
+				t.Errorf("target database has %d schema objects after a rejected restore", n)
+			}
+		})
+	}
+	if _, err := Verify(strings.NewReader("not an archive")); err == nil {
+		t.Error("Verify() accepted garbage")
+	}
+}
+
+func TestRestoreRequiresEmptyDatabase(t *testing.T) {
+	src := source(t)
+	archive := backup(t, src)
+	_, err := Restore(ctx, src, repo.SQLite, bytes.NewReader(archive), RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "employees already holds 3 rows") {
+		t.Errorf("Restore() into a non-empty database error = %v", err)
+	}
+}
+
+func TestRestoreSingleEmployee(t *testing.T) {
+	src := source(t)
+	archive := backup(t, src)
+	before, _ := snapshot(t, src, repo.SQLite)
+
+	if _, err := src.Exec("DELETE FROM employees WHERE id = 2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(ctx, src, repo.SQLite, bytes.NewReader(archive), RestoreOptions{EmployeeID: 2}); err != nil {
+		t.Fatalf("Restore(employee 2) error: %v", err)
+	}
+	after, _ := snapshot(t, src, repo.SQLite)
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("employees after restoring employee 2:\n%+v\nexpected:\n%+v", after, before)
+	}
+	entries, _ := repo.NewAuditRepository(src, repo.SQLite).FindByEmployee(ctx, 2)
+	if len(entries) != 2 || entries[1].Action != domain.AuditRestore {
+		t.Errorf("audit log of employee 2 = %+v, expected the restore to be recorded", entries)
+	}
+
+	if _, err := Restore(ctx, src, repo.SQLite, bytes.NewReader(archive), RestoreOptions{EmployeeID: 2}); !errors.Is(err, ErrEmployeeExists) {
+		t.Errorf("restoring an existing employee error = %v", err)
+	}
+	if _, err := Restore(ctx, src, repo.SQLite, bytes.NewReader(archive), RestoreOptions{EmployeeID: 2, Replace: true}); err != nil {
+		t.Errorf("Restore(Replace) error: %v", err)
+	}
+	if _, err := Restore(ctx, src, repo.SQLite, bytes.NewReader(archive), RestoreOptions{EmployeeID: 42}); err == nil || !strings.Contains(err.Error(), "not in the backup") {
+		t.Errorf("restoring a missing employee error = %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"karyawan-app/internal/domain"
+	repo "karyawan-app/internal/repository"
+)
+
+// ErrEmployeeExists is returned when restoring a single employee whose ID
+// is taken, unless RestoreOptions.Replace is set.
+var ErrEmployeeExists = errors.New("employee already exists")
+
+// RestoreOptions select what Restore writes.
+type RestoreOptions struct {
+	// EmployeeID, when not zero, restores only that employee, keeping
+	// its ID, into a database that may hold other employees.
+	EmployeeID int
+	// Replace lets a single employee restore overwrite the employee with
+	// the same ID instead of failing.
+	Replace bool
+}
+
+// archive reads the entries of an archive after its manifest.
+type archive struct {
+	*tar.Reader
+	gz       *gzip.Reader
+	manifest *Manifest
+}
+
+// openArchive reads the manifest of the archive in r and checks that this
+// build can restore it.
+func openArchive(r io.Reader) (*archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	a := &archive{Reader: tar.NewReader(gz), gz: gz}
+	hdr, err := a.Next()
+	if err != nil || hdr.Name != manifestName {
+		gz.Close()
+		return nil, fmt.Errorf("not a backup archive: %s must be the first entry", manifestName)
+	}
+	var m Manifest
+	if err := json.NewDecoder(a).Decode(&m); err != nil {
+		gz.Close()
+		return nil, fmt.Errorf("invalid %s: %w", manifestName, err)
+	}
+	if err := m.check(); err != nil {
+		gz.Close()
+		return nil, err
+	}
+	a.manifest = &m
+	return a, nil
+}
+
+// check rejects manifests this build cannot restore.
+func (m *Manifest) check() error {
+	if m.FormatVersion != FormatVersion {
+		return fmt.Errorf("unsupported archive format version %d, expected %d", m.FormatVersion, FormatVersion)
+	}
+	// Versions are numbered, so they compare as strings
+	if m.SchemaVersion > repo.SchemaVersion() {
+		return fmt.Errorf("archive schema version %s is newer than %s; restore it with a newer build", m.SchemaVersion, repo.SchemaVersion())
+	}
+	for _, tm := range m.Tables {
+		t, ok := findTable(tm.Name)
+		if !ok {
+			return fmt.Errorf("archive holds unknown table %q", tm.Name)
+		}
+		for _, name := range tm.Columns {
+			if _, ok := t.column(name); !ok {
+				return fmt.Errorf("archive holds unknown column %s.%s", tm.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+func findTable(name string) (table, bool) {
+	for _, t := range tables {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return table{}, false
+}
+
+// tableFor returns the manifest of the table stored in file.
+func (m *Manifest) tableFor(file string) *TableManifest {
+	for i := range m.Tables {
+		if m.Tables[i].File == file {
+			return &m.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Verify reads the whole archive in r and checks its format, its schema
+// version and the row count and checksum of every table.
+func Verify(r io.Reader) (*Manifest, error) {
+	a, err := openArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	defer a.gz.Close()
+
+	seen := make(map[string]bool)
+	for {
+		hdr, err := a.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+		tm := a.manifest.tableFor(hdr.Name)
+		if tm == nil {
+			return nil, fmt.Errorf("archive holds unexpected file %s", hdr.Name)
+		}
+		seen[hdr.Name] = true
+
+		h := sha256.New()
+		lines := &lineCounter{}
+		if _, err := io.Copy(io.MultiWriter(h, lines), a); err != nil {
+			return nil, fmt.Errorf("corrupt archive: %s: %w", hdr.Name, err)
+		}
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != tm.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s: archive has %s, manifest records %s", hdr.Name, sum, tm.SHA256)
+		}
+		if lines.n != tm.Rows {
+			return nil, fmt.Errorf("%s holds %d rows, manifest records %d", hdr.Name, lines.n, tm.Rows)
+		}
+	}
+	for _, tm := range a.manifest.Tables {
+		if !seen[tm.File] {
+			return nil, fmt.Errorf("archive is missing %s", tm.File)
+		}
+	}
+	return a.manifest, nil
+}
+
+// lineCounter counts the lines written to it.
+type lineCounter struct{ n int }
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	c.n += strings.Count(string(p), "\n")
+	return len(p), nil
+}
+
+// Restore verifies the archive in r, then migrates db to the current
+// schema and restores into it in a single transaction, so a failed restore
+// leaves db unchanged. A full restore requires db to hold no employees or
+// audit entries; IDs and timestamps are kept.
+func Restore(ctx context.Context, db *sql.DB, d repo.Dialect, r io.ReadSeeker, opts RestoreOptions) (*Manifest, error) {
+	if _, err := Verify(r); err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	a, err := openArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	defer a.gz.Close()
+
+	if err := repo.Migrate(db, d); err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if opts.EmployeeID != 0 {
+		err = restoreEmployee(ctx, tx, d, a, opts)
+	} else {
+		err = restoreAll(ctx, tx, d, a)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d == repo.Postgres {
+		// Explicit IDs do not advance SERIAL sequences
+		for _, t := range tables {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+				"SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE((SELECT MAX(id) FROM %[1]s), 0) + 1, false)", t.name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a.manifest, tx.Commit()
+}
+
+func restoreAll(ctx context.Context, tx *sql.Tx, d repo.Dialect, a *archive) error {
+	for _, t := range tables {
+		var n int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+t.name).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%s already holds %d rows; restore into an empty database, or restore a single employee", t.name, n)
+		}
+	}
+
+	for {
+		hdr, err := a.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		tm := a.manifest.tableFor(hdr.Name)
+		if err := insertRows(ctx, tx, d, tm, a, func(map[string]interface{}) bool { return true }); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", tm.Name, err)
+		}
+	}
+}
+
+func restoreEmployee(ctx context.Context, tx *sql.Tx, d repo.Dialect, a *archive, opts RestoreOptions) error {
+	var exists int
+	if err := tx.QueryRowContext(ctx, d.Rebind("SELECT COUNT(*) FROM employees WHERE id = ?"), opts.EmployeeID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		if !opts.Replace {
+			return fmt.Errorf("employee %d: %w", opts.EmployeeID, ErrEmployeeExists)
+		}
+		if _, err := tx.ExecContext(ctx, d.Rebind("DELETE FROM employees WHERE id = ?"), opts.EmployeeID); err != nil {
+			return err
+		}
+	}
+
+	tm := a.manifest.Table("employees")
+	if tm == nil {
+		return fmt.Errorf("archive has no employees table")
+	}
+	for {
+		hdr, err := a.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("archive is missing %s", tm.File)
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Name == tm.File {
+			break
+		}
+	}
+
+	found := false
+	err := insertRows(ctx, tx, d, tm, a, func(row map[string]interface{}) bool {
+		id, _ := row["id"].(int64)
+		if int(id) != opts.EmployeeID {
+			return false
+		}
+		found = true
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore employee %d: %w", opts.EmployeeID, err)
+	}
+	if !found {
+		return fmt.Errorf("employee %d is not in the backup", opts.EmployeeID)
+	}
+
+	// The audit log survives deletes, so only the restore itself is new
+	_, err = tx.ExecContext(ctx, d.Rebind("INSERT INTO employee_audit_log (employee_id, action, fields) VALUES (?, ?, ?)"),
+		opts.EmployeeID, domain.AuditRestore, "")
+	return err
+}
+
+// insertRows inserts the NDJSON rows of table tm read from r for which
+// keep returns true. Only the columns in the archive are written, so rows
+// from an older schema get the defaults of newer columns.
+func insertRows(ctx context.Context, tx *sql.Tx, d repo.Dialect, tm *TableManifest, r io.Reader, keep func(map[string]interface{}) bool) error {
+	t, _ := findTable(tm.Name)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tm.Columns)), ", ")
+	stmt, err := tx.PrepareContext(ctx, d.Rebind(
+		"INSERT INTO "+tm.Name+" ("+strings.Join(tm.Columns, ", ")+") VALUES ("+placeholders+")"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	args := make([]interface{}, len(tm.Columns))
+	for line := 1; ; line++ {
+		var raw map[string]interface{}
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("row %d: %w", line, err)
+		}
+		row := make(map[string]interface{}, len(raw))
+		for i, name := range tm.Columns {
+			c, _ := t.column(name)
+			v, err := sqlValue(d, c, raw[name])
+			if err != nil {
+				return fmt.Errorf("row %d: %s: %w", line, name, err)
+			}
+			row[name] = v
+			args[i] = v
+		}
+		if !keep(row) {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("row %d: %w", line, err)
+		}
+	}
+}
+
+// sqliteTimeFormat matches CURRENT_TIMESTAMP. SQLite keeps timestamps as
+// text, so restored rows must use the same layout to sort with new ones.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999"
+
+// sqlValue converts an NDJSON value back to the column's Go type.
+func sqlValue(d repo.Dialect, c column, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch c.kind {
+	case intColumn:
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %T", v)
+		}
+		return n.Int64()
+	case stringColumn:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return s, nil
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a timestamp, got %T", v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil || d != repo.SQLite {
+			return t, err
+		}
+		return t.UTC().Format(sqliteTimeFormat), nil
+	}
+}
@@ -0,0 +1,218 @@
+// Package config is the typed configuration of the server and its
+// commands. Values come from, in increasing precedence, the defaults below,
+// a YAML or TOML file, environment variables and command line flags.
+//
+// Every setting has a key (its path in the file, e.g. "database.host"), a
+// flag of the same name (-database.host) and usually an environment
+// variable, given by the env tag. Fields tagged secret are redacted when
+// the configuration is printed.
+package config
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Config is the complete configuration.
+type Config struct {
+	Server      Server      `yaml:"server" toml:"server"`
+	Database    Database    `yaml:"database" toml:"database"`
+	PII         PII         `yaml:"pii" toml:"pii"`
+	Log         Log         `yaml:"log" toml:"log"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing"`
+	RateLimit   RateLimit   `yaml:"rate_limit" toml:"rate_limit"`
+	CORS        CORS        `yaml:"cors" toml:"cors"`
+	GraphQL     GraphQL     `yaml:"graphql" toml:"graphql"`
+	Events      Events      `yaml:"events" toml:"events"`
+	Bulk        Bulk        `yaml:"bulk" toml:"bulk"`
+	Idempotency Idempotency `yaml:"idempotency" toml:"idempotency"`
+	Debug       Debug       `yaml:"debug" toml:"debug"`
+
+	// sources records where each key was last set, see Source.
+	sources map[string]string
+}
+
+// Server configures the HTTP listener and shutdown.
+type Server struct {
+	Host         string        `yaml:"host" toml:"host" env:"HOST" help:"interface to listen on, empty for all"`
+	Port         int           `yaml:"port" toml:"port" env:"PORT" help:"port to listen on"`
+	GRPCPort     int           `yaml:"grpc_port" toml:"grpc_port" env:"GRPC_PORT" help:"port of the gRPC API, 0 to disable"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"HTTP_READ_TIMEOUT" help:"maximum time to read a request"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" help:"maximum time to write a response"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" help:"keep-alive timeout"`
+	FrontendDir  string        `yaml:"frontend_dir" toml:"frontend_dir" env:"FRONTEND_DIR" help:"static files served at /, empty to disable"`
+	// ShutdownDrainDelay is the time between failing /readyz and closing
+	// the listener, for load balancers to notice.
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" toml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY" help:"wait after failing readiness before shutting down"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" help:"deadline for draining requests and workers"`
+}
+
+// Database selects and locates the database. Port and User default by
+// driver when unset.
+type Database struct {
+	Driver   string `yaml:"driver" toml:"driver" env:"DB_DRIVER" help:"mysql, sqlite or postgres"`
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST" help:"database host"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT" help:"database port (default 3306 or 5432)"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER" help:"database user (default root or postgres)"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true" help:"database password"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME" help:"database name"`
+	Path     string `yaml:"path" toml:"path" env:"DB_PATH" help:"SQLite database file"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE" help:"PostgreSQL sslmode"`
+	// URL overrides the fields above for PostgreSQL.
+	URL string `yaml:"url" toml:"url" env:"DATABASE_URL" secret:"url" help:"PostgreSQL connection URL"`
+	// Timeout bounds the database work of a request, with overrides by
+	// route name.
+	Timeout       time.Duration            `yaml:"timeout" toml:"timeout" env:"DB_TIMEOUT" help:"deadline for database work per request"`
+	RouteTimeouts map[string]time.Duration `yaml:"route_timeouts" toml:"route_timeouts" env:"DB_ROUTE_TIMEOUTS" help:"per-route deadlines, e.g. employees.list=3s"`
+}
+
+// PII locates the keys encrypting personal data. KeyFile takes precedence
+// over the individual keys.
+type PII struct {
+	KeyFile           string   `yaml:"key_file" toml:"key_file" env:"PII_KEY_FILE" help:"JSON key file"`
+	MasterKey         string   `yaml:"master_key" toml:"master_key" env:"PII_MASTER_KEY" secret:"true" help:"current master key as id:base64"`
+	RetiredMasterKeys []string `yaml:"retired_master_keys" toml:"retired_master_keys" env:"PII_RETIRED_MASTER_KEYS" secret:"true" help:"previous master keys as id:base64"`
+	BlindIndexKey     string   `yaml:"blind_index_key" toml:"blind_index_key" env:"PII_BLIND_INDEX_KEY" secret:"true" help:"base64 blind index key"`
+}
+
+// Log configures the JSON logger.
+type Log struct {
+	Level     slog.Level `yaml:"level" toml:"level" env:"LOG_LEVEL" help:"debug, info, warn or error"`
+	Sample2xx int        `yaml:"sample_2xx" toml:"sample_2xx" env:"LOG_SAMPLE_2XX" help:"log one in every N successful requests"`
+}
+
+// Tracing selects the span exporter. The exporter itself reads the
+// standard OTEL_EXPORTER_OTLP_*, OTEL_SERVICE_NAME and OTEL_TRACES_SAMPLER
+// variables.
+type Tracing struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"OTEL_TRACES_EXPORTER" help:"otlp, stdout or none"`
+}
+
+// RateLimit holds the per-client quotas, in requests per minute.
+type RateLimit struct {
+	Requests int               `yaml:"requests" toml:"requests" env:"RATE_LIMIT_REQUESTS" help:"quota of anonymous clients"`
+	Tiers    map[string]int    `yaml:"tiers" toml:"tiers" env:"RATE_LIMIT_TIERS" help:"quota per tier, e.g. premium=6000"`
+	APIKeys  map[string]string `yaml:"api_keys" toml:"api_keys" env:"RATE_LIMIT_API_KEYS" sep:":" secret:"true" help:"tier per API key, e.g. key:premium"`
+	Routes   map[string]int    `yaml:"routes" toml:"routes" env:"RATE_LIMIT_ROUTES" help:"quota per route or route@tier"`
+	Store    string            `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE" help:"memory or sql"`
+	// TrustedProxies are CIDRs or IPs whose X-Forwarded-For is believed.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES" help:"proxies whose X-Forwarded-For is trusted"`
+}
+
+// CORS is the default cross-origin policy. PolicyFile may add per-route
+// overrides; the fields here override its default policy when set.
+type CORS struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS,CORS_ORIGIN" help:"origins, https://*.example.com wildcards or *"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" help:"allow cookies and Authorization"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE" help:"preflight cache duration"`
+	PolicyFile       string        `yaml:"policy_file" toml:"policy_file" env:"CORS_CONFIG_FILE" help:"JSON CORS policy with per-route overrides"`
+}
+
+// GraphQL bounds the queries /graphql accepts.
+type GraphQL struct {
+	MaxDepth      int `yaml:"max_depth" toml:"max_depth" env:"GRAPHQL_MAX_DEPTH" help:"deepest nesting of selections"`
+	MaxComplexity int `yaml:"max_complexity" toml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" help:"estimated number of fields a query may resolve"`
+}
+
+// Events configures the employee change stream at /api/employees/stream.
+type Events struct {
+	Retention     time.Duration `yaml:"retention" toml:"retention" env:"EVENT_RETENTION" help:"how long events are kept for clients resuming a stream"`
+	Heartbeat     time.Duration `yaml:"heartbeat" toml:"heartbeat" env:"SSE_HEARTBEAT" help:"interval of keep-alive comments on idle streams"`
+	PollInterval  time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"EVENT_POLL_INTERVAL" help:"how often streams read the event log for changes made through other replicas"`
+	MaxBacklogLag time.Duration `yaml:"max_backlog_lag" toml:"max_backlog_lag" env:"EVENT_MAX_BACKLOG_LAG" help:"how long events may stay in the log past retention before /readyz fails, 0 to disable"`
+}
+
+// Bulk bounds the requests /api/employees/bulk accepts.
+type Bulk struct {
+	MaxOperations int `yaml:"max_operations" toml:"max_operations" env:"BULK_MAX_OPERATIONS" help:"most operations a bulk request may carry"`
+}
+
+// Idempotency configures the Idempotency-Key support of POST and PATCH
+// requests.
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL" help:"how long responses are kept for retries with the same Idempotency-Key"`
+}
+
+// Debug configures /debug/status.
+type Debug struct {
+	Token string `yaml:"token" toml:"token" env:"DEBUG_TOKEN" secret:"true" help:"bearer token for /debug/status, empty to disable"`
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:            8080,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			FrontendDir:     "./frontend",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: Database{
+			Driver:  "mysql",
+			Host:    "localhost",
+			Name:    "karyawan_db",
+			Path:    "karyawan.db",
+			SSLMode: "disable",
+			Timeout: 5 * time.Second,
+		},
+		Log:         Log{Level: slog.LevelInfo, Sample2xx: 1},
+		Tracing:     Tracing{Exporter: "none"},
+		RateLimit:   RateLimit{Requests: 100, Store: "memory"},
+		CORS:        CORS{AllowedOrigins: []string{"*"}, MaxAge: time.Hour},
+		GraphQL:     GraphQL{MaxDepth: 10, MaxComplexity: 5000},
+		Events:      Events{Retention: 24 * time.Hour, Heartbeat: 15 * time.Second, PollInterval: time.Second, MaxBacklogLag: 6 * time.Hour},
+		Bulk:        Bulk{MaxOperations: 100},
+		Idempotency: Idempotency{TTL: 24 * time.Hour},
+	}
+}
+
+// CanonicalDriver maps a database.driver value and its aliases to mysql,
+// sqlite or postgres. An empty name selects mysql; ok is false for an
+// unknown driver.
+func CanonicalDriver(name string) (driver string, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "mysql":
+		return "mysql", true
+	case "sqlite", "sqlite3":
+		return "sqlite", true
+	case "postgres", "postgresql", "pgx":
+		return "postgres", true
+	}
+	return "", false
+}
+
+// applyDriverDefaults fills in the settings whose default depends on the
+// database driver.
+func (c *Config) applyDriverDefaults() {
+	driver, _ := CanonicalDriver(c.Database.Driver)
+	if c.Database.Port == 0 {
+		c.Database.Port = 3306
+		if driver == "postgres" {
+			c.Database.Port = 5432
+		}
+	}
+	if c.Database.User == "" {
+		c.Database.User = "root"
+		if driver == "postgres" {
+			c.Database.User = "postgres"
+		}
+	}
+}
+
+// Source describes where the setting with the given key came from:
+// "default", "file", "env NAME" or "flag -name".
+func (c *Config) Source(key string) string {
+	if s, ok := c.sources[key]; ok {
+		return s
+	}
+	return "default"
+}
+
+// IsSet reports whether the setting with the given key was configured
+// rather than left at its default.
+func (c *Config) IsSet(key string) bool {
+	return c.Source(key) != "default"
+}
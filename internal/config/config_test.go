@@ -0,0 +1,216 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(bytes.Buffer))
+	return Load(fs, args)
+}
+
+func TestDefaults(t *testing.T) {
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Server.Port != 8080 || cfg.Database.Driver != "mysql" || cfg.Database.Port != 3306 || cfg.Database.User != "root" {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.IsSet("server.port") {
+		t.Error("server.port reported as set")
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, "karyawan.yaml", `
+server:
+  host: 0.0.0.0
+  port: 9000
+  write_timeout: 30s
+database:
+  driver: postgres
+  name: from_file
+log:
+  level: warn
+rate_limit:
+  tiers:
+    premium: 6000
+`)
+	t.Setenv("PORT", "9100")
+	t.Setenv("DB_NAME", "from_env")
+	t.Setenv("RATE_LIMIT_API_KEYS", "k1:premium")
+
+	cfg, err := load(t, "-config", path, "-database.name", "from_flag", "-rate_limit.tiers", "premium=10,standard=5")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	// file < env < flags, defaults for the rest
+	if cfg.Server.Host != "0.0.0.0" || cfg.Server.WriteTimeout != 30*time.Second || cfg.Log.Level != slog.LevelWarn {
+		t.Errorf("file values not applied: %+v %+v", cfg.Server, cfg.Log)
+	}
+	if cfg.Server.Port != 9100 {
+		t.Errorf("server.port = %d, expected the environment to override the file", cfg.Server.Port)
+	}
+	if cfg.Database.Name != "from_flag" {
+		t.Errorf("database.name = %q, expected the flag to override the environment", cfg.Database.Name)
+	}
+	if cfg.Database.Port != 5432 || cfg.Database.User != "postgres" {
+		t.Errorf("database port/user = %d/%s, expected the postgres defaults", cfg.Database.Port, cfg.Database.User)
+	}
+	if cfg.RateLimit.Tiers["premium"] != 10 || cfg.RateLimit.APIKeys["k1"] != "premium" {
+		t.Errorf("rate_limit = %+v", cfg.RateLimit)
+	}
+
+	for key, source := range map[string]string{
+		"server.host":   "file",
+		"server.port":   "env PORT",
+		"database.name": "flag -database.name",
+		"database.user": "default",
+	} {
+		if got := cfg.Source(key); got != source {
+			t.Errorf("Source(%s) = %q, expected %q", key, got, source)
+		}
+	}
+}
+
+func TestTOMLFile(t *testing.T) {
+	path := writeFile(t, "karyawan.toml", `
+[database]
+driver = "sqlite"
+path = "/tmp/karyawan.db"
+route_timeouts = { "employees.list" = "3s" }
+
+[cors]
+allowed_origins = ["https://hr.example.com"]
+allow_credentials = true
+`)
+	cfg, err := load(t, "-config", path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Database.Driver != "sqlite" || cfg.Database.RouteTimeouts["employees.list"] != 3*time.Second {
+		t.Errorf("database = %+v", cfg.Database)
+	}
+	if !cfg.CORS.AllowCredentials || cfg.CORS.AllowedOrigins[0] != "https://hr.example.com" {
+		t.Errorf("cors = %+v", cfg.CORS)
+	}
+}
+
+func TestLegacyEnvAlias(t *testing.T) {
+	t.Setenv("CORS_ORIGIN", "https://a.example.com, https://b.example.com")
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 2 || cfg.Source("cors.allowed_origins") != "env CORS_ORIGIN" {
+		t.Errorf("cors.allowed_origins = %v from %s", cfg.CORS.AllowedOrigins, cfg.Source("cors.allowed_origins"))
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Setenv("PORT", "70000")
+	t.Setenv("DB_DRIVER", "oracle")
+	t.Setenv("LOG_SAMPLE_2XX", "0")
+	t.Setenv("RATE_LIMIT_API_KEYS", "s3cr3t:gold")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	_, err := load(t)
+	if err == nil {
+		t.Fatal("Load() accepted an invalid configuration")
+	}
+	for _, want := range []string{
+		"server.port (env PORT): must be between 1 and 65535",
+		`database.driver (env DB_DRIVER): must be one of mysql, sqlite, postgres, got "oracle"`,
+		"log.sample_2xx (env LOG_SAMPLE_2XX)",
+		`rate_limit.api_keys (env RATE_LIMIT_API_KEYS): unknown tier "gold"`,
+		"cors.allow_credentials",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("error leaks an API key: %v", err)
+	}
+}
+
+func TestDriverAliases(t *testing.T) {
+	for driver, port := range map[string]int{"sqlite3": 3306, "postgresql": 5432, "pgx": 5432} {
+		t.Setenv("DB_DRIVER", driver)
+		cfg, err := load(t)
+		if err != nil {
+			t.Errorf("Load() with DB_DRIVER=%s error: %v", driver, err)
+			continue
+		}
+		if cfg.Database.Port != port {
+			t.Errorf("DB_DRIVER=%s: database.port = %d, want %d", driver, cfg.Database.Port, port)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Setenv("DB_TIMEOUT", "soon")
+	if _, err := load(t); err == nil || !strings.Contains(err.Error(), `database.timeout (env DB_TIMEOUT): invalid duration "soon"`) {
+		t.Errorf("Load() error = %v", err)
+	}
+
+	path := writeFile(t, "typo.yaml", "server:\n  prot: 80\n")
+	if _, err := load(t, "-config", path); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Errorf("Load() with an unknown key error = %v", err)
+	}
+}
+
+func TestWriteRedactsSecrets(t *testing.T) {
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("DB_PASSWORD", "hunter2")
+	t.Setenv("DATABASE_URL", "postgres://app:hunter3@db:5432/karyawan")
+	t.Setenv("PII_RETIRED_MASTER_KEYS", "old:b2xk")
+	t.Setenv("RATE_LIMIT_TIERS", "premium=6000")
+	t.Setenv("RATE_LIMIT_API_KEYS", "abc123:premium")
+	t.Setenv("DEBUG_TOKEN", "letmein")
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Write(&buf); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "hunter3", "b2xk", "abc123", "letmein"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{"password: '[REDACTED]' # env DB_PASSWORD", "postgres://app:xxxxx@db:5432/karyawan", "premium: 6000"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	values := cfg.Values()
+	if values["debug.token"] != "[REDACTED]" || values["rate_limit.tiers"] != "premium=6000" {
+		t.Errorf("Values() = %v", values)
+	}
+}
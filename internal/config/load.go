@@ -0,0 +1,251 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// field is one setting of a Config.
+type field struct {
+	key    string
+	env    []string
+	help   string
+	sep    string
+	secret string
+	value  reflect.Value
+}
+
+// fields lists the settings of c in declaration order. The values are
+// addressable, so setting them updates c.
+func (c *Config) fields() []field {
+	var fields []field
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Type().Field(i)
+		if !section.IsExported() {
+			continue
+		}
+		prefix := section.Tag.Get("yaml")
+		v := sections.Field(i)
+		for j := 0; j < v.NumField(); j++ {
+			f := v.Type().Field(j)
+			fd := field{
+				key:    prefix + "." + f.Tag.Get("yaml"),
+				help:   f.Tag.Get("help"),
+				sep:    f.Tag.Get("sep"),
+				secret: f.Tag.Get("secret"),
+				value:  v.Field(j),
+			}
+			if env := f.Tag.Get("env"); env != "" {
+				fd.env = strings.Split(env, ",")
+			}
+			if fd.sep == "" {
+				fd.sep = "="
+			}
+			fields = append(fields, fd)
+		}
+	}
+	return fields
+}
+
+// Load builds the configuration and validates it. It registers -config,
+// naming the file (default $CONFIG_FILE), and a flag per setting on fs,
+// then parses args, so commands can register flags of their own on fs
+// beforehand. Empty environment variables count as unset.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	cfg.sources = make(map[string]string)
+	fields := cfg.fields()
+
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration `file`")
+	type flagValue struct {
+		field
+		value string
+	}
+	var flagged []flagValue
+	for _, f := range fields {
+		set := func(s string) error {
+			flagged = append(flagged, flagValue{f, s})
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.key, f.help, set)
+		} else {
+			fs.Func(f.key, f.help, set)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := cfg.loadFile(*path, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range fields {
+		for _, name := range f.env {
+			if v := os.Getenv(name); v != "" {
+				errs = append(errs, cfg.set(f, v, "env "+name))
+				break
+			}
+		}
+	}
+	for _, f := range flagged {
+		errs = append(errs, cfg.set(f.field, f.value, "flag -"+f.key))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	cfg.applyDriverDefaults()
+	return cfg, cfg.Validate()
+}
+
+// set parses s into the field and records its source.
+func (c *Config) set(f field, s, source string) error {
+	if err := parseInto(f, f.value, s); err != nil {
+		return fmt.Errorf("%s (%s): %w", f.key, source, err)
+	}
+	c.sources[f.key] = source
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parseInto parses s into v, which is the field f or a value of its map.
+// Lists are comma separated and maps are comma separated name=value
+// pairs, or name:value for fields with sep ":".
+func parseInto(f field, v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		items := make([]string, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i, entry := range strings.Split(s, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(entry, f.sep)
+			if !ok || strings.TrimSpace(name) == "" {
+				if f.secret != "" {
+					return fmt.Errorf("invalid entry %d, expected name%svalue", i+1, f.sep)
+				}
+				return fmt.Errorf("invalid entry %q, expected name%svalue", entry, f.sep)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := parseInto(f, elem, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(name)), elem)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// loadFile decodes a .yaml, .yml or .toml file over c. Unknown keys are
+// errors, so typos do not go unnoticed.
+func (c *Config) loadFile(path string, fields []field) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var present map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && err != io.EOF {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(data, &present); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("invalid config file %s: unknown key %s", path, undecoded[0])
+		}
+		if _, err := toml.Decode(string(data), &present); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q, expected .yaml, .yml or .toml", ext)
+	}
+
+	keys := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keys[f.key] = true
+	}
+	c.markFileKeys("", present, keys)
+	return nil
+}
+
+// markFileKeys records the settings present in a decoded file.
+func (c *Config) markFileKeys(prefix string, values map[string]interface{}, keys map[string]bool) {
+	for name, value := range values {
+		key := prefix + name
+		if keys[key] {
+			c.sources[key] = "file"
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			c.markFileKeys(key+".", nested, keys)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Write prints the configuration as YAML that Load accepts, with secrets
+// redacted and the source of every setting not left at its default as a
+// comment.
+func (c *Config) Write(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	for _, f := range c.fields() {
+		name, key, _ := strings.Cut(f.key, ".")
+		if section == nil || root.Content[len(root.Content)-2].Value != name {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, scalar("!!str", name), section)
+		}
+		keyNode, value := scalar("!!str", key), node(f, f.value)
+		if c.IsSet(f.key) {
+			// Block mappings take the comment on their key line
+			if value.Kind == yaml.MappingNode && value.Style != yaml.FlowStyle {
+				keyNode.LineComment = c.Source(f.key)
+			} else {
+				value.LineComment = c.Source(f.key)
+			}
+		}
+		section.Content = append(section.Content, keyNode, value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Values returns every setting by key, formatted as in the environment,
+// with secrets redacted.
+func (c *Config) Values() map[string]string {
+	values := make(map[string]string)
+	for _, f := range c.fields() {
+		n := node(f, f.value)
+		switch n.Kind {
+		case yaml.SequenceNode:
+			items := make([]string, len(n.Content))
+			for i, item := range n.Content {
+				items[i] = item.Value
+			}
+			values[f.key] = strings.Join(items, ",")
+		case yaml.MappingNode:
+			entries := make([]string, 0, len(n.Content)/2)
+			for i := 0; i < len(n.Content); i += 2 {
+				entries = append(entries, n.Content[i].Value+f.sep+n.Content[i+1].Value)
+			}
+			values[f.key] = strings.Join(entries, ",")
+		default:
+			values[f.key] = n.Value
+		}
+	}
+	return values
+}
+
+func scalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// node renders the value of field f, redacting it when the field is
+// secret.
+func node(f field, v reflect.Value) *yaml.Node {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return scalar("!!str", strings.ToLower(string(text)))
+	}
+	if v.Type() == durationType {
+		return scalar("!!str", v.Interface().(fmt.Stringer).String())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		switch {
+		case s == "" || f.secret == "":
+		case f.secret == "url":
+			if u, err := url.Parse(s); err == nil && u.User != nil {
+				s = u.Redacted()
+			} else if err != nil {
+				s = redacted
+			}
+		default:
+			s = redacted
+		}
+		return scalar("!!str", s)
+	case reflect.Int:
+		return scalar("!!int", strconv.FormatInt(v.Int(), 10))
+	case reflect.Bool:
+		return scalar("!!bool", strconv.FormatBool(v.Bool()))
+	case reflect.Slice:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < v.Len(); i++ {
+			seq.Content = append(seq.Content, node(f, v.Index(i)))
+		}
+		return seq
+	case reflect.Map:
+		m := &yaml.Node{Kind: yaml.MappingNode}
+		if v.Len() == 0 {
+			m.Style = yaml.FlowStyle
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			name := k
+			if f.secret != "" {
+				// The names are the secrets, e.g. API keys
+				name = fmt.Sprintf("%s-%d", redacted, i+1)
+			}
+			m.Content = append(m.Content, scalar("!!str", name),
+				node(field{key: f.key}, v.MapIndex(reflect.ValueOf(k))))
+		}
+		return m
+	}
+	return scalar("!!str", fmt.Sprint(v.Interface()))
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Validate checks every setting and reports all problems at once, each
+// naming the key and where its value came from.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(key string, ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s (%s): %s", key, c.Source(key), fmt.Sprintf(format, args...)))
+		}
+	}
+	nonNegative := func(key string, d time.Duration) {
+		check(key, d >= 0, "must not be negative, got %s", d)
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		check(key, false, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+
+	s := c.Server
+	check("server.port", s.Port > 0 && s.Port < 65536, "must be between 1 and 65535, got %d", s.Port)
+	check("server.grpc_port", s.GRPCPort >= 0 && s.GRPCPort < 65536, "must be between 0 and 65535, got %d", s.GRPCPort)
+	nonNegative("server.read_timeout", s.ReadTimeout)
+	nonNegative("server.write_timeout", s.WriteTimeout)
+	nonNegative("server.idle_timeout", s.IdleTimeout)
+	nonNegative("server.shutdown_drain_delay", s.ShutdownDrainDelay)
+	nonNegative("server.shutdown_timeout", s.ShutdownTimeout)
+
+	d := c.Database
+	_, known := CanonicalDriver(d.Driver)
+	check("database.driver", known, "must be one of mysql, sqlite, postgres, got %q", d.Driver)
+	check("database.port", d.Port > 0 && d.Port < 65536, "must be between 1 and 65535, got %d", d.Port)
+	nonNegative("database.timeout", d.Timeout)
+	for route, timeout := range d.RouteTimeouts {
+		check("database.route_timeouts", timeout >= 0, "%s must not be negative, got %s", route, timeout)
+	}
+
+	check("log.sample_2xx", c.Log.Sample2xx > 0, "must be a positive integer, got %d", c.Log.Sample2xx)
+	oneOf("tracing.exporter", c.Tracing.Exporter, "otlp", "stdout", "none")
+
+	r := c.RateLimit
+	check("rate_limit.requests", r.Requests > 0, "must be a positive integer, got %d", r.Requests)
+	for tier, n := range r.Tiers {
+		check("rate_limit.tiers", n > 0, "%s must be a positive integer, got %d", tier, n)
+	}
+	for route, n := range r.Routes {
+		check("rate_limit.routes", n > 0, "%s must be a positive integer, got %d", route, n)
+	}
+	for _, tier := range r.APIKeys {
+		// Keys are secret; name only the tier
+		_, ok := r.Tiers[tier]
+		check("rate_limit.api_keys", ok, "unknown tier %q, define it in rate_limit.tiers", tier)
+	}
+	oneOf("rate_limit.store", r.Store, "memory", "sql")
+	for _, proxy := range r.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check("rate_limit.trusted_proxies", err == nil || net.ParseIP(proxy) != nil, "invalid CIDR or IP %q", proxy)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check("cors.allow_credentials", origin != "*" || !c.CORS.AllowCredentials,
+			"credentials cannot be allowed when cors.allowed_origins contains *")
+	}
+	nonNegative("cors.max_age", c.CORS.MaxAge)
+
+	check("graphql.max_depth", c.GraphQL.MaxDepth > 0, "must be a positive integer, got %d", c.GraphQL.MaxDepth)
+	check("graphql.max_complexity", c.GraphQL.MaxComplexity > 0, "must be a positive integer, got %d", c.GraphQL.MaxComplexity)
+
+	check("events.retention", c.Events.Retention > 0, "must be positive, got %s", c.Events.Retention)
+	check("events.heartbeat", c.Events.Heartbeat > 0, "must be positive, got %s", c.Events.Heartbeat)
+	check("events.poll_interval", c.Events.PollInterval > 0, "must be positive, got %s", c.Events.PollInterval)
+	nonNegative("events.max_backlog_lag", c.Events.MaxBacklogLag)
+
+	check("bulk.max_operations", c.Bulk.MaxOperations > 0, "must be a positive integer, got %d", c.Bulk.MaxOperations)
+
+	check("idempotency.ttl", c.Idempotency.TTL > 0, "must be positive, got %s", c.Idempotency.TTL)
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+// Package database opens the SQL database selected by the configuration.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	_ "modernc.org/sqlite"
+
+	"karyawan-app/internal/config"
+	repo "karyawan-app/internal/repository"
+)
+
+// Open opens the database described by cfg. The connection is not pinged.
+// Every statement is traced through the global OpenTelemetry tracer
+// provider.
+func Open(cfg config.Database) (*sql.DB, repo.Dialect, error) {
+	dialect, err := repo.ParseDialect(cfg.Driver)
+	if err != nil {
+		return nil, "", err
+	}
+
+	db, err := otelsql.Open(dialect.DriverName(), DSN(dialect, cfg),
+		otelsql.WithAttributes(dbSystem[dialect]),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			DisableErrSkip:       true,
+		}),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open %s database: %w", dialect, err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	if dialect == repo.SQLite {
+		db.SetMaxOpenConns(1)
+	}
+	return db, dialect, nil
+}
+
+var dbSystem = map[repo.Dialect]attribute.KeyValue{
+	repo.MySQL:    semconv.DBSystemMySQL,
+	repo.SQLite:   semconv.DBSystemSqlite,
+	repo.Postgres: semconv.DBSystemPostgreSQL,
+}
+
+// DSN builds the data source name for a dialect. PostgreSQL uses cfg.URL
+// when set.
+func DSN(dialect repo.Dialect, cfg config.Database) string {
+	port := strconv.Itoa(cfg.Port)
+	switch dialect {
+	case repo.SQLite:
+		return "file:" + cfg.Path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	case repo.Postgres:
+		if cfg.URL != "" {
+			return cfg.URL
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.User, cfg.Password),
+			Host:     net.JoinHostPort(cfg.Host, port),
+			Path:     cfg.Name,
+			RawQuery: "sslmode=" + cfg.SSLMode,
+		}
+		return u.String()
+	}
+
+	return cfg.User + ":" + cfg.Password + "@tcp(" + net.JoinHostPort(cfg.Host, port) + ")/" + cfg.Name + "?parseTime=true"
+}
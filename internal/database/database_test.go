@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"karyawan-app/internal/config"
+	"karyawan-app/internal/tracing/spantest"
+)
+
+func TestOpenTracesStatements(t *testing.T) {
+	recorder := spantest.Install(t)
+
+	db, _, err := Open(config.Database{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "trace.db")})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer db.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "parent")
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&n); err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	span.End()
+
+	spans := recorder.Spans()
+	query := spantest.Find(spans, "sql.conn.query")
+	if query == nil {
+		t.Fatalf("no sql.conn.query span in %+v", spans)
+	}
+	if query.ParentSpanID != span.SpanContext().SpanID().String() {
+		t.Errorf("query span is not a child of the caller's span")
+	}
+	if query.Attributes["db.system"] != "sqlite" || query.Attributes["db.statement"] != "SELECT 1" {
+		t.Errorf("query span attributes = %v", query.Attributes)
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "strings"
+
+// Address is a structured Indonesian postal address. Region fields hold the
+// canonical names from the region reference dataset.
+type Address struct {
+	Street        string `json:"street"`
+	RT            string `json:"rt"`
+	RW            string `json:"rw"`
+	Kelurahan     string `json:"kelurahan"`
+	Kecamatan     string `json:"kecamatan"`
+	KotaKabupaten string `json:"kota_kabupaten"`
+	Provinsi      string `json:"provinsi"`
+	KodePos       string `json:"kode_pos"`
+}
+
+// Render formats the address as a single display line, e.g.
+// "Jl. Sudirman No. 1, RT 001/RW 002, Kel. Senayan, Kec. Kebayoran Baru,
+// Kota Jakarta Selatan, DKI Jakarta 12190".
+func (a Address) Render() string {
+	var parts []string
+	if s := strings.TrimSpace(a.Street); s != "" {
+		parts = append(parts, s)
+	}
+	if a.RT != "" || a.RW != "" {
+		parts = append(parts, "RT "+a.RT+"/RW "+a.RW)
+	}
+	if a.Kelurahan != "" {
+		parts = append(parts, "Kel. "+a.Kelurahan)
+	}
+	if a.Kecamatan != "" {
+		parts = append(parts, "Kec. "+a.Kecamatan)
+	}
+	if a.KotaKabupaten != "" {
+		parts = append(parts, a.KotaKabupaten)
+	}
+
+	provinsi := strings.TrimSpace(a.Provinsi + " " + a.KodePos)
+	if provinsi != "" {
+		parts = append(parts, provinsi)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Audit actions recorded for employee records.
+const (
+	AuditCreate     = "create"
+	AuditUpdate     = "update"
+	AuditDelete     = "delete"
+	AuditDataExport = "data_export"
+	AuditAnonymize  = "anonymize"
+	AuditRestore    = "restore"
+)
+
+// AuditEntry records an operation on an employee. It only names the fields
+// involved, never their values, so the audit log itself holds no PII.
+type AuditEntry struct {
+	ID         int       `json:"id"`
+	EmployeeID int       `json:"employee_id"`
+	Action     string    `json:"action"`
+	Fields     []string  `json:"fields,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry *AuditEntry) error
+	FindByEmployee(ctx context.Context, employeeID int) ([]AuditEntry, error)
+	// FindByEmployees returns the entries of several employees, oldest
+	// first.
+	FindByEmployees(ctx context.Context, employeeIDs []int) ([]AuditEntry, error)
+}
+
+// DataExport is everything held about one employee, produced for a data
+// subject access request.
+type DataExport struct {
+	ExportedAt time.Time    `json:"exported_at"`
+	Employee   Employee     `json:"employee"`
+	AuditLog   []AuditEntry `json:"audit_log"`
+	// Events are the employee's entries in the event log, and
+	// StoredResponses the responses kept for Idempotency-Key retries that
+	// hold the employee's data. They are filled in by the services that
+	// own them, see PublishEmployeeChanges and ForgetErasedEmployees.
+	Events          []EmployeeEvent  `json:"events"`
+	StoredResponses []StoredResponse `json:"stored_responses"`
+}
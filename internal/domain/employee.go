@@ -1,31 +1,163 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrEmployeeAnonymized is returned when modifying an employee whose
+// personal data has been erased.
+var ErrEmployeeAnonymized = errors.New("employee has been anonymized")
+
+// ErrEmployeeNotFound is returned when an update or delete, on its own or in
+// a bulk request, targets an employee that does not exist.
+var ErrEmployeeNotFound = errors.New("employee not found")
+
+// ErrBulkAborted is the result of the operations of an atomic bulk request
+// that were not applied because another operation failed.
+var ErrBulkAborted = errors.New("not applied because another operation failed")
 
 type Employee struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Position  string    `json:"position"`
-	Role      string    `json:"role"`
-	Phone     string    `json:"phone"`
-	Alamat    string    `json:"alamat"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Position     string     `json:"position"`
+	Role         string     `json:"role"`
+	Phone        string     `json:"phone"`
+	Alamat       string     `json:"alamat"`
+	Address      *Address   `json:"address,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at,omitempty"`
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
+}
+
+// MaxPageSize is the largest page ListEmployees returns.
+const MaxPageSize = 100
+
+// EmployeePage is one page of employees, newest first. Pages are keyed by
+// ID rather than offset, so concurrent inserts and deletes do not shift
+// them. Next is the cursor of the following page, 0 on the last one.
+type EmployeePage struct {
+	Employees []Employee
+	Next      int
+}
+
+// ErrInvalidCursor is returned for page cursors not made by EncodeCursor.
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// EncodeCursor turns a cursor of ListEmployees into the opaque token given
+// to API clients, so what it holds can change without breaking them. The
+// cursor 0, meaning no further page, is the empty token.
+func EncodeCursor(cursor int) string {
+	if cursor == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(cursor)))
+}
+
+// DecodeCursor reverses EncodeCursor.
+func DecodeCursor(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	cursor, err := strconv.Atoi(string(b))
+	if err != nil || cursor <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// Kinds of BulkOperation.
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkOperation is one write of a bulk request. ID is the target of an
+// update or delete; Employee is the record to create or the new state of
+// the updated one.
+type BulkOperation struct {
+	Op       string
+	ID       int
+	Employee *Employee
+}
+
+// BulkResult is the outcome of one BulkOperation: the created or updated
+// employee, or the error that kept it from being applied.
+type BulkResult struct {
+	Employee *Employee
+	Err      error
 }
 
 type EmployeeRepository interface {
-	FindAll() ([]Employee, error)
-	FindByID(id int) (*Employee, error)
-	Create(employee *Employee) error
-	Update(employee *Employee) error
-	Delete(id int) error
+	FindAll(ctx context.Context) ([]Employee, error)
+	// FindPage returns up to limit employees with an ID below beforeID,
+	// highest ID first. A beforeID of 0 starts at the newest employee.
+	FindPage(ctx context.Context, beforeID, limit int) ([]Employee, error)
+	FindByID(ctx context.Context, id int) (*Employee, error)
+	// FindByIDs returns the employees with the given IDs, in no particular
+	// order. Unknown IDs are skipped.
+	FindByIDs(ctx context.Context, ids []int) ([]Employee, error)
+	FindByEmail(ctx context.Context, email string) (*Employee, error)
+	// CountByEmails returns how many of emails, compared like FindByEmail
+	// compares them, already belong to an employee.
+	CountByEmails(ctx context.Context, emails []string) (int, error)
+	FindByPhone(ctx context.Context, phone string) ([]Employee, error)
+	Create(ctx context.Context, employee *Employee) error
+	// CreateBatch inserts employees in a single transaction and sets their
+	// IDs. Either all of them are inserted or none.
+	CreateBatch(ctx context.Context, employees []Employee) error
+	// ApplyBatch runs the operations in a single transaction, in order,
+	// and sets the IDs of created employees once it commits. When atomic,
+	// nothing is committed unless every operation succeeds; otherwise a
+	// failed operation is rolled back on its own and the rest commit. It
+	// returns the error of each operation, nil for those applied, and an
+	// error of its own only when the batch as a whole failed. Updates and
+	// deletes of unknown IDs fail with ErrEmployeeNotFound.
+	ApplyBatch(ctx context.Context, ops []BulkOperation, atomic bool) ([]error, error)
+	// Update and Delete fail with ErrEmployeeNotFound when there is no
+	// employee with the ID.
+	Update(ctx context.Context, employee *Employee) error
+	Delete(ctx context.Context, id int) error
+	// Anonymize overwrites the employee's personal data with the scrubbed
+	// values in employee and marks the record as anonymized. Like Update,
+	// it fails with ErrEmployeeNotFound for an unknown ID.
+	Anonymize(ctx context.Context, employee *Employee) error
+	// CountByRole returns the number of employees per role.
+	CountByRole(ctx context.Context) (map[string]int, error)
 }
 
 type EmployeeService interface {
-	GetAllEmployees() ([]Employee, error)
-	GetEmployee(id int) (*Employee, error)
-	CreateEmployee(employee *Employee) error
-	UpdateEmployee(employee *Employee) error
-	DeleteEmployee(id int) error
+	GetAllEmployees(ctx context.Context) ([]Employee, error)
+	// ListEmployees returns the page of at most limit employees that
+	// follows the cursor, see EmployeePage. A cursor of 0 is the first page.
+	ListEmployees(ctx context.Context, cursor, limit int) (*EmployeePage, error)
+	GetEmployee(ctx context.Context, id int) (*Employee, error)
+	// GetEmployees looks up many employees at once, see
+	// EmployeeRepository.FindByIDs.
+	GetEmployees(ctx context.Context, ids []int) ([]Employee, error)
+	GetEmployeeByEmail(ctx context.Context, email string) (*Employee, error)
+	GetEmployeesByPhone(ctx context.Context, phone string) ([]Employee, error)
+	CreateEmployee(ctx context.Context, employee *Employee) error
+	UpdateEmployee(ctx context.Context, employee *Employee) error
+	DeleteEmployee(ctx context.Context, id int) error
+	// BulkEmployees validates and applies many writes at once, returning a
+	// result per operation. When atomic, either every operation is applied
+	// or none is, and those that did not fail themselves end with
+	// ErrBulkAborted. The error is for failures of the request as a whole.
+	BulkEmployees(ctx context.Context, ops []BulkOperation, atomic bool) ([]BulkResult, error)
+	ExportEmployeeData(ctx context.Context, id int) (*DataExport, error)
+	// GetAuditLogs returns the audit entries of the given employees, oldest
+	// first, keyed by employee ID.
+	GetAuditLogs(ctx context.Context, employeeIDs []int) (map[int][]AuditEntry, error)
+	AnonymizeEmployee(ctx context.Context, id int) (*Employee, error)
+	HeadcountByRole(ctx context.Context) (map[string]int, error)
 }
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Types of EmployeeEvent.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// EmployeeEvent reports a change to an employee. Employee holds the record
+// after the change and is nil for deletions. Role is the employee's role
+// after the change, or before it for deletions, so watchers can filter
+// deletions too.
+type EmployeeEvent struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	EmployeeID int       `json:"employee_id"`
+	Role       string    `json:"role,omitempty"`
+	Employee   *Employee `json:"employee,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// EventLog persists employee events so watchers can resume where they left
+// off. Like the audit log it holds no personal data: only the type,
+// employee ID and role of each event are stored, and Employee is never
+// set on the events it returns.
+type EventLog interface {
+	// Append stores event and sets its ID, which increases with every
+	// event, and At.
+	Append(ctx context.Context, event *EmployeeEvent) error
+	// FindAfter returns up to limit events with an ID above afterID,
+	// oldest first.
+	FindAfter(ctx context.Context, afterID int64, limit int) ([]EmployeeEvent, error)
+	// LastID returns the highest ID of the events in the log, or 0 when it
+	// is empty.
+	LastID(ctx context.Context) (int64, error)
+	// FindByEmployee returns the events of the employee, oldest first.
+	FindByEmployee(ctx context.Context, employeeID int) ([]EmployeeEvent, error)
+	// Prune deletes the events that happened before the given time and
+	// returns how many there were.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
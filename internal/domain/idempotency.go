@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is what an IdempotencyStore holds for a key: the
+// fingerprint of the request that first used it and, once that request
+// finished, its response.
+type IdempotencyRecord struct {
+	Fingerprint string
+	// Status is 0 while the first request is still being processed.
+	Status      int
+	ContentType string
+	Body        []byte
+	// EmployeeIDs are the employees whose data Body holds. They are only
+	// stored, for PurgeEmployee, and not returned by Reserve.
+	EmployeeIDs []int
+}
+
+// StoredResponse is a response kept by an IdempotencyStore, as included in
+// data exports.
+type StoredResponse struct {
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        string    `json:"body"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// IdempotencyStore keeps the responses of requests sent with an
+// Idempotency-Key, so retries get the same answer. Replicas sharing a
+// store replay each other's responses.
+type IdempotencyStore interface {
+	// Reserve claims key for a request with fingerprint until the given
+	// time. When the key is already held it claims nothing and returns the
+	// record holding it; a nil record means the caller now holds the key.
+	// Expired keys are free.
+	Reserve(ctx context.Context, key, fingerprint string, until time.Time) (*IdempotencyRecord, error)
+	// Complete stores the response of the request holding key and keeps it
+	// until expires.
+	Complete(ctx context.Context, key string, record IdempotencyRecord, expires time.Time) error
+	// Release frees a key whose request's response is not kept.
+	Release(ctx context.Context, key string) error
+	// Prune deletes the keys that expired before the given time and
+	// returns how many there were.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+	// PurgeEmployee deletes the keys whose responses hold the data of the
+	// employee, so it is not replayed once erased.
+	PurgeEmployee(ctx context.Context, employeeID int) error
+	// FindByEmployee returns the unexpired responses holding the data of
+	// the employee, the ones PurgeEmployee would delete.
+	FindByEmployee(ctx context.Context, employeeID int) ([]StoredResponse, error)
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitResult is the outcome of counting one request against a quota.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is the time until the full quota is available again.
+	Reset time.Duration
+	// RetryAfter is the time until a rejected client may retry.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts requests per client bucket. Replicas sharing a
+// store enforce quotas together.
+type RateLimitStore interface {
+	// Take counts a request against key, allowing at most limit requests
+	// per window.
+	Take(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+	// Cleanup periodically discards expired state until ctx is cancelled.
+	Cleanup(ctx context.Context)
+}
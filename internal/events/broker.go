@@ -0,0 +1,94 @@
+// Package events fans employee changes out to watchers: a Broker delivers
+// them within this process, and a Feed reads them from the event log
+// shared with other replicas.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// subscriptionBuffer is how many events a watcher may fall behind before it
+// is dropped.
+const subscriptionBuffer = 64
+
+// Broker delivers every published event to all current subscribers. It
+// never blocks publishers: a subscriber whose buffer is full is dropped and
+// its channel closed.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[chan domain.EmployeeEvent]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[chan domain.EmployeeEvent]struct{})}
+}
+
+// Publish delivers the event as it is; its ID, if any, is given by the
+// publisher.
+func (b *Broker) Publish(event domain.EmployeeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.At.IsZero() {
+		event.At = time.Now().UTC()
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events published from now on.
+// It is closed when ctx is done, when the subscriber falls too far behind
+// or when the broker is closed, which the caller can tell apart by
+// checking ctx and Closed.
+func (b *Broker) Subscribe(ctx context.Context) <-chan domain.EmployeeEvent {
+	ch := make(chan domain.EmployeeEvent, subscriptionBuffer)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}()
+	return ch
+}
+
+// Close ends every subscription, for shutdown. Later subscriptions are
+// closed straight away; publishing goes on without subscribers.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Closed reports whether Close was called.
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
@@ -0,0 +1,241 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+const (
+	// DefaultPollInterval is how often watchers read the event log when
+	// FeedConfig.PollInterval is not set.
+	DefaultPollInterval = time.Second
+	// readBatch is how many logged events are read per query.
+	readBatch = 100
+	// gapTimeout is how long a watcher waits for an event whose ID is
+	// missing from the log. IDs are taken when an event is inserted but
+	// only seen once it commits, so with several replicas writing, an event
+	// can show up after others with higher IDs. IDs that never show up,
+	// taken by failed inserts, delay watchers by this much.
+	gapTimeout = 2 * time.Second
+)
+
+var (
+	// ErrClosed ends the watches of a closed broker.
+	ErrClosed = errors.New("events: broker closed")
+	// ErrFellBehind ends a watch without a log that could not keep up with
+	// the events published.
+	ErrFellBehind = errors.New("events: watcher fell too far behind")
+)
+
+// FeedConfig configures how a Feed reads the event log.
+type FeedConfig struct {
+	// PollInterval is how often the log is read for the changes made
+	// through other replicas.
+	PollInterval time.Duration
+	// QueryTimeout bounds every read of the log, when set.
+	QueryTimeout time.Duration
+}
+
+// Feed serves employee changes to watchers. With an event log, watchers
+// read the changes from it, so they see those made through every replica
+// sharing the database: it is read every PollInterval, and straight away
+// when a change is published on the broker of this process. Without a log
+// watchers only get the changes published on the broker.
+type Feed struct {
+	broker *Broker
+	log    domain.EventLog
+	svc    domain.EmployeeService
+	cfg    FeedConfig
+}
+
+// NewFeed returns a feed of the changes logged in log, which may be nil,
+// and published on broker. svc looks up the employees of logged events.
+func NewFeed(broker *Broker, log domain.EventLog, svc domain.EmployeeService, cfg FeedConfig) *Feed {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	return &Feed{broker: broker, log: log, svc: svc, cfg: cfg}
+}
+
+// Watch is a running watch of the feed. Events arrive on C in ID order,
+// except those the log failed to store, which have no ID and are sent as
+// soon as they are published. C is closed when the watch ends.
+type Watch struct {
+	C   <-chan domain.EmployeeEvent
+	err error
+}
+
+// Err returns why C was closed: the error of the watch's context,
+// ErrClosed, ErrFellBehind, or the error reading the log.
+func (w *Watch) Err() error {
+	return w.err
+}
+
+// Watch starts watching the changes that follow the event with ID afterID
+// or, when afterID is negative, those made from the call on. Without a log
+// afterID is ignored: only changes from the call on can be watched. The
+// error is that of looking up where the log ends.
+func (f *Feed) Watch(ctx context.Context, afterID int64) (*Watch, error) {
+	// Subscribe before reading the log, so no change falls between the two
+	live := f.broker.Subscribe(ctx)
+	if f.log != nil && afterID < 0 {
+		qctx, cancel := f.queryContext(ctx)
+		last, err := f.log.LastID(qctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		afterID = last
+	}
+
+	out := make(chan domain.EmployeeEvent)
+	w := &Watch{C: out}
+	go func() {
+		defer close(out)
+		if f.log == nil {
+			w.err = f.watchBroker(ctx, live, out)
+		} else {
+			w.err = f.watchLog(ctx, live, afterID, out)
+		}
+	}()
+	return w, nil
+}
+
+func (f *Feed) watchBroker(ctx context.Context, live <-chan domain.EmployeeEvent, out chan<- domain.EmployeeEvent) error {
+	for event := range live {
+		if !send(ctx, out, event) {
+			return ctx.Err()
+		}
+	}
+	if err := f.ended(ctx); err != nil {
+		return err
+	}
+	return ErrFellBehind
+}
+
+func (f *Feed) watchLog(ctx context.Context, live <-chan domain.EmployeeEvent, afterID int64, out chan<- domain.EmployeeEvent) error {
+	t := &tail{log: f.log, svc: f.svc, lastID: afterID}
+	ticker := time.NewTicker(f.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			qctx, cancel := f.queryContext(ctx)
+			batch, err := t.next(qctx, readBatch)
+			cancel()
+			if err != nil {
+				return f.queryError(ctx, err)
+			}
+			for _, event := range batch {
+				if !send(ctx, out, event) {
+					return ctx.Err()
+				}
+			}
+			if len(batch) < readBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-live:
+			if !ok {
+				if err := f.ended(ctx); err != nil {
+					return err
+				}
+				// Only wake-ups were lost; the log has every change
+				live = f.broker.Subscribe(ctx)
+				continue
+			}
+			// Not in the log, so only watchers of this process see it
+			if event.ID == 0 && !send(ctx, out, event) {
+				return ctx.Err()
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// ended returns why a broker subscription was closed, or nil when it was
+// dropped for falling behind.
+func (f *Feed) ended(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if f.broker.Closed() {
+		return ErrClosed
+	}
+	return nil
+}
+
+// queryError prefers the watch's end to the error of a query it cut short.
+func (f *Feed) queryError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (f *Feed) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.cfg.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, f.cfg.QueryTimeout)
+}
+
+func send(ctx context.Context, out chan<- domain.EmployeeEvent, event domain.EmployeeEvent) bool {
+	select {
+	case out <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// tail reads the log in ID order from after lastID. It does not move past
+// a missing ID until the gap is settled, see gapTimeout, so watchers
+// resuming from the last ID they got cannot skip an event committed late.
+type tail struct {
+	log    domain.EventLog
+	svc    domain.EmployeeService
+	lastID int64
+	// gapSince is when the ID after lastID was first found missing
+	gapSince time.Time
+}
+
+// next returns up to limit events following the last one returned.
+func (t *tail) next(ctx context.Context, limit int) ([]domain.EmployeeEvent, error) {
+	events, err := t.log.FindAfter(ctx, t.lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, e := range events {
+		if e.ID != t.lastID+1 && !t.settled(e) {
+			break
+		}
+		t.lastID, t.gapSince = e.ID, time.Time{}
+		n++
+	}
+	events = events[:n]
+	if len(events) == 0 {
+		return events, nil
+	}
+	return events, withEmployees(ctx, t.svc, events)
+}
+
+// settled reports whether the IDs missing before e can be given up on:
+// when they have been missing for gapTimeout, or e was logged long enough
+// ago that any event before it has committed, as when resuming from an
+// event since pruned.
+func (t *tail) settled(e domain.EmployeeEvent) bool {
+	now := time.Now()
+	if t.gapSince.IsZero() {
+		t.gapSince = now
+	}
+	// At has second precision
+	return now.Sub(t.gapSince) >= gapTimeout || now.Sub(e.At) >= gapTimeout+time.Second
+}
@@ -0,0 +1,187 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/repository/memory"
+	"karyawan-app/internal/service"
+)
+
+// sharedLog is an event log whose events are added with their IDs, the way
+// replicas' commits show up in a shared table.
+type sharedLog struct {
+	mu     sync.Mutex
+	events []domain.EmployeeEvent
+}
+
+func (l *sharedLog) commit(id int64, employeeID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, domain.EmployeeEvent{ID: id, Type: domain.EventDeleted, EmployeeID: employeeID, At: time.Now()})
+	sort.Slice(l.events, func(i, j int) bool { return l.events[i].ID < l.events[j].ID })
+}
+
+func (l *sharedLog) Append(ctx context.Context, event *domain.EmployeeEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	event.ID, event.At = int64(len(l.events)+1), time.Now()
+	l.events = append(l.events, *event)
+	return nil
+}
+
+func (l *sharedLog) FindAfter(ctx context.Context, afterID int64, limit int) ([]domain.EmployeeEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var events []domain.EmployeeEvent
+	for _, e := range l.events {
+		if e.ID > afterID && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (l *sharedLog) LastID(ctx context.Context) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.events) == 0 {
+		return 0, nil
+	}
+	return l.events[len(l.events)-1].ID, nil
+}
+
+func (l *sharedLog) FindByEmployee(ctx context.Context, employeeID int) ([]domain.EmployeeEvent, error) {
+	return nil, nil
+}
+
+func (l *sharedLog) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newFeed(t *testing.T, log domain.EventLog) (*Feed, *Broker) {
+	t.Helper()
+	broker := NewBroker()
+	t.Cleanup(broker.Close)
+	svc := service.NewEmployeeService(memory.NewEmployeeRepository(), memory.NewAuditRepository(), nil)
+	return NewFeed(broker, log, svc, FeedConfig{PollInterval: 10 * time.Millisecond}), broker
+}
+
+func receive(t *testing.T, w *Watch, within time.Duration) (domain.EmployeeEvent, bool) {
+	t.Helper()
+	select {
+	case e, ok := <-w.C:
+		if !ok {
+			t.Fatalf("watch ended: %v", w.Err())
+		}
+		return e, true
+	case <-time.After(within):
+		return domain.EmployeeEvent{}, false
+	}
+}
+
+func TestFeedWaitsForLateCommits(t *testing.T) {
+	log := &sharedLog{}
+	feed, _ := newFeed(t, log)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := feed.Watch(ctx, 0)
+	if err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+	log.commit(1, 10)
+	if e, ok := receive(t, w, time.Second); !ok || e.ID != 1 {
+		t.Fatalf("first event = %+v, %v, expected ID 1", e, ok)
+	}
+
+	// Another replica's event 2 commits after event 3
+	log.commit(3, 30)
+	if e, ok := receive(t, w, 100*time.Millisecond); ok {
+		t.Fatalf("event %d sent while event 2 was missing", e.ID)
+	}
+	log.commit(2, 20)
+	for _, want := range []int64{2, 3} {
+		if e, ok := receive(t, w, time.Second); !ok || e.ID != want {
+			t.Fatalf("event = %+v, %v, expected ID %d", e, ok, want)
+		}
+	}
+
+	// An ID that never shows up only delays the events after it
+	log.commit(5, 50)
+	if e, ok := receive(t, w, gapTimeout+time.Second); !ok || e.ID != 5 {
+		t.Fatalf("event after a lasting gap = %+v, %v, expected ID 5", e, ok)
+	}
+}
+
+func TestFeedFromNow(t *testing.T) {
+	log := &sharedLog{}
+	feed, broker := newFeed(t, log)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	log.commit(1, 10)
+	w, err := feed.Watch(ctx, -1)
+	if err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+
+	// Events the log failed to store are sent without an ID
+	broker.Publish(domain.EmployeeEvent{Type: domain.EventDeleted, EmployeeID: 20})
+	if e, ok := receive(t, w, time.Second); !ok || e.ID != 0 || e.EmployeeID != 20 {
+		t.Fatalf("unlogged event = %+v, %v", e, ok)
+	}
+	log.commit(2, 30)
+	if e, ok := receive(t, w, time.Second); !ok || e.ID != 2 {
+		t.Fatalf("event = %+v, %v, expected ID 2 and not the one before the watch", e, ok)
+	}
+
+	broker.Close()
+	if _, ok := <-w.C; ok {
+		t.Fatal("watch still open after the broker closed")
+	}
+	if w.Err() != ErrClosed {
+		t.Errorf("Err() = %v, expected ErrClosed", w.Err())
+	}
+}
+
+func TestFailedChangesAreNotPublished(t *testing.T) {
+	log := &sharedLog{}
+	broker := NewBroker()
+	t.Cleanup(broker.Close)
+	svc := PublishEmployeeChanges(service.NewEmployeeService(memory.NewEmployeeRepository(), memory.NewAuditRepository(), nil), broker, log)
+
+	ctx := context.Background()
+	missing := &domain.Employee{ID: 42, Name: "Dewi", Email: "dewi@example.com", Position: "Staff", Role: "HR", Phone: "081234567890", Alamat: "Jl. Sudirman No. 1"}
+	if err := svc.UpdateEmployee(ctx, missing); err == nil {
+		t.Error("UpdateEmployee() of an unknown ID succeeded")
+	}
+	if err := svc.DeleteEmployee(ctx, 42); err == nil {
+		t.Error("DeleteEmployee() of an unknown ID succeeded")
+	}
+	if last, _ := log.LastID(ctx); last != 0 {
+		t.Errorf("%d events logged for failed changes, expected none", last)
+	}
+}
+
+func TestCheckBacklog(t *testing.T) {
+	log := &sharedLog{}
+	check := CheckBacklog(log, time.Hour, 30*time.Minute)
+	ctx := context.Background()
+	if err := check(ctx); err != nil {
+		t.Errorf("check of an empty log = %v", err)
+	}
+
+	log.events = []domain.EmployeeEvent{{ID: 1, Type: domain.EventDeleted, EmployeeID: 1, At: time.Now().Add(-80 * time.Minute)}}
+	if err := check(ctx); err != nil {
+		t.Errorf("check within the allowed lag = %v", err)
+	}
+	log.events[0].At = time.Now().Add(-2 * time.Hour)
+	if err := check(ctx); err == nil {
+		t.Error("check of an event unpruned for an hour succeeded")
+	}
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// pruneInterval is how often PruneLog deletes expired events.
+const pruneInterval = time.Hour
+
+// withEmployees sets the employee of logged events. The log holds no
+// personal data, so the events of employees that still exist carry their
+// current record rather than the one at the time.
+func withEmployees(ctx context.Context, svc domain.EmployeeService, events []domain.EmployeeEvent) error {
+	var ids []int
+	for _, e := range events {
+		if e.Type != domain.EventDeleted {
+			ids = append(ids, e.EmployeeID)
+		}
+	}
+	employees, err := svc.GetEmployees(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]*domain.Employee, len(employees))
+	for i := range employees {
+		byID[employees[i].ID] = &employees[i]
+	}
+	for i := range events {
+		if events[i].Type != domain.EventDeleted {
+			events[i].Employee = byID[events[i].EmployeeID]
+		}
+	}
+	return nil
+}
+
+// CheckBacklog returns a readiness check failing when the oldest event in
+// log has waited for pruning longer than maxLag past retention, that is
+// when PruneLog has stopped keeping up and the table keeps growing.
+func CheckBacklog(log domain.EventLog, retention, maxLag time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		oldest, err := log.FindAfter(ctx, 0, 1)
+		if err != nil {
+			return err
+		}
+		if len(oldest) == 0 {
+			return nil
+		}
+		if lag := time.Since(oldest[0].At) - retention; lag > maxLag {
+			return fmt.Errorf("event log backlog: expired events waiting %s for pruning, over %s", lag.Round(time.Second), maxLag)
+		}
+		return nil
+	}
+}
+
+// PruneLog deletes the events older than retention from log once an hour,
+// until ctx is cancelled.
+func PruneLog(ctx context.Context, log domain.EventLog, retention time.Duration) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := log.Prune(ctx, time.Now().Add(-retention))
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("failed to prune employee events", "error", err)
+				}
+				continue
+			}
+			if n > 0 {
+				slog.Info("pruned employee events", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"karyawan-app/internal/domain"
+)
+
+// PublishEmployeeChanges wraps svc so every successful create, update,
+// anonymization and delete, including those of bulk requests, is published
+// on b. Failed changes, such as those of employees that do not exist, are
+// not. Anonymizations are published as updates. With a log, events are
+// appended to it first and carry its IDs, so watchers can resume from it;
+// events it failed to store are published without an ID, so they are
+// never taken for a point to resume from. Without a log, which may be nil,
+// events are numbered in this process.
+func PublishEmployeeChanges(svc domain.EmployeeService, b *Broker, log domain.EventLog) domain.EmployeeService {
+	return &employeeService{EmployeeService: svc, broker: b, log: log}
+}
+
+type employeeService struct {
+	domain.EmployeeService
+	broker *Broker
+	log    domain.EventLog
+	// mu keeps events numbered in this process published in the order of
+	// their IDs. Logged events need no such care: watchers read them from
+	// the log, which waits for late ones, and the broker only wakes them.
+	mu sync.Mutex
+	// lastID numbers the events when there is no log
+	lastID int64
+}
+
+func (s *employeeService) CreateEmployee(ctx context.Context, employee *domain.Employee) error {
+	if err := s.EmployeeService.CreateEmployee(ctx, employee); err != nil {
+		return err
+	}
+	s.publish(ctx, domain.EventCreated, employee.ID, employee.Role, employee)
+	return nil
+}
+
+func (s *employeeService) UpdateEmployee(ctx context.Context, employee *domain.Employee) error {
+	if err := s.EmployeeService.UpdateEmployee(ctx, employee); err != nil {
+		return err
+	}
+	s.publish(ctx, domain.EventUpdated, employee.ID, employee.Role, employee)
+	return nil
+}
+
+func (s *employeeService) AnonymizeEmployee(ctx context.Context, id int) (*domain.Employee, error) {
+	employee, err := s.EmployeeService.AnonymizeEmployee(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if employee != nil {
+		s.publish(ctx, domain.EventUpdated, id, employee.Role, employee)
+	}
+	return employee, nil
+}
+
+func (s *employeeService) DeleteEmployee(ctx context.Context, id int) error {
+	// The role is gone with the record, so it is looked up beforehand
+	var role string
+	if employee, err := s.EmployeeService.GetEmployee(ctx, id); err == nil && employee != nil {
+		role = employee.Role
+	}
+	if err := s.EmployeeService.DeleteEmployee(ctx, id); err != nil {
+		return err
+	}
+	s.publish(ctx, domain.EventDeleted, id, role, nil)
+	return nil
+}
+
+// ExportEmployeeData adds the employee's events in the log, which hold no
+// personal data beyond the employee's ID and role.
+func (s *employeeService) ExportEmployeeData(ctx context.Context, id int) (*domain.DataExport, error) {
+	export, err := s.EmployeeService.ExportEmployeeData(ctx, id)
+	if err != nil || export == nil || s.log == nil {
+		return export, err
+	}
+	if export.Events, err = s.log.FindByEmployee(ctx, id); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *employeeService) BulkEmployees(ctx context.Context, ops []domain.BulkOperation, atomic bool) ([]domain.BulkResult, error) {
+	// Like DeleteEmployee, roles of deleted employees are looked up first
+	var deleted []int
+	for _, op := range ops {
+		if op.Op == domain.BulkDelete {
+			deleted = append(deleted, op.ID)
+		}
+	}
+	roles := make(map[int]string, len(deleted))
+	if len(deleted) > 0 {
+		if employees, err := s.EmployeeService.GetEmployees(ctx, deleted); err == nil {
+			for _, e := range employees {
+				roles[e.ID] = e.Role
+			}
+		}
+	}
+
+	results, err := s.EmployeeService.BulkEmployees(ctx, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		switch op := ops[i]; op.Op {
+		case domain.BulkCreate:
+			s.publish(ctx, domain.EventCreated, r.Employee.ID, r.Employee.Role, r.Employee)
+		case domain.BulkUpdate:
+			s.publish(ctx, domain.EventUpdated, op.ID, r.Employee.Role, r.Employee)
+		case domain.BulkDelete:
+			s.publish(ctx, domain.EventDeleted, op.ID, roles[op.ID], nil)
+		}
+	}
+	return results, nil
+}
+
+// publish sends a copy of employee, so later changes by the caller do not
+// reach watchers. Like the audit log, the event is appended even if ctx
+// was cancelled meanwhile, and a failure to append is logged rather than
+// returned; the event is then only seen by current watchers of this
+// process.
+func (s *employeeService) publish(ctx context.Context, typ string, id int, role string, employee *domain.Employee) {
+	event := domain.EmployeeEvent{Type: typ, EmployeeID: id, Role: role}
+	if employee != nil {
+		e := *employee
+		if e.Address != nil {
+			addr := *e.Address
+			e.Address = &addr
+		}
+		event.Employee = &e
+	}
+
+	if s.log == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.lastID++
+		event.ID = s.lastID
+	} else if err := s.log.Append(context.WithoutCancel(ctx), &event); err != nil {
+		slog.ErrorContext(ctx, "failed to append employee event", "type", typ, "employee_id", id, "error", err)
+		event.ID = 0
+	}
+	s.broker.Publish(event)
+}
@@ -0,0 +1,94 @@
+package graphapi
+
+import (
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// listFields are the fields returning lists, with the number of items they
+// return when their first argument is left out.
+var listFields = map[string]int{
+	"employees": defaultPageSize,
+	"auditLog":  defaultAuditEntries,
+}
+
+// complexity estimates how many fields req resolves: every field counts
+// one, and the fields below a list field count once per item requested.
+// Queries that do not parse report false; execution rejects them anyway.
+// Without an operation name the costliest operation is counted.
+func complexity(req Request) (int, bool) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil {
+		return 0, false
+	}
+	c := &costs{doc: doc, vars: req.Variables, visiting: make(map[string]bool)}
+	var max int
+	for _, op := range doc.Operations {
+		if req.OperationName != "" && op.Name != req.OperationName {
+			continue
+		}
+		if cost := c.selections(op.SelectionSet); cost > max {
+			max = cost
+		}
+	}
+	return max, true
+}
+
+type costs struct {
+	doc  *ast.QueryDocument
+	vars map[string]interface{}
+	// visiting guards against fragments that spread themselves, which
+	// validation rejects later
+	visiting map[string]bool
+}
+
+func (c *costs) selections(set ast.SelectionSet) int {
+	var cost int
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			cost += 1 + c.selections(sel.SelectionSet)*c.items(sel)
+		case *ast.InlineFragment:
+			cost += c.selections(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			frag := c.doc.Fragments.ForName(sel.Name)
+			if frag == nil || c.visiting[sel.Name] {
+				continue
+			}
+			c.visiting[sel.Name] = true
+			cost += c.selections(frag.SelectionSet)
+			delete(c.visiting, sel.Name)
+		}
+	}
+	return cost
+}
+
+// items returns how many items a field returns at most, 1 for fields that
+// are not lists.
+func (c *costs) items(field *ast.Field) int {
+	n, ok := listFields[field.Name]
+	if !ok {
+		return 1
+	}
+	arg := field.Arguments.ForName("first")
+	if arg == nil || arg.Value == nil {
+		return n
+	}
+	switch arg.Value.Kind {
+	case ast.IntValue:
+		if v, err := strconv.Atoi(arg.Value.Raw); err == nil {
+			n = v
+		}
+	case ast.Variable:
+		// JSON numbers decode as float64
+		if v, ok := c.vars[arg.Value.Raw].(float64); ok {
+			n = int(v)
+		}
+	}
+	if n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+// Package graphapi serves a GraphQL schema over employees, defined in
+// schema.graphql, on top of domain.EmployeeService. Lists are cursor
+// connections keyed like ListEmployees pages, related records are loaded
+// in batches per request rather than once per employee, and queries are
+// bounded in depth and estimated cost before they run.
+package graphapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"karyawan-app/internal/domain"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// SDL returns the schema in the GraphQL schema definition language.
+func SDL() string {
+	return schemaSDL
+}
+
+// Limits bound the queries Execute accepts.
+type Limits struct {
+	// MaxDepth is the deepest nesting of selections.
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of fields a query
+	// resolves, see complexity.
+	MaxComplexity int
+}
+
+// Schema executes GraphQL requests.
+type Schema struct {
+	schema  *graphql.Schema
+	service domain.EmployeeService
+	limits  Limits
+}
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response is the result of a request, with data and errors as the
+// GraphQL specification lays them out.
+type Response = graphql.Response
+
+func New(service domain.EmployeeService, limits Limits) (*Schema, error) {
+	schema, err := graphql.ParseSchema(schemaSDL, &resolver{service: service},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(limits.MaxDepth),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GraphQL schema: %w", err)
+	}
+	return &Schema{schema: schema, service: service, limits: limits}, nil
+}
+
+// Execute runs a request. Queries over the complexity limit are rejected
+// without running any resolver.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	if cost, ok := complexity(req); ok && s.limits.MaxComplexity > 0 && cost > s.limits.MaxComplexity {
+		return &Response{Errors: []*gqlerrors.QueryError{{
+			Message:    fmt.Sprintf("query complexity %d exceeds the limit of %d", cost, s.limits.MaxComplexity),
+			Extensions: map[string]interface{}{"code": "QUERY_TOO_COMPLEX"},
+		}}}
+	}
+	ctx = withLoaders(ctx, s.service)
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
@@ -0,0 +1,274 @@
+package graphapi
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/repository/memory"
+	"karyawan-app/internal/service"
+)
+
+// countingEmployees counts the lookups of employees by ID.
+type countingEmployees struct {
+	domain.EmployeeRepository
+	findByID, findByIDs atomic.Int32
+}
+
+func (r *countingEmployees) FindByID(ctx context.Context, id int) (*domain.Employee, error) {
+	r.findByID.Add(1)
+	return r.EmployeeRepository.FindByID(ctx, id)
+}
+
+func (r *countingEmployees) FindByIDs(ctx context.Context, ids []int) ([]domain.Employee, error) {
+	r.findByIDs.Add(1)
+	return r.EmployeeRepository.FindByIDs(ctx, ids)
+}
+
+// countingAudit counts the lookups of audit entries.
+type countingAudit struct {
+	domain.AuditRepository
+	findByEmployee, findByEmployees atomic.Int32
+}
+
+func (r *countingAudit) FindByEmployee(ctx context.Context, employeeID int) ([]domain.AuditEntry, error) {
+	r.findByEmployee.Add(1)
+	return r.AuditRepository.FindByEmployee(ctx, employeeID)
+}
+
+func (r *countingAudit) FindByEmployees(ctx context.Context, employeeIDs []int) ([]domain.AuditEntry, error) {
+	r.findByEmployees.Add(1)
+	return r.AuditRepository.FindByEmployees(ctx, employeeIDs)
+}
+
+type testSchema struct {
+	*Schema
+	employees *countingEmployees
+	audit     *countingAudit
+}
+
+func newTestSchema(t *testing.T, limits Limits) *testSchema {
+	t.Helper()
+	employees := &countingEmployees{EmployeeRepository: memory.NewEmployeeRepository()}
+	audit := &countingAudit{AuditRepository: memory.NewAuditRepository()}
+	schema, err := New(service.NewEmployeeService(employees, audit, nil), limits)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return &testSchema{Schema: schema, employees: employees, audit: audit}
+}
+
+// exec runs query and decodes its data into out. It fails the test on
+// errors.
+func (s *testSchema) exec(t *testing.T, query string, vars map[string]interface{}, out interface{}) {
+	t.Helper()
+	resp := s.Execute(context.Background(), Request{Query: query, Variables: vars})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query %s failed: %v", query, resp.Errors)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		t.Fatalf("failed to decode %s: %v", resp.Data, err)
+	}
+}
+
+// expectError runs query and checks that it fails with the given message
+// and extensions.code.
+func (s *testSchema) expectError(t *testing.T, query, message, code string) {
+	t.Helper()
+	resp := s.Execute(context.Background(), Request{Query: query})
+	if len(resp.Errors) != 1 {
+		t.Fatalf("query %s: expected 1 error, got %v", query, resp.Errors)
+	}
+	err := resp.Errors[0]
+	if err.Message != message || err.Extensions["code"] != code {
+		t.Errorf("query %s: error = %q %v, expected %q with code %s", query, err.Message, err.Extensions, message, code)
+	}
+}
+
+const createMutation = `mutation($input: EmployeeInput!) { createEmployee(input: $input) { id } }`
+
+func (s *testSchema) create(t *testing.T, n int) string {
+	t.Helper()
+	var data struct {
+		CreateEmployee struct{ ID string }
+	}
+	s.exec(t, createMutation, map[string]interface{}{"input": map[string]interface{}{
+		"name":     "Karyawan " + strconv.Itoa(n),
+		"email":    "karyawan" + strconv.Itoa(n) + "@example.com",
+		"position": "Software Engineer",
+		"role":     "Developer",
+		"phone":    "081234567" + strconv.Itoa(10+n),
+		"alamat":   "Jl. Sudirman No. 1, Jakarta Selatan",
+	}}, &data)
+	return data.CreateEmployee.ID
+}
+
+type connection struct {
+	Employees struct {
+		Edges []struct {
+			Cursor string
+			Node   struct {
+				ID       string
+				Name     string
+				AuditLog []struct{ Action string }
+			}
+		}
+		PageInfo struct {
+			HasNextPage bool
+			EndCursor   *string
+		}
+	}
+}
+
+func TestEmployeesConnection(t *testing.T) {
+	s := newTestSchema(t, Limits{MaxDepth: 10, MaxComplexity: 5000})
+	for i := 1; i <= 5; i++ {
+		s.create(t, i)
+	}
+
+	const query = `query($after: String) {
+		employees(first: 2, after: $after) {
+			edges { cursor node { id name } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+	var ids []string
+	var after interface{}
+	for page := 0; ; page++ {
+		var data connection
+		s.exec(t, query, map[string]interface{}{"after": after}, &data)
+		for _, edge := range data.Employees.Edges {
+			ids = append(ids, edge.Node.ID)
+		}
+		if !data.Employees.PageInfo.HasNextPage {
+			break
+		}
+		if page > 3 {
+			t.Fatal("pagination does not end")
+		}
+		after = *data.Employees.PageInfo.EndCursor
+	}
+	expected := []string{"5", "4", "3", "2", "1"}
+	if len(ids) != len(expected) {
+		t.Fatalf("paged through %v, expected %v", ids, expected)
+	}
+	for i := range ids {
+		if ids[i] != expected[i] {
+			t.Fatalf("paged through %v, expected %v", ids, expected)
+		}
+	}
+
+	s.expectError(t, `{ employees(after: "nonsense") { edges { cursor } } }`, "Invalid cursor", "INVALID_ARGUMENT")
+	s.expectError(t, `{ employees(first: 101) { edges { cursor } } }`, "first must be between 1 and 100", "INVALID_ARGUMENT")
+}
+
+func TestBatching(t *testing.T) {
+	s := newTestSchema(t, Limits{MaxDepth: 10, MaxComplexity: 5000})
+	for i := 1; i <= 30; i++ {
+		s.create(t, i)
+	}
+
+	// More items than the executor resolves at once
+	var data connection
+	s.exec(t, `{ employees(first: 30) { edges { node { id auditLog { action } } } } }`, nil, &data)
+	if len(data.Employees.Edges) != 30 {
+		t.Fatalf("got %d employees, expected 30", len(data.Employees.Edges))
+	}
+	for _, edge := range data.Employees.Edges {
+		if len(edge.Node.AuditLog) != 1 || edge.Node.AuditLog[0].Action != domain.AuditCreate {
+			t.Errorf("audit log of employee %s = %v, expected one create", edge.Node.ID, edge.Node.AuditLog)
+		}
+	}
+	if n := s.audit.findByEmployees.Load(); n != 1 {
+		t.Errorf("audit log fetched in %d batches, expected 1", n)
+	}
+	if n := s.audit.findByEmployee.Load(); n != 0 {
+		t.Errorf("audit log fetched %d times per employee, expected 0", n)
+	}
+
+	var byID map[string]*struct{ Name string }
+	s.exec(t, `{ a: employee(id: 1) { name } b: employee(id: 2) { name } c: employee(id: 999) { name } }`, nil, &byID)
+	if byID["a"] == nil || byID["b"] == nil || byID["c"] != nil {
+		t.Errorf("employees by ID = %v, expected a and b but not c", byID)
+	}
+	if n := s.employees.findByIDs.Load(); n != 1 {
+		t.Errorf("employees fetched in %d batches, expected 1", n)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	s := newTestSchema(t, Limits{MaxDepth: 4, MaxComplexity: 100})
+
+	var data connection
+	s.exec(t, `{ employees(first: 5) { edges { node { id name } } } }`, nil, &data)
+
+	// employees > edges > node > auditLog > action
+	resp := s.Execute(context.Background(), Request{Query: `{ employees { edges { node { auditLog { action } } } } }`})
+	if len(resp.Errors) == 0 {
+		t.Error("query deeper than the limit succeeded")
+	}
+
+	// 100 employees with 20 audit entries each
+	s.expectError(t, `{ employees(first: 100) { edges { node { auditLog { id } } } } }`,
+		"query complexity 2301 exceeds the limit of 100", "QUERY_TOO_COMPLEX")
+	resp = s.Execute(context.Background(), Request{
+		Query:     `query($n: Int) { employees(first: $n) { edges { node { id } } } }`,
+		Variables: map[string]interface{}{"n": float64(50)},
+	})
+	if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != "QUERY_TOO_COMPLEX" {
+		t.Errorf("errors = %v, expected the complexity limit to count variables", resp.Errors)
+	}
+	if n := s.employees.findByIDs.Load() + s.employees.findByID.Load(); n != 0 {
+		t.Errorf("rejected queries hit the repository %d times", n)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	s := newTestSchema(t, Limits{MaxDepth: 10, MaxComplexity: 5000})
+	id := s.create(t, 1)
+
+	var updated struct {
+		UpdateEmployee struct {
+			Position string
+			Address  *struct{ Provinsi string }
+			AuditLog []struct{ Action string }
+		}
+	}
+	s.exec(t, `mutation($id: ID!) {
+		updateEmployee(id: $id, input: {
+			name: "Karyawan 1", email: "karyawan1@example.com", position: "Tech Lead",
+			role: "Developer", phone: "08123456711", alamat: "Jl. Sudirman No. 1, Jakarta Selatan"
+		}) { position address { provinsi } auditLog { action } }
+	}`, map[string]interface{}{"id": id}, &updated)
+	if updated.UpdateEmployee.Position != "Tech Lead" {
+		t.Errorf("position = %q, expected Tech Lead", updated.UpdateEmployee.Position)
+	}
+	if n := len(updated.UpdateEmployee.AuditLog); n != 2 {
+		t.Errorf("audit log has %d entries, expected 2", n)
+	}
+
+	s.expectError(t, `mutation { createEmployee(input: {name: "", email: "x@example.com", position: "p", role: "r", phone: "08123456789"}) { id } }`,
+		"name is required", "INVALID_ARGUMENT")
+	s.expectError(t, `mutation { anonymizeEmployee(id: 999) { id } }`, "Employee not found", "NOT_FOUND")
+	s.expectError(t, `{ employee(id: "abc") { id } }`, "Invalid employee ID", "INVALID_ARGUMENT")
+
+	var anonymized struct {
+		AnonymizeEmployee struct{ Name string }
+	}
+	s.exec(t, `mutation($id: ID!) { anonymizeEmployee(id: $id) { name } }`, map[string]interface{}{"id": id}, &anonymized)
+	s.expectError(t, `mutation { anonymizeEmployee(id: `+id+`) { id } }`, "employee has been anonymized", "CONFLICT")
+
+	var deleted struct{ DeleteEmployee string }
+	s.exec(t, `mutation($id: ID!) { deleteEmployee(id: $id) }`, map[string]interface{}{"id": id}, &deleted)
+	if deleted.DeleteEmployee != id {
+		t.Errorf("deleteEmployee = %q, expected %s", deleted.DeleteEmployee, id)
+	}
+	var fetched struct{ Employee *struct{ ID string } }
+	s.exec(t, `query($id: ID!) { employee(id: $id) { id } }`, map[string]interface{}{"id": id}, &fetched)
+	if fetched.Employee != nil {
+		t.Errorf("deleted employee still found: %v", fetched.Employee)
+	}
+}
@@ -0,0 +1,135 @@
+package graphapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// batchWindow is how long a loader collects keys before fetching them.
+// Resolvers of sibling fields run concurrently, so they all ask within it.
+const batchWindow = time.Millisecond
+
+// loader fetches values by ID in batches and caches them for the rest of
+// the request, so resolving a field on every item of a list costs one
+// query instead of one per item.
+type loader[V any] struct {
+	fetch func(ctx context.Context, ids []int) (map[int]V, error)
+
+	mu      sync.Mutex
+	entries map[int]*entry[V]
+	pending *batch[V]
+}
+
+type entry[V any] struct {
+	batch *batch[V]
+	value V
+	found bool
+}
+
+// batch is a set of IDs fetched together. done is closed once the entries
+// of its IDs are filled in.
+type batch[V any] struct {
+	ids  []int
+	done chan struct{}
+	err  error
+}
+
+func newLoader[V any](fetch func(ctx context.Context, ids []int) (map[int]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, entries: make(map[int]*entry[V])}
+}
+
+// load returns the value for id, and false if there is none.
+func (l *loader[V]) load(ctx context.Context, id int) (V, bool, error) {
+	l.mu.Lock()
+	e := l.add(ctx, id)
+	l.mu.Unlock()
+
+	select {
+	case <-e.batch.done:
+		return e.value, e.found, e.batch.err
+	case <-ctx.Done():
+		var zero V
+		return zero, false, ctx.Err()
+	}
+}
+
+// want adds ids to the next batch without waiting for it. Resolvers of
+// lists call it with the IDs of all their items, because only a limited
+// number of item resolvers run at a time.
+func (l *loader[V]) want(ctx context.Context, ids ...int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		l.add(ctx, id)
+	}
+}
+
+// add returns the entry for id, scheduling a fetch of it if it is new.
+// l.mu must be held.
+func (l *loader[V]) add(ctx context.Context, id int) *entry[V] {
+	if e, ok := l.entries[id]; ok {
+		return e
+	}
+	if l.pending == nil {
+		b := &batch[V]{done: make(chan struct{})}
+		l.pending = b
+		time.AfterFunc(batchWindow, func() { l.dispatch(ctx, b) })
+	}
+	e := &entry[V]{batch: l.pending}
+	l.pending.ids = append(l.pending.ids, id)
+	l.entries[id] = e
+	return e
+}
+
+func (l *loader[V]) dispatch(ctx context.Context, b *batch[V]) {
+	l.mu.Lock()
+	l.pending = nil
+	l.mu.Unlock()
+
+	values, err := l.fetch(ctx, b.ids)
+
+	l.mu.Lock()
+	for _, id := range b.ids {
+		e := l.entries[id]
+		e.value, e.found = values[id]
+		if err != nil {
+			// Let a later request for the same ID try again
+			delete(l.entries, id)
+		}
+	}
+	l.mu.Unlock()
+	b.err = err
+	close(b.done)
+}
+
+// loaders are the loaders of one request.
+type loaders struct {
+	employees *loader[*domain.Employee]
+	auditLogs *loader[[]domain.AuditEntry]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, service domain.EmployeeService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		employees: newLoader(func(ctx context.Context, ids []int) (map[int]*domain.Employee, error) {
+			employees, err := service.GetEmployees(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int]*domain.Employee, len(employees))
+			for i := range employees {
+				byID[employees[i].ID] = &employees[i]
+			}
+			return byID, nil
+		}),
+		auditLogs: newLoader(service.GetAuditLogs),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
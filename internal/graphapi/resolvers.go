@@ -0,0 +1,370 @@
+package graphapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+)
+
+const (
+	// defaultPageSize is the first argument of employees when left out.
+	defaultPageSize = 20
+	// defaultAuditEntries is the first argument of auditLog when left out.
+	defaultAuditEntries = 20
+)
+
+// resolver is the root of the schema, resolving Query and Mutation.
+type resolver struct {
+	service domain.EmployeeService
+}
+
+type idArgs struct {
+	ID graphql.ID
+}
+
+func (r *resolver) Employee(ctx context.Context, args idArgs) (*employeeResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	employee, found, err := loadersFrom(ctx).employees.load(ctx, id)
+	if err != nil {
+		return nil, serviceError(ctx, "employee", err, apierror.Internal, "Failed to fetch employee")
+	}
+	if !found {
+		return nil, nil
+	}
+	return &employeeResolver{employee}, nil
+}
+
+func (r *resolver) EmployeeByEmail(ctx context.Context, args struct{ Email string }) (*employeeResolver, error) {
+	employee, err := r.service.GetEmployeeByEmail(ctx, args.Email)
+	if err != nil {
+		return nil, serviceError(ctx, "employeeByEmail", err, apierror.Internal, "Failed to fetch employee")
+	}
+	if employee == nil {
+		return nil, nil
+	}
+	return &employeeResolver{employee}, nil
+}
+
+type employeesArgs struct {
+	First int32
+	After *string
+}
+
+func (r *resolver) Employees(ctx context.Context, args employeesArgs) (*connectionResolver, error) {
+	if args.First < 1 || args.First > domain.MaxPageSize {
+		return nil, invalid(fmt.Sprintf("first must be between 1 and %d", domain.MaxPageSize))
+	}
+	var cursor int
+	if args.After != nil {
+		var err error
+		if cursor, err = domain.DecodeCursor(*args.After); err != nil {
+			return nil, invalid("Invalid cursor")
+		}
+	}
+	page, err := r.service.ListEmployees(ctx, cursor, int(args.First))
+	if err != nil {
+		return nil, serviceError(ctx, "employees", err, apierror.Internal, "Failed to fetch employees")
+	}
+
+	ids := make([]int, len(page.Employees))
+	for i, e := range page.Employees {
+		ids[i] = e.ID
+	}
+	loadersFrom(ctx).auditLogs.want(ctx, ids...)
+	return &connectionResolver{page}, nil
+}
+
+func (r *resolver) Headcount(ctx context.Context) ([]*roleHeadcountResolver, error) {
+	counts, err := r.service.HeadcountByRole(ctx)
+	if err != nil {
+		return nil, serviceError(ctx, "headcount", err, apierror.Internal, "Failed to count employees")
+	}
+	headcount := make([]*roleHeadcountResolver, 0, len(counts))
+	for role, count := range counts {
+		headcount = append(headcount, &roleHeadcountResolver{role: role, count: count})
+	}
+	sort.Slice(headcount, func(i, j int) bool { return headcount[i].role < headcount[j].role })
+	return headcount, nil
+}
+
+type employeeInputArgs struct {
+	Input employeeInput
+}
+
+func (r *resolver) CreateEmployee(ctx context.Context, args employeeInputArgs) (*employeeResolver, error) {
+	employee := args.Input.employee()
+	if err := r.service.CreateEmployee(ctx, employee); err != nil {
+		return nil, serviceError(ctx, "createEmployee", err, apierror.Invalid, err.Error())
+	}
+	return &employeeResolver{employee}, nil
+}
+
+func (r *resolver) UpdateEmployee(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input employeeInput
+}) (*employeeResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	employee := args.Input.employee()
+	employee.ID = id
+	if err := r.service.UpdateEmployee(ctx, employee); err != nil {
+		return nil, serviceError(ctx, "updateEmployee", err, apierror.Invalid, err.Error())
+	}
+	return &employeeResolver{employee}, nil
+}
+
+func (r *resolver) DeleteEmployee(ctx context.Context, args idArgs) (graphql.ID, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return "", err
+	}
+	if err := r.service.DeleteEmployee(ctx, id); err != nil {
+		return "", serviceError(ctx, "deleteEmployee", err, apierror.Internal, err.Error())
+	}
+	return args.ID, nil
+}
+
+func (r *resolver) AnonymizeEmployee(ctx context.Context, args idArgs) (*employeeResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	employee, err := r.service.AnonymizeEmployee(ctx, id)
+	if err != nil {
+		return nil, serviceError(ctx, "anonymizeEmployee", err, apierror.Internal, "Failed to anonymize employee")
+	}
+	if employee == nil {
+		return nil, &queryError{kind: apierror.NotFound, message: apierror.MsgNotFound}
+	}
+	return &employeeResolver{employee}, nil
+}
+
+type employeeInput struct {
+	Name     string
+	Email    string
+	Position string
+	Role     string
+	Phone    string
+	Alamat   *string
+	Address  *addressInput
+}
+
+type addressInput struct {
+	Street        string
+	RT            *string
+	RW            *string
+	Kelurahan     *string
+	Kecamatan     *string
+	KotaKabupaten *string
+	Provinsi      *string
+	KodePos       *string
+}
+
+func (in employeeInput) employee() *domain.Employee {
+	employee := &domain.Employee{
+		Name:     in.Name,
+		Email:    in.Email,
+		Position: in.Position,
+		Role:     in.Role,
+		Phone:    in.Phone,
+		Alamat:   deref(in.Alamat),
+	}
+	if a := in.Address; a != nil {
+		employee.Address = &domain.Address{
+			Street:        a.Street,
+			RT:            deref(a.RT),
+			RW:            deref(a.RW),
+			Kelurahan:     deref(a.Kelurahan),
+			Kecamatan:     deref(a.Kecamatan),
+			KotaKabupaten: deref(a.KotaKabupaten),
+			Provinsi:      deref(a.Provinsi),
+			KodePos:       deref(a.KodePos),
+		}
+	}
+	return employee
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+type employeeResolver struct {
+	e *domain.Employee
+}
+
+func (r *employeeResolver) ID() graphql.ID   { return graphql.ID(strconv.Itoa(r.e.ID)) }
+func (r *employeeResolver) Name() string     { return r.e.Name }
+func (r *employeeResolver) Email() string    { return r.e.Email }
+func (r *employeeResolver) Position() string { return r.e.Position }
+func (r *employeeResolver) Role() string     { return r.e.Role }
+func (r *employeeResolver) Phone() string    { return r.e.Phone }
+func (r *employeeResolver) Alamat() string   { return r.e.Alamat }
+func (r *employeeResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.e.CreatedAt}
+}
+
+func (r *employeeResolver) UpdatedAt() *graphql.Time {
+	if r.e.UpdatedAt.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: r.e.UpdatedAt}
+}
+
+func (r *employeeResolver) AnonymizedAt() *graphql.Time {
+	if r.e.AnonymizedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.e.AnonymizedAt}
+}
+
+func (r *employeeResolver) Address() *addressResolver {
+	if r.e.Address == nil {
+		return nil
+	}
+	return &addressResolver{r.e.Address}
+}
+
+func (r *employeeResolver) AuditLog(ctx context.Context, args struct{ First int32 }) ([]*auditEntryResolver, error) {
+	if args.First < 0 {
+		return nil, invalid("first must not be negative")
+	}
+	entries, _, err := loadersFrom(ctx).auditLogs.load(ctx, r.e.ID)
+	if err != nil {
+		return nil, serviceError(ctx, "auditLog", err, apierror.Internal, "Failed to fetch audit log")
+	}
+	if len(entries) > int(args.First) {
+		entries = entries[:args.First]
+	}
+	resolvers := make([]*auditEntryResolver, len(entries))
+	for i := range entries {
+		resolvers[i] = &auditEntryResolver{&entries[i]}
+	}
+	return resolvers, nil
+}
+
+type addressResolver struct {
+	a *domain.Address
+}
+
+func (r *addressResolver) Street() string        { return r.a.Street }
+func (r *addressResolver) RT() string            { return r.a.RT }
+func (r *addressResolver) RW() string            { return r.a.RW }
+func (r *addressResolver) Kelurahan() string     { return r.a.Kelurahan }
+func (r *addressResolver) Kecamatan() string     { return r.a.Kecamatan }
+func (r *addressResolver) KotaKabupaten() string { return r.a.KotaKabupaten }
+func (r *addressResolver) Provinsi() string      { return r.a.Provinsi }
+func (r *addressResolver) KodePos() string       { return r.a.KodePos }
+
+type auditEntryResolver struct {
+	e *domain.AuditEntry
+}
+
+func (r *auditEntryResolver) ID() graphql.ID { return graphql.ID(strconv.Itoa(r.e.ID)) }
+func (r *auditEntryResolver) Action() string { return r.e.Action }
+func (r *auditEntryResolver) Fields() []string {
+	if r.e.Fields == nil {
+		return []string{}
+	}
+	return r.e.Fields
+}
+func (r *auditEntryResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.e.CreatedAt} }
+
+type roleHeadcountResolver struct {
+	role  string
+	count int
+}
+
+func (r *roleHeadcountResolver) Role() string { return r.role }
+func (r *roleHeadcountResolver) Count() int32 { return int32(r.count) }
+
+type connectionResolver struct {
+	page *domain.EmployeePage
+}
+
+func (r *connectionResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, len(r.page.Employees))
+	for i := range r.page.Employees {
+		edges[i] = &edgeResolver{&r.page.Employees[i]}
+	}
+	return edges
+}
+
+func (r *connectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNextPage: r.page.Next != 0}
+	if n := len(r.page.Employees); n > 0 {
+		cursor := domain.EncodeCursor(r.page.Employees[n-1].ID)
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+// edgeResolver resolves an employee of a connection. Its cursor is the
+// page cursor of the employees that follow it.
+type edgeResolver struct {
+	e *domain.Employee
+}
+
+func (r *edgeResolver) Cursor() string          { return domain.EncodeCursor(r.e.ID) }
+func (r *edgeResolver) Node() *employeeResolver { return &employeeResolver{r.e} }
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool  { return r.hasNextPage }
+func (r *pageInfoResolver) EndCursor() *string { return r.endCursor }
+
+// queryError is a resolver error. Its kind is reported to clients as
+// extensions.code, e.g. NOT_FOUND.
+type queryError struct {
+	kind    apierror.Kind
+	message string
+}
+
+func (e *queryError) Error() string { return e.message }
+
+func (e *queryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.kind.String()}
+}
+
+// serviceError turns an error of the service into the error reported for
+// field, with the messages of the REST API.
+func serviceError(ctx context.Context, field string, err error, fallback apierror.Kind, message string) error {
+	kind, message := apierror.Describe(err, fallback, message)
+	switch {
+	case kind == apierror.Timeout:
+		slog.ErrorContext(ctx, "database operation timed out", "field", field, "error", err)
+	case kind.ServerError():
+		slog.ErrorContext(ctx, message, "field", field, "error", err)
+	}
+	return &queryError{kind: kind, message: message}
+}
+
+// invalid reports a malformed argument, like the REST API's 400s.
+func invalid(message string) error {
+	return &queryError{kind: apierror.Invalid, message: message}
+}
+
+func parseID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, invalid("Invalid employee ID")
+	}
+	return n, nil
+}
@@ -0,0 +1,255 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/grpcapi/karyawanv1"
+)
+
+// defaultPageSize is the page size of ListEmployees calls that leave it
+// unset.
+const defaultPageSize = 20
+
+// EmployeeServer implements karyawanv1.EmployeeServiceServer on top of
+// domain.EmployeeService. Errors carry the messages of the REST API.
+type EmployeeServer struct {
+	karyawanv1.UnimplementedEmployeeServiceServer
+	service domain.EmployeeService
+	feed    *events.Feed
+}
+
+func NewEmployeeServer(service domain.EmployeeService, feed *events.Feed) *EmployeeServer {
+	return &EmployeeServer{service: service, feed: feed}
+}
+
+func (s *EmployeeServer) GetEmployee(ctx context.Context, req *karyawanv1.GetEmployeeRequest) (*karyawanv1.Employee, error) {
+	employee, err := s.service.GetEmployee(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, serviceError(ctx, "GetEmployee", err, apierror.Internal, err.Error())
+	}
+	if employee == nil {
+		return nil, status.Error(codes.NotFound, apierror.MsgNotFound)
+	}
+	return toProto(employee), nil
+}
+
+func (s *EmployeeServer) ListEmployees(ctx context.Context, req *karyawanv1.ListEmployeesRequest) (*karyawanv1.ListEmployeesResponse, error) {
+	// Exact-match lookups, served from blind indexes when PII is encrypted
+	if email := req.GetEmail(); email != "" {
+		employee, err := s.service.GetEmployeeByEmail(ctx, email)
+		if err != nil {
+			return nil, serviceError(ctx, "ListEmployees", err, apierror.Internal, "Failed to fetch employees")
+		}
+		resp := &karyawanv1.ListEmployeesResponse{}
+		if employee != nil {
+			resp.Employees = append(resp.Employees, toProto(employee))
+		}
+		return resp, nil
+	}
+	if phone := req.GetPhone(); phone != "" {
+		employees, err := s.service.GetEmployeesByPhone(ctx, phone)
+		if err != nil {
+			return nil, serviceError(ctx, "ListEmployees", err, apierror.Internal, "Failed to fetch employees")
+		}
+		return &karyawanv1.ListEmployeesResponse{Employees: toProtos(employees)}, nil
+	}
+
+	size := int(req.GetPageSize())
+	switch {
+	case size < 0:
+		return nil, invalid("page_size must not be negative")
+	case size == 0:
+		size = defaultPageSize
+	case size > domain.MaxPageSize:
+		size = domain.MaxPageSize
+	}
+	cursor, err := domain.DecodeCursor(req.GetPageToken())
+	if err != nil {
+		return nil, invalid("Invalid page token")
+	}
+
+	page, err := s.service.ListEmployees(ctx, cursor, size)
+	if err != nil {
+		return nil, serviceError(ctx, "ListEmployees", err, apierror.Internal, "Failed to fetch employees")
+	}
+	return &karyawanv1.ListEmployeesResponse{
+		Employees:     toProtos(page.Employees),
+		NextPageToken: domain.EncodeCursor(page.Next),
+	}, nil
+}
+
+func (s *EmployeeServer) CreateEmployee(ctx context.Context, req *karyawanv1.CreateEmployeeRequest) (*karyawanv1.Employee, error) {
+	if req.GetEmployee() == nil {
+		return nil, invalid("Invalid request payload")
+	}
+	employee := fromInput(req.GetEmployee())
+	if err := s.service.CreateEmployee(ctx, employee); err != nil {
+		return nil, serviceError(ctx, "CreateEmployee", err, apierror.Invalid, err.Error())
+	}
+	return toProto(employee), nil
+}
+
+func (s *EmployeeServer) UpdateEmployee(ctx context.Context, req *karyawanv1.UpdateEmployeeRequest) (*karyawanv1.Employee, error) {
+	if req.GetEmployee() == nil {
+		return nil, invalid("Invalid request payload")
+	}
+	employee := fromInput(req.GetEmployee())
+	employee.ID = int(req.GetId())
+	if err := s.service.UpdateEmployee(ctx, employee); err != nil {
+		return nil, serviceError(ctx, "UpdateEmployee", err, apierror.Invalid, err.Error())
+	}
+	return toProto(employee), nil
+}
+
+func (s *EmployeeServer) DeleteEmployee(ctx context.Context, req *karyawanv1.DeleteEmployeeRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteEmployee(ctx, int(req.GetId())); err != nil {
+		return nil, serviceError(ctx, "DeleteEmployee", err, apierror.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *EmployeeServer) WatchEmployees(req *karyawanv1.WatchEmployeesRequest, stream karyawanv1.EmployeeService_WatchEmployeesServer) error {
+	ctx := stream.Context()
+	types := make(map[string]bool)
+	for _, t := range req.GetTypes() {
+		name, ok := eventTypeNames[t]
+		if !ok {
+			return invalid("Invalid event type " + t.String())
+		}
+		types[name] = true
+	}
+
+	// Send the headers once subscribed, so clients know the watch is
+	// established before the first change
+	watch, err := s.feed.Watch(ctx, -1)
+	if err != nil {
+		return serviceError(ctx, "WatchEmployees", err, apierror.Internal, "Failed to read employee events")
+	}
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for event := range watch.C {
+		if id := req.GetEmployeeId(); id != 0 && int64(event.EmployeeID) != id {
+			continue
+		}
+		if len(types) > 0 && !types[event.Type] {
+			continue
+		}
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+	switch err := watch.Err(); {
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, events.ErrClosed):
+		return status.Error(codes.Unavailable, "Server is shutting down")
+	case errors.Is(err, events.ErrFellBehind):
+		return status.Error(codes.ResourceExhausted, "Watcher fell too far behind")
+	default:
+		return serviceError(ctx, "WatchEmployees", err, apierror.Internal, "Failed to read employee events")
+	}
+}
+
+var eventTypeNames = map[karyawanv1.EventType]string{
+	karyawanv1.EventType_EVENT_TYPE_CREATED: domain.EventCreated,
+	karyawanv1.EventType_EVENT_TYPE_UPDATED: domain.EventUpdated,
+	karyawanv1.EventType_EVENT_TYPE_DELETED: domain.EventDeleted,
+}
+
+func eventToProto(event domain.EmployeeEvent) *karyawanv1.EmployeeEvent {
+	pb := &karyawanv1.EmployeeEvent{
+		Id:         event.ID,
+		EmployeeId: int64(event.EmployeeID),
+		Time:       timestamppb.New(event.At),
+	}
+	for t, name := range eventTypeNames {
+		if name == event.Type {
+			pb.Type = t
+		}
+	}
+	if event.Employee != nil {
+		pb.Employee = toProto(event.Employee)
+	}
+	return pb
+}
+
+func fromInput(in *karyawanv1.EmployeeInput) *domain.Employee {
+	employee := &domain.Employee{
+		Name:     in.GetName(),
+		Email:    in.GetEmail(),
+		Position: in.GetPosition(),
+		Role:     in.GetRole(),
+		Phone:    in.GetPhone(),
+		Alamat:   in.GetAlamat(),
+	}
+	if a := in.GetAddress(); a != nil {
+		employee.Address = &domain.Address{
+			Street:        a.GetStreet(),
+			RT:            a.GetRt(),
+			RW:            a.GetRw(),
+			Kelurahan:     a.GetKelurahan(),
+			Kecamatan:     a.GetKecamatan(),
+			KotaKabupaten: a.GetKotaKabupaten(),
+			Provinsi:      a.GetProvinsi(),
+			KodePos:       a.GetKodePos(),
+		}
+	}
+	return employee
+}
+
+func toProto(e *domain.Employee) *karyawanv1.Employee {
+	pb := &karyawanv1.Employee{
+		Id:        int64(e.ID),
+		Name:      e.Name,
+		Email:     e.Email,
+		Position:  e.Position,
+		Role:      e.Role,
+		Phone:     e.Phone,
+		Alamat:    e.Alamat,
+		CreatedAt: timestamp(e.CreatedAt),
+		UpdatedAt: timestamp(e.UpdatedAt),
+	}
+	if e.AnonymizedAt != nil {
+		pb.AnonymizedAt = timestamppb.New(*e.AnonymizedAt)
+	}
+	if a := e.Address; a != nil {
+		pb.Address = &karyawanv1.Address{
+			Street:        a.Street,
+			Rt:            a.RT,
+			Rw:            a.RW,
+			Kelurahan:     a.Kelurahan,
+			Kecamatan:     a.Kecamatan,
+			KotaKabupaten: a.KotaKabupaten,
+			Provinsi:      a.Provinsi,
+			KodePos:       a.KodePos,
+		}
+	}
+	return pb
+}
+
+func toProtos(employees []domain.Employee) []*karyawanv1.Employee {
+	pbs := make([]*karyawanv1.Employee, len(employees))
+	for i := range employees {
+		pbs[i] = toProto(&employees[i])
+	}
+	return pbs
+}
+
+// timestamp leaves unknown times unset rather than sending year 1.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
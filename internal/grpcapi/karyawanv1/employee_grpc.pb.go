@@ -0,0 +1,334 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: karyawan/v1/employee.proto
+
+package karyawanv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EmployeeService_GetEmployee_FullMethodName    = "/karyawan.v1.EmployeeService/GetEmployee"
+	EmployeeService_ListEmployees_FullMethodName  = "/karyawan.v1.EmployeeService/ListEmployees"
+	EmployeeService_CreateEmployee_FullMethodName = "/karyawan.v1.EmployeeService/CreateEmployee"
+	EmployeeService_UpdateEmployee_FullMethodName = "/karyawan.v1.EmployeeService/UpdateEmployee"
+	EmployeeService_DeleteEmployee_FullMethodName = "/karyawan.v1.EmployeeService/DeleteEmployee"
+	EmployeeService_WatchEmployees_FullMethodName = "/karyawan.v1.EmployeeService/WatchEmployees"
+)
+
+// EmployeeServiceClient is the client API for EmployeeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// EmployeeService manages employee records. It is the gRPC counterpart of
+// the /api/v2/employees REST routes and shares their validation, rate
+// limits, deadlines and error messages.
+type EmployeeServiceClient interface {
+	// GetEmployee returns one employee, or NOT_FOUND.
+	GetEmployee(ctx context.Context, in *GetEmployeeRequest, opts ...grpc.CallOption) (*Employee, error)
+	// ListEmployees pages through employees, newest first.
+	ListEmployees(ctx context.Context, in *ListEmployeesRequest, opts ...grpc.CallOption) (*ListEmployeesResponse, error)
+	CreateEmployee(ctx context.Context, in *CreateEmployeeRequest, opts ...grpc.CallOption) (*Employee, error)
+	UpdateEmployee(ctx context.Context, in *UpdateEmployeeRequest, opts ...grpc.CallOption) (*Employee, error)
+	DeleteEmployee(ctx context.Context, in *DeleteEmployeeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// WatchEmployees streams changes made after the call, until the client
+	// cancels it. Watchers that fall too far behind are disconnected with
+	// RESOURCE_EXHAUSTED.
+	WatchEmployees(ctx context.Context, in *WatchEmployeesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EmployeeEvent], error)
+}
+
+type employeeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEmployeeServiceClient(cc grpc.ClientConnInterface) EmployeeServiceClient {
+	return &employeeServiceClient{cc}
+}
+
+func (c *employeeServiceClient) GetEmployee(ctx context.Context, in *GetEmployeeRequest, opts ...grpc.CallOption) (*Employee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, EmployeeService_GetEmployee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *employeeServiceClient) ListEmployees(ctx context.Context, in *ListEmployeesRequest, opts ...grpc.CallOption) (*ListEmployeesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEmployeesResponse)
+	err := c.cc.Invoke(ctx, EmployeeService_ListEmployees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *employeeServiceClient) CreateEmployee(ctx context.Context, in *CreateEmployeeRequest, opts ...grpc.CallOption) (*Employee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, EmployeeService_CreateEmployee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *employeeServiceClient) UpdateEmployee(ctx context.Context, in *UpdateEmployeeRequest, opts ...grpc.CallOption) (*Employee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, EmployeeService_UpdateEmployee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *employeeServiceClient) DeleteEmployee(ctx context.Context, in *DeleteEmployeeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, EmployeeService_DeleteEmployee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *employeeServiceClient) WatchEmployees(ctx context.Context, in *WatchEmployeesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EmployeeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EmployeeService_ServiceDesc.Streams[0], EmployeeService_WatchEmployees_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEmployeesRequest, EmployeeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EmployeeService_WatchEmployeesClient = grpc.ServerStreamingClient[EmployeeEvent]
+
+// EmployeeServiceServer is the server API for EmployeeService service.
+// All implementations must embed UnimplementedEmployeeServiceServer
+// for forward compatibility.
+//
+// EmployeeService manages employee records. It is the gRPC counterpart of
+// the /api/v2/employees REST routes and shares their validation, rate
+// limits, deadlines and error messages.
+type EmployeeServiceServer interface {
+	// GetEmployee returns one employee, or NOT_FOUND.
+	GetEmployee(context.Context, *GetEmployeeRequest) (*Employee, error)
+	// ListEmployees pages through employees, newest first.
+	ListEmployees(context.Context, *ListEmployeesRequest) (*ListEmployeesResponse, error)
+	CreateEmployee(context.Context, *CreateEmployeeRequest) (*Employee, error)
+	UpdateEmployee(context.Context, *UpdateEmployeeRequest) (*Employee, error)
+	DeleteEmployee(context.Context, *DeleteEmployeeRequest) (*emptypb.Empty, error)
+	// WatchEmployees streams changes made after the call, until the client
+	// cancels it. Watchers that fall too far behind are disconnected with
+	// RESOURCE_EXHAUSTED.
+	WatchEmployees(*WatchEmployeesRequest, grpc.ServerStreamingServer[EmployeeEvent]) error
+	mustEmbedUnimplementedEmployeeServiceServer()
+}
+
+// UnimplementedEmployeeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEmployeeServiceServer struct{}
+
+func (UnimplementedEmployeeServiceServer) GetEmployee(context.Context, *GetEmployeeRequest) (*Employee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEmployee not implemented")
+}
+func (UnimplementedEmployeeServiceServer) ListEmployees(context.Context, *ListEmployeesRequest) (*ListEmployeesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEmployees not implemented")
+}
+func (UnimplementedEmployeeServiceServer) CreateEmployee(context.Context, *CreateEmployeeRequest) (*Employee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateEmployee not implemented")
+}
+func (UnimplementedEmployeeServiceServer) UpdateEmployee(context.Context, *UpdateEmployeeRequest) (*Employee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEmployee not implemented")
+}
+func (UnimplementedEmployeeServiceServer) DeleteEmployee(context.Context, *DeleteEmployeeRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEmployee not implemented")
+}
+func (UnimplementedEmployeeServiceServer) WatchEmployees(*WatchEmployeesRequest, grpc.ServerStreamingServer[EmployeeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEmployees not implemented")
+}
+func (UnimplementedEmployeeServiceServer) mustEmbedUnimplementedEmployeeServiceServer() {}
+func (UnimplementedEmployeeServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeEmployeeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EmployeeServiceServer will
+// result in compilation errors.
+type UnsafeEmployeeServiceServer interface {
+	mustEmbedUnimplementedEmployeeServiceServer()
+}
+
+func RegisterEmployeeServiceServer(s grpc.ServiceRegistrar, srv EmployeeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedEmployeeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EmployeeService_ServiceDesc, srv)
+}
+
+func _EmployeeService_GetEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEmployeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmployeeServiceServer).GetEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmployeeService_GetEmployee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmployeeServiceServer).GetEmployee(ctx, req.(*GetEmployeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmployeeService_ListEmployees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEmployeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmployeeServiceServer).ListEmployees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmployeeService_ListEmployees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmployeeServiceServer).ListEmployees(ctx, req.(*ListEmployeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmployeeService_CreateEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEmployeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmployeeServiceServer).CreateEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmployeeService_CreateEmployee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmployeeServiceServer).CreateEmployee(ctx, req.(*CreateEmployeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmployeeService_UpdateEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEmployeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmployeeServiceServer).UpdateEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmployeeService_UpdateEmployee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmployeeServiceServer).UpdateEmployee(ctx, req.(*UpdateEmployeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmployeeService_DeleteEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEmployeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmployeeServiceServer).DeleteEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmployeeService_DeleteEmployee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmployeeServiceServer).DeleteEmployee(ctx, req.(*DeleteEmployeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmployeeService_WatchEmployees_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEmployeesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EmployeeServiceServer).WatchEmployees(m, &grpc.GenericServerStream[WatchEmployeesRequest, EmployeeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EmployeeService_WatchEmployeesServer = grpc.ServerStreamingServer[EmployeeEvent]
+
+// EmployeeService_ServiceDesc is the grpc.ServiceDesc for EmployeeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EmployeeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "karyawan.v1.EmployeeService",
+	HandlerType: (*EmployeeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetEmployee",
+			Handler:    _EmployeeService_GetEmployee_Handler,
+		},
+		{
+			MethodName: "ListEmployees",
+			Handler:    _EmployeeService_ListEmployees_Handler,
+		},
+		{
+			MethodName: "CreateEmployee",
+			Handler:    _EmployeeService_CreateEmployee_Handler,
+		},
+		{
+			MethodName: "UpdateEmployee",
+			Handler:    _EmployeeService_UpdateEmployee_Handler,
+		},
+		{
+			MethodName: "DeleteEmployee",
+			Handler:    _EmployeeService_DeleteEmployee_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEmployees",
+			Handler:       _EmployeeService_WatchEmployees_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "karyawan/v1/employee.proto",
+}
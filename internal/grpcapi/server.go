@@ -0,0 +1,162 @@
+// Package grpcapi serves the employee API over gRPC, as defined in
+// proto/karyawan/v1/employee.proto. It runs on the same domain service as
+// the REST API, with the same rate limits, deadlines and error messages.
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=karyawan-app --go-grpc_out=../.. --go-grpc_opt=module=karyawan-app karyawan/v1/employee.proto
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/grpcapi/karyawanv1"
+	"karyawan-app/internal/handler"
+)
+
+// routes names every method after the REST route it mirrors, so rate
+// limit and deadline overrides configured by route name apply to both.
+var routes = map[string]string{
+	karyawanv1.EmployeeService_GetEmployee_FullMethodName:    "employees.get",
+	karyawanv1.EmployeeService_ListEmployees_FullMethodName:  "employees.list",
+	karyawanv1.EmployeeService_CreateEmployee_FullMethodName: "employees.create",
+	karyawanv1.EmployeeService_UpdateEmployee_FullMethodName: "employees.update",
+	karyawanv1.EmployeeService_DeleteEmployee_FullMethodName: "employees.delete",
+	karyawanv1.EmployeeService_WatchEmployees_FullMethodName: "employees.watch",
+}
+
+// Config holds the policies shared with the REST API.
+type Config struct {
+	// RateLimiter, when set, counts calls against the same quotas as HTTP
+	// requests. Clients are identified by the x-api-key metadata, else by
+	// their address.
+	RateLimiter *handler.RateLimiter
+	// OnReject, when set, is called for every call rejected by the rate
+	// limiter.
+	OnReject func()
+	// Timeouts bound unary calls. Watch streams have no deadline.
+	Timeouts handler.RouteTimeouts
+}
+
+// NewServer creates a gRPC server with EmployeeService and server
+// reflection registered. Events for WatchEmployees come from feed.
+func NewServer(service domain.EmployeeService, feed *events.Feed, cfg Config, opts ...grpc.ServerOption) *grpc.Server {
+	i := &interceptors{cfg: cfg}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(i.unary),
+		grpc.ChainStreamInterceptor(i.stream),
+	)
+	s := grpc.NewServer(opts...)
+	karyawanv1.RegisterEmployeeServiceServer(s, NewEmployeeServer(service, feed))
+	reflection.Register(s)
+	return s
+}
+
+type interceptors struct {
+	cfg Config
+}
+
+func (i *interceptors) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+	route := routes[info.FullMethod]
+	if err := i.rateLimit(ctx, route, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+	if d := i.cfg.Timeouts.For(route); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	return next(ctx, req)
+}
+
+func (i *interceptors) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	if err := i.rateLimit(ss.Context(), routes[info.FullMethod], ss.SetHeader); err != nil {
+		return err
+	}
+	return next(srv, ss)
+}
+
+// rateLimit counts a call and sends the ratelimit-* headers the REST API
+// sends, rejecting calls over the quota with RESOURCE_EXHAUSTED. Like the
+// HTTP limiter it fails open when the store is unavailable.
+func (i *interceptors) rateLimit(ctx context.Context, route string, setHeader func(metadata.MD) error) error {
+	l := i.cfg.RateLimiter
+	if l == nil {
+		return nil
+	}
+	var apiKey string
+	if values := metadata.ValueFromIncomingContext(ctx, "x-api-key"); len(values) > 0 {
+		apiKey = values[0]
+	}
+	client, tier := l.Identify(apiKey, peerIP(ctx))
+	limit, result, err := l.Take(ctx, client, tier, route)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit store unavailable", "error", err)
+		return nil
+	}
+
+	md := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(limit),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", strconv.Itoa(ceilSeconds(result.Reset)),
+	)
+	if !result.Allowed {
+		md.Set("retry-after", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	}
+	setHeader(md)
+	if !result.Allowed {
+		if i.cfg.OnReject != nil {
+			i.cfg.OnReject()
+		}
+		return status.Error(apierror.RateLimited.GRPCCode(), apierror.MsgRateLimited)
+	}
+	return nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// peerIP returns the address the call came from, without the port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// serviceError converts an error of the service layer to a status, the
+// way the REST handlers convert it to a response.
+func serviceError(ctx context.Context, method string, err error, fallback apierror.Kind, message string) error {
+	kind, message := apierror.Describe(err, fallback, message)
+	switch {
+	case kind == apierror.Timeout:
+		slog.ErrorContext(ctx, "database operation timed out", "method", method, "error", err)
+	case kind.ServerError():
+		slog.ErrorContext(ctx, message, "method", method, "error", err)
+	}
+	return status.Error(kind.GRPCCode(), message)
+}
+
+// invalid reports a malformed request, like the REST API's 400s.
+func invalid(message string) error {
+	return status.Error(codes.InvalidArgument, message)
+}
@@ -0,0 +1,323 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+	"karyawan-app/internal/grpcapi/karyawanv1"
+	"karyawan-app/internal/handler"
+	"karyawan-app/internal/repository/memory"
+	"karyawan-app/internal/service"
+)
+
+type testServer struct {
+	client  karyawanv1.EmployeeServiceClient
+	conn    *grpc.ClientConn
+	service domain.EmployeeService
+}
+
+// newTestServer serves the API over an in-process bufconn listener, on the
+// in-memory repositories.
+func newTestServer(t *testing.T, cfg Config) *testServer {
+	t.Helper()
+	broker := events.NewBroker()
+	svc := events.PublishEmployeeChanges(service.NewEmployeeService(memory.NewEmployeeRepository(), memory.NewAuditRepository(), nil), broker, nil)
+	return serve(t, svc, broker, cfg)
+}
+
+func serve(t *testing.T, svc domain.EmployeeService, broker *events.Broker, cfg Config) *testServer {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(svc, events.NewFeed(broker, nil, svc, events.FeedConfig{}), cfg)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		broker.Close()
+		srv.Stop()
+	})
+	return &testServer{client: karyawanv1.NewEmployeeServiceClient(conn), conn: conn, service: svc}
+}
+
+func newInput(n string) *karyawanv1.EmployeeInput {
+	return &karyawanv1.EmployeeInput{
+		Name:     "Karyawan " + n,
+		Email:    "karyawan" + n + "@example.com",
+		Position: "Software Engineer",
+		Role:     "Developer",
+		Phone:    "0812345678" + n,
+		Alamat:   "Jl. Sudirman No. 1, Jakarta Selatan",
+	}
+}
+
+// expectStatus fails unless err is a status with the given code and
+// message.
+func expectStatus(t *testing.T, err error, code codes.Code, message string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code || st.Message() != message {
+		t.Errorf("error = %v, expected %s %q", err, code, message)
+	}
+}
+
+func TestEmployeeCRUD(t *testing.T) {
+	s := newTestServer(t, Config{})
+	ctx := context.Background()
+
+	created, err := s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: newInput("1")})
+	if err != nil {
+		t.Fatalf("CreateEmployee() error: %v", err)
+	}
+	if created.GetId() == 0 || created.GetCreatedAt() == nil {
+		t.Fatalf("CreateEmployee() = %v, expected an ID and creation time", created)
+	}
+
+	got, err := s.client.GetEmployee(ctx, &karyawanv1.GetEmployeeRequest{Id: created.GetId()})
+	if err != nil {
+		t.Fatalf("GetEmployee() error: %v", err)
+	}
+	if got.GetEmail() != "karyawan1@example.com" || got.GetAnonymizedAt() != nil {
+		t.Errorf("GetEmployee() = %v", got)
+	}
+
+	input := newInput("1")
+	input.Position = "Tech Lead"
+	if _, err := s.client.UpdateEmployee(ctx, &karyawanv1.UpdateEmployeeRequest{Id: created.GetId(), Employee: input}); err != nil {
+		t.Fatalf("UpdateEmployee() error: %v", err)
+	}
+	got, err = s.client.GetEmployee(ctx, &karyawanv1.GetEmployeeRequest{Id: created.GetId()})
+	if err != nil || got.GetPosition() != "Tech Lead" {
+		t.Errorf("GetEmployee() after update = %v, %v", got, err)
+	}
+
+	if _, err := s.client.DeleteEmployee(ctx, &karyawanv1.DeleteEmployeeRequest{Id: created.GetId()}); err != nil {
+		t.Fatalf("DeleteEmployee() error: %v", err)
+	}
+	_, err = s.client.GetEmployee(ctx, &karyawanv1.GetEmployeeRequest{Id: created.GetId()})
+	expectStatus(t, err, codes.NotFound, "Employee not found")
+}
+
+// TestErrors checks that failures carry the messages the REST API
+// answers with.
+func TestErrors(t *testing.T) {
+	s := newTestServer(t, Config{})
+	ctx := context.Background()
+
+	input := newInput("1")
+	input.Email = "not-an-email"
+	_, err := s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: input})
+	expectStatus(t, err, codes.InvalidArgument, "invalid email format")
+
+	_, err = s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{})
+	expectStatus(t, err, codes.InvalidArgument, "Invalid request payload")
+
+	input = newInput("1")
+	input.Address = &karyawanv1.Address{Kelurahan: "Senayan"}
+	_, err = s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: input})
+	expectStatus(t, err, codes.InvalidArgument, "address street is required")
+
+	created, err := s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: newInput("2")})
+	if err != nil {
+		t.Fatalf("CreateEmployee() error: %v", err)
+	}
+	_, err = s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: newInput("2")})
+	expectStatus(t, err, codes.InvalidArgument, "email is already registered")
+
+	if _, err := s.service.AnonymizeEmployee(ctx, int(created.GetId())); err != nil {
+		t.Fatalf("AnonymizeEmployee() error: %v", err)
+	}
+	_, err = s.client.UpdateEmployee(ctx, &karyawanv1.UpdateEmployeeRequest{Id: created.GetId(), Employee: newInput("3")})
+	expectStatus(t, err, codes.FailedPrecondition, domain.ErrEmployeeAnonymized.Error())
+}
+
+// slowService never answers before the deadline of the call.
+type slowService struct {
+	domain.EmployeeService
+}
+
+func (slowService) GetEmployee(ctx context.Context, id int) (*domain.Employee, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeouts(t *testing.T) {
+	s := serve(t, slowService{}, events.NewBroker(), Config{
+		Timeouts: handler.RouteTimeouts{Default: time.Minute, Routes: map[string]time.Duration{"employees.get": 10 * time.Millisecond}},
+	})
+	_, err := s.client.GetEmployee(context.Background(), &karyawanv1.GetEmployeeRequest{Id: 1})
+	expectStatus(t, err, codes.DeadlineExceeded, "Database operation timed out")
+}
+
+func TestListEmployeesPages(t *testing.T) {
+	s := newTestServer(t, Config{})
+	ctx := context.Background()
+	for _, n := range []string{"1", "2", "3", "4", "5"} {
+		if _, err := s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: newInput(n)}); err != nil {
+			t.Fatalf("CreateEmployee() error: %v", err)
+		}
+	}
+
+	var emails []string
+	var pages int
+	req := &karyawanv1.ListEmployeesRequest{PageSize: 2}
+	for {
+		resp, err := s.client.ListEmployees(ctx, req)
+		if err != nil {
+			t.Fatalf("ListEmployees() error: %v", err)
+		}
+		pages++
+		for _, e := range resp.GetEmployees() {
+			emails = append(emails, e.GetEmail())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+	if pages != 3 || len(emails) != 5 || emails[0] != "karyawan5@example.com" || emails[4] != "karyawan1@example.com" {
+		t.Errorf("ListEmployees() returned %v in %d pages, expected 5 employees newest first in 3", emails, pages)
+	}
+
+	resp, err := s.client.ListEmployees(ctx, &karyawanv1.ListEmployeesRequest{Email: "KARYAWAN3@example.com"})
+	if err != nil || len(resp.GetEmployees()) != 1 || resp.GetEmployees()[0].GetName() != "Karyawan 3" {
+		t.Errorf("ListEmployees(email) = %v, %v", resp, err)
+	}
+
+	_, err = s.client.ListEmployees(ctx, &karyawanv1.ListEmployeesRequest{PageToken: "not a token"})
+	expectStatus(t, err, codes.InvalidArgument, "Invalid page token")
+}
+
+func TestRateLimit(t *testing.T) {
+	s := newTestServer(t, Config{
+		RateLimiter: handler.NewRateLimiter(handler.RateLimits{
+			Default: 100,
+			Tiers:   map[string]int{"basic": 2},
+			APIKeys: map[string]string{"basic-key": "basic"},
+		}, memory.NewRateLimitStore()),
+	})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "basic-key")
+
+	for i := 1; i <= 2; i++ {
+		var header metadata.MD
+		if _, err := s.client.ListEmployees(ctx, &karyawanv1.ListEmployeesRequest{}, grpc.Header(&header)); err != nil {
+			t.Fatalf("call %d error: %v", i, err)
+		}
+		if got := header.Get("ratelimit-limit"); len(got) != 1 || got[0] != "2" {
+			t.Errorf("ratelimit-limit = %v, expected the basic tier quota", got)
+		}
+	}
+	var header metadata.MD
+	_, err := s.client.ListEmployees(ctx, &karyawanv1.ListEmployeesRequest{}, grpc.Header(&header))
+	expectStatus(t, err, codes.ResourceExhausted, "Rate limit exceeded")
+	if len(header.Get("retry-after")) != 1 {
+		t.Errorf("rejected call headers = %v, expected retry-after", header)
+	}
+
+	// Clients without a key have a quota of their own
+	if _, err := s.client.ListEmployees(context.Background(), &karyawanv1.ListEmployeesRequest{}); err != nil {
+		t.Errorf("anonymous call error: %v", err)
+	}
+}
+
+func TestWatchEmployees(t *testing.T) {
+	s := newTestServer(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := s.client.WatchEmployees(ctx, &karyawanv1.WatchEmployeesRequest{
+		Types: []karyawanv1.EventType{karyawanv1.EventType_EVENT_TYPE_CREATED, karyawanv1.EventType_EVENT_TYPE_DELETED},
+	})
+	if err != nil {
+		t.Fatalf("WatchEmployees() error: %v", err)
+	}
+	// The header arrives once the watch is subscribed
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header() error: %v", err)
+	}
+
+	created, err := s.client.CreateEmployee(ctx, &karyawanv1.CreateEmployeeRequest{Employee: newInput("1")})
+	if err != nil {
+		t.Fatalf("CreateEmployee() error: %v", err)
+	}
+	input := newInput("1")
+	input.Role = "Manager"
+	if _, err := s.client.UpdateEmployee(ctx, &karyawanv1.UpdateEmployeeRequest{Id: created.GetId(), Employee: input}); err != nil {
+		t.Fatalf("UpdateEmployee() error: %v", err)
+	}
+	if _, err := s.client.DeleteEmployee(ctx, &karyawanv1.DeleteEmployeeRequest{Id: created.GetId()}); err != nil {
+		t.Fatalf("DeleteEmployee() error: %v", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	if event.GetType() != karyawanv1.EventType_EVENT_TYPE_CREATED || event.GetEmployee().GetEmail() != "karyawan1@example.com" {
+		t.Errorf("first event = %v, expected the creation", event)
+	}
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	if event.GetType() != karyawanv1.EventType_EVENT_TYPE_DELETED || event.GetEmployeeId() != created.GetId() || event.GetEmployee() != nil {
+		t.Errorf("second event = %v, expected the deletion without the update", event)
+	}
+
+	// Errors of server streams surface on the first Recv
+	bad, err := s.client.WatchEmployees(ctx, &karyawanv1.WatchEmployeesRequest{Types: []karyawanv1.EventType{karyawanv1.EventType_EVENT_TYPE_UNSPECIFIED}})
+	if err == nil {
+		_, err = bad.Recv()
+	}
+	expectStatus(t, err, codes.InvalidArgument, "Invalid event type EVENT_TYPE_UNSPECIFIED")
+}
+
+func TestReflection(t *testing.T) {
+	s := newTestServer(t, Config{})
+	stream, err := reflectionpb.NewServerReflectionClient(s.conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo() error: %v", err)
+	}
+	defer stream.CloseSend()
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	var names []string
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		names = append(names, svc.GetName())
+	}
+	for _, want := range []string{"karyawan.v1.EmployeeService", "grpc.reflection.v1.ServerReflection"} {
+		found := false
+		for _, name := range names {
+			found = found || name == want
+		}
+		if !found {
+			t.Errorf("reflection lists %v, expected %s", names, want)
+		}
+	}
+}
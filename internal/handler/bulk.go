@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+)
+
+// DefaultMaxBulkOperations is the size cap of bulk requests when
+// EmployeeHandler.MaxBulkOperations is not set.
+const DefaultMaxBulkOperations = 100
+
+// Modes of a bulk request.
+const (
+	bulkAtomic     = "atomic"
+	bulkBestEffort = "best_effort"
+)
+
+type bulkRequest struct {
+	// Mode is atomic, the default, or best_effort.
+	Mode       string          `json:"mode"`
+	Operations []bulkOperation `json:"operations"`
+}
+
+type bulkOperation struct {
+	Op       string           `json:"op"`
+	ID       int              `json:"id,omitempty"`
+	Employee *domain.Employee `json:"employee,omitempty"`
+}
+
+type bulkResponse struct {
+	Mode    string       `json:"mode"`
+	Applied int          `json:"applied"`
+	Failed  int          `json:"failed"`
+	Results []bulkResult `json:"results"`
+}
+
+// bulkResult reports one operation with the status the matching single
+// request would have been answered with.
+type bulkResult struct {
+	Index    int              `json:"index"`
+	Op       string           `json:"op"`
+	Status   int              `json:"status"`
+	ID       int              `json:"id,omitempty"`
+	Employee *domain.Employee `json:"employee,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// BulkEmployees applies a list of creates, updates and deletes in one
+// request. In atomic mode they all succeed or none is applied, and a
+// failure is answered with 422; in best_effort mode each is applied on its
+// own and the response is 200 whatever their outcome. Either way the
+// results report every operation.
+func (h *EmployeeHandler) BulkEmployees(w http.ResponseWriter, r *http.Request) {
+	var req bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Mode == "" {
+		req.Mode = bulkAtomic
+	}
+	if req.Mode != bulkAtomic && req.Mode != bulkBestEffort {
+		respondWithError(w, http.StatusBadRequest, "mode must be atomic or best_effort")
+		return
+	}
+	if len(req.Operations) == 0 {
+		respondWithError(w, http.StatusBadRequest, "operations must not be empty")
+		return
+	}
+	maxOps := h.MaxBulkOperations
+	if maxOps <= 0 {
+		maxOps = DefaultMaxBulkOperations
+	}
+	if len(req.Operations) > maxOps {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("at most %d operations are allowed per request", maxOps))
+		return
+	}
+
+	ops := make([]domain.BulkOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = domain.BulkOperation{Op: op.Op, ID: op.ID, Employee: op.Employee}
+	}
+	results, err := h.service.BulkEmployees(r.Context(), ops, req.Mode == bulkAtomic)
+	if err != nil {
+		respondWithServiceError(w, r, err, apierror.Internal, "Failed to apply operations")
+		return
+	}
+
+	resp := bulkResponse{Mode: req.Mode, Results: make([]bulkResult, len(results))}
+	for i, result := range results {
+		resp.Results[i] = h.bulkResult(r, i, ops[i], result)
+		if result.Err != nil {
+			resp.Failed++
+		} else {
+			resp.Applied++
+		}
+	}
+	status := http.StatusOK
+	if req.Mode == bulkAtomic && resp.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	respondWithJSON(w, status, resp)
+}
+
+func (h *EmployeeHandler) bulkResult(r *http.Request, i int, op domain.BulkOperation, result domain.BulkResult) bulkResult {
+	res := bulkResult{Index: i, Op: op.Op, ID: op.ID}
+	if result.Err != nil {
+		kind, message := apierror.Describe(result.Err, apierror.Invalid, result.Err.Error())
+		if kind.ServerError() {
+			slog.ErrorContext(r.Context(), message, "route", routeName(r), "index", i, "error", result.Err)
+		}
+		res.Status = kind.HTTPStatus()
+		res.Error = message
+		return res
+	}
+
+	res.Status = http.StatusOK
+	if op.Op == domain.BulkCreate {
+		res.Status = http.StatusCreated
+	}
+	if result.Employee != nil {
+		res.ID = result.Employee.ID
+		res.Employee = result.Employee
+	}
+	return res
+}
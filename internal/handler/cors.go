@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy decides which cross-origin callers may use the API from a
+// browser.
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins ("https://hr.example.com"),
+	// subdomain wildcards ("https://*.example.com", which does not match
+	// example.com itself) or "*" for any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a preflight may ask for.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization. It
+	// cannot be combined with "*".
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight result.
+	MaxAge time.Duration
+}
+
+// DefaultCORSPolicy allows any origin without credentials, the methods and
+// headers the API uses, and exposes the headers clients need to read.
+func DefaultCORSPolicy() CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID", "Idempotency-Key"},
+		ExposedHeaders: []string{
+			"ETag", "X-Request-ID", "Content-Disposition",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+			"Deprecation", "Sunset", "Link", "Idempotent-Replayed",
+		},
+		MaxAge: time.Hour,
+	}
+}
+
+// Validate reports malformed origins and credentials allowed for every
+// origin, which browsers refuse and which would expose user data to any
+// site if the origin were echoed instead.
+func (p CORSPolicy) Validate() error {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			if p.AllowCredentials {
+				return fmt.Errorf("credentials cannot be allowed for every origin")
+			}
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("invalid origin %q, expected scheme://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+func (p CORSPolicy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.TrimSuffix(strings.ToLower(allowed), "/")
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		scheme, domain, ok := strings.Cut(allowed, "://*.")
+		if !ok {
+			continue
+		}
+		sub, ok := strings.CutSuffix(strings.TrimPrefix(origin, scheme+"://"), "."+domain)
+		if ok && strings.HasPrefix(origin, scheme+"://") && isSubdomain(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubdomain reports whether s is one or more DNS labels.
+func isSubdomain(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p CORSPolicy) allowsMethod(method string) bool {
+	for _, m := range p.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders checks every header of an Access-Control-Request-Headers
+// list.
+func (p CORSPolicy) allowsHeaders(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		allowed := false
+		for _, h := range p.AllowedHeaders {
+			if strings.EqualFold(h, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func (p CORSPolicy) allowsAnyOrigin() bool {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSConfig is the default CORS policy with overrides for named routes.
+type CORSConfig struct {
+	Default CORSPolicy
+	Routes  map[string]CORSPolicy
+}
+
+// For returns the policy of a named route.
+func (c CORSConfig) For(route string) CORSPolicy {
+	if p, ok := c.Routes[route]; ok {
+		return p
+	}
+	return c.Default
+}
+
+// Validate validates every policy.
+func (c CORSConfig) Validate() error {
+	if err := c.Default.Validate(); err != nil {
+		return err
+	}
+	for route, p := range c.Routes {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("route %s: %w", route, err)
+		}
+	}
+	return nil
+}
+
+// corsPolicyFile is the JSON form of a CORSPolicy.
+type corsPolicyFile struct {
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           string   `json:"max_age"`
+}
+
+func (f corsPolicyFile) policy() (CORSPolicy, error) {
+	p := CORSPolicy{
+		AllowedOrigins:   f.AllowedOrigins,
+		AllowedMethods:   f.AllowedMethods,
+		AllowedHeaders:   f.AllowedHeaders,
+		ExposedHeaders:   f.ExposedHeaders,
+		AllowCredentials: f.AllowCredentials,
+	}
+	if f.MaxAge != "" {
+		d, err := time.ParseDuration(f.MaxAge)
+		if err != nil {
+			return p, fmt.Errorf("invalid max_age: %w", err)
+		}
+		p.MaxAge = d
+	}
+	return p, nil
+}
+
+// LoadCORSConfigFile reads a JSON file holding the default policy and a
+// "routes" object of per-route overrides, e.g.
+//
+//	{
+//	  "allowed_origins": ["https://hr.example.com", "https://*.example.com"],
+//	  "allow_credentials": true,
+//	  "routes": {"employees.data_export": {"allowed_origins": ["https://hr.example.com"]}}
+//	}
+//
+// Fields missing from the file keep the values of DefaultCORSPolicy, and
+// fields missing from a route keep the file's default.
+func LoadCORSConfigFile(path string) (CORSConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CORSConfig{}, err
+	}
+
+	var file struct {
+		corsPolicyFile
+		Routes map[string]json.RawMessage `json:"routes"`
+	}
+	file.corsPolicyFile = policyFile(DefaultCORSPolicy())
+	if err := json.Unmarshal(data, &file); err != nil {
+		return CORSConfig{}, fmt.Errorf("invalid CORS config %s: %w", path, err)
+	}
+
+	var cfg CORSConfig
+	if cfg.Default, err = file.policy(); err != nil {
+		return CORSConfig{}, err
+	}
+	cfg.Routes = make(map[string]CORSPolicy, len(file.Routes))
+	for route, raw := range file.Routes {
+		override := file.corsPolicyFile.clone()
+		if err := json.Unmarshal(raw, &override); err != nil {
+			return CORSConfig{}, fmt.Errorf("invalid CORS config for route %s: %w", route, err)
+		}
+		if cfg.Routes[route], err = override.policy(); err != nil {
+			return CORSConfig{}, fmt.Errorf("route %s: %w", route, err)
+		}
+	}
+	return cfg, nil
+}
+
+// clone copies the slices, which json.Unmarshal would otherwise overwrite
+// in place.
+func (f corsPolicyFile) clone() corsPolicyFile {
+	f.AllowedOrigins = slices.Clone(f.AllowedOrigins)
+	f.AllowedMethods = slices.Clone(f.AllowedMethods)
+	f.AllowedHeaders = slices.Clone(f.AllowedHeaders)
+	f.ExposedHeaders = slices.Clone(f.ExposedHeaders)
+	return f
+}
+
+func policyFile(p CORSPolicy) corsPolicyFile {
+	return corsPolicyFile{
+		AllowedOrigins:   p.AllowedOrigins,
+		AllowedMethods:   p.AllowedMethods,
+		AllowedHeaders:   p.AllowedHeaders,
+		ExposedHeaders:   p.ExposedHeaders,
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           p.MaxAge.String(),
+	}
+}
+
+// CORS applies a CORSConfig to requests carrying an Origin header.
+type CORS struct {
+	config CORSConfig
+
+	// RouteName, when set, names the route a request will be served by, so
+	// per-route policies apply. Preflights are matched by the method they
+	// ask for.
+	RouteName func(r *http.Request) string
+}
+
+// NewCORS creates the CORS middleware for cfg, which should be validated.
+func NewCORS(cfg CORSConfig) *CORS {
+	return &CORS{config: cfg}
+}
+
+// Middleware answers preflights, rejecting with 403 those from origins or
+// asking for methods or headers the policy does not allow, and adds the
+// allow and expose headers to responses to allowed origins. Requests from
+// other origins are served without them, so browsers withhold the
+// response from the calling script.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && requestMethod != ""
+
+		var route string
+		if c.RouteName != nil {
+			routed := r
+			if preflight {
+				routed = r.Clone(r.Context())
+				routed.Method = requestMethod
+			}
+			route = c.RouteName(routed)
+		}
+		policy := c.config.For(route)
+		allowed := policy.allowsOrigin(origin)
+
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			requestHeaders := strings.Join(r.Header.Values("Access-Control-Request-Headers"), ",")
+			if !allowed || !policy.allowsMethod(requestMethod) || !policy.allowsHeaders(requestHeaders) {
+				respondWithError(w, http.StatusForbidden, "CORS preflight rejected")
+				return
+			}
+			setAllowOrigin(h, policy, origin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+			if len(policy.AllowedHeaders) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+			}
+			if policy.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if allowed {
+			setAllowOrigin(h, policy, origin)
+			if len(policy.ExposedHeaders) > 0 {
+				h.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setAllowOrigin(h http.Header, policy CORSPolicy, origin string) {
+	if policy.allowsAnyOrigin() {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if policy.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
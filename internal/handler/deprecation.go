@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Deprecation describes an API version or route clients should move off.
+type Deprecation struct {
+	// Since is when it was deprecated, sent in the Deprecation header.
+	Since time.Time
+	// Sunset, when set, is when it will be removed, sent in the Sunset
+	// header.
+	Sunset time.Time
+	// Successor, when set, is the path prefix replacing the version's
+	// prefix, e.g. /api/v2. The request path under it is sent as the
+	// successor-version Link.
+	Successor string
+}
+
+// Deprecations is the registry of deprecated routes. A key is either an API
+// version, deprecating all of its routes, or a version and a route name
+// given in RegisterRoutes, e.g. "v2 employees.list", which takes precedence.
+type Deprecations map[string]Deprecation
+
+// Lookup returns the deprecation of a route of an API version.
+func (d Deprecations) Lookup(version, route string) (Deprecation, bool) {
+	if dep, ok := d[version+" "+route]; ok {
+		return dep, true
+	}
+	dep, ok := d[version]
+	return dep, ok
+}
+
+// DeprecationMiddleware adds the Deprecation (RFC 9745), Sunset (RFC 8594)
+// and successor-version Link headers to responses of deprecated routes of
+// an API version served under prefix. It must be installed with Router.Use
+// on the version's subrouter so the route is already matched.
+func DeprecationMiddleware(version, prefix string, deprecations Deprecations) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dep, ok := deprecations.Lookup(version, routeName(r))
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Deprecation", fmt.Sprintf("@%d", dep.Since.Unix()))
+			if !dep.Sunset.IsZero() {
+				h.Set("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if dep.Successor != "" {
+				successor := dep.Successor + strings.TrimPrefix(r.URL.Path, prefix)
+				h.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
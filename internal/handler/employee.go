@@ -2,16 +2,20 @@ package handler
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"karyawan-app/internal/apierror"
 	"karyawan-app/internal/domain"
 )
 
 type EmployeeHandler struct {
 	service domain.EmployeeService
+	// MaxBulkOperations caps the operations of one bulk request,
+	// DefaultMaxBulkOperations when not set.
+	MaxBulkOperations int
 }
 
 func NewEmployeeHandler(service domain.EmployeeService) *EmployeeHandler {
@@ -19,17 +23,55 @@ func NewEmployeeHandler(service domain.EmployeeService) *EmployeeHandler {
 }
 
 func (h *EmployeeHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/employees", h.GetAllEmployees).Methods("GET")
-	router.HandleFunc("/employees/{id}", h.GetEmployee).Methods("GET")
-	router.HandleFunc("/employees", h.CreateEmployee).Methods("POST")
-	router.HandleFunc("/employees/{id}", h.UpdateEmployee).Methods("PUT")
-	router.HandleFunc("/employees/{id}", h.DeleteEmployee).Methods("DELETE")
+	router.HandleFunc("/employees/bulk", traced("EmployeeHandler.BulkEmployees", h.BulkEmployees)).Methods("POST").Name("employees.bulk")
+	h.registerCRUDRoutes(router)
+	router.HandleFunc("/employees/{id}/data-export", traced("EmployeeHandler.ExportEmployeeData", h.ExportEmployeeData)).Methods("GET").Name("employees.data_export")
+	router.HandleFunc("/employees/{id}/anonymize", traced("EmployeeHandler.AnonymizeEmployee", h.AnonymizeEmployee)).Methods("POST").Name("employees.anonymize")
+}
+
+// RegisterV1Routes registers the routes of the v1 API, which only covers
+// what the original server offered. Install V1Middleware on router to keep
+// its response contract.
+func (h *EmployeeHandler) RegisterV1Routes(router *mux.Router) {
+	h.registerCRUDRoutes(router)
+}
+
+func (h *EmployeeHandler) registerCRUDRoutes(router *mux.Router) {
+	router.HandleFunc("/employees", traced("EmployeeHandler.GetAllEmployees", h.GetAllEmployees)).Methods("GET").Name("employees.list")
+	router.HandleFunc("/employees/{id}", traced("EmployeeHandler.GetEmployee", h.GetEmployee)).Methods("GET").Name("employees.get")
+	router.HandleFunc("/employees", traced("EmployeeHandler.CreateEmployee", h.CreateEmployee)).Methods("POST").Name("employees.create")
+	router.HandleFunc("/employees/{id}", traced("EmployeeHandler.UpdateEmployee", h.UpdateEmployee)).Methods("PUT").Name("employees.update")
+	router.HandleFunc("/employees/{id}", traced("EmployeeHandler.DeleteEmployee", h.DeleteEmployee)).Methods("DELETE").Name("employees.delete")
 }
 
 func (h *EmployeeHandler) GetAllEmployees(w http.ResponseWriter, r *http.Request) {
-	employees, err := h.service.GetAllEmployees()
+	// Exact-match lookups, served from blind indexes when PII is encrypted
+	if email := r.URL.Query().Get("email"); email != "" {
+		employee, err := h.service.GetEmployeeByEmail(r.Context(), email)
+		if err != nil {
+			respondWithServiceError(w, r, err, apierror.Internal, "Failed to fetch employees")
+			return
+		}
+		employees := []domain.Employee{}
+		if employee != nil {
+			employees = append(employees, *employee)
+		}
+		respondWithJSON(w, http.StatusOK, employees)
+		return
+	}
+	if phone := r.URL.Query().Get("phone"); phone != "" {
+		employees, err := h.service.GetEmployeesByPhone(r.Context(), phone)
+		if err != nil {
+			respondWithServiceError(w, r, err, apierror.Internal, "Failed to fetch employees")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, employees)
+		return
+	}
+
+	employees, err := h.service.GetAllEmployees(r.Context())
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to fetch employees")
+		respondWithServiceError(w, r, err, apierror.Internal, "Failed to fetch employees")
 		return
 	}
 	respondWithJSON(w, http.StatusOK, employees)
@@ -43,13 +85,13 @@ func (h *EmployeeHandler) GetEmployee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	employee, err := h.service.GetEmployee(id)
+	employee, err := h.service.GetEmployee(r.Context(), id)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, r, err, apierror.Internal, err.Error())
 		return
 	}
 	if employee == nil {
-		respondWithError(w, http.StatusNotFound, "Employee not found")
+		respondWithError(w, http.StatusNotFound, apierror.MsgNotFound)
 		return
 	}
 
@@ -64,8 +106,8 @@ func (h *EmployeeHandler) CreateEmployee(w http.ResponseWriter, r *http.Request)
 	}
 	defer r.Body.Close()
 
-	if err := h.service.CreateEmployee(&employee); err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+	if err := h.service.CreateEmployee(r.Context(), &employee); err != nil {
+		respondWithServiceError(w, r, err, apierror.Invalid, err.Error())
 		return
 	}
 
@@ -88,8 +130,8 @@ func (h *EmployeeHandler) UpdateEmployee(w http.ResponseWriter, r *http.Request)
 	defer r.Body.Close()
 
 	employee.ID = id
-	if err := h.service.UpdateEmployee(&employee); err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+	if err := h.service.UpdateEmployee(r.Context(), &employee); err != nil {
+		respondWithServiceError(w, r, err, apierror.Invalid, err.Error())
 		return
 	}
 
@@ -104,14 +146,42 @@ func (h *EmployeeHandler) DeleteEmployee(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.service.DeleteEmployee(id); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteEmployee(r.Context(), id); err != nil {
+		respondWithServiceError(w, r, err, apierror.Internal, err.Error())
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Employee deleted successfully"})
 }
 
+// respondWithServiceError reports an error returned by the service layer,
+// classified by apierror.Describe: database deadlines map to 504 and edits
+// of anonymized employees to 409; anything else is answered as kind
+// fallback with the given message. Nothing is written when the client has
+// already gone away. Server side failures are logged with the request ID.
+func respondWithServiceError(w http.ResponseWriter, r *http.Request, err error, fallback apierror.Kind, message string) {
+	ctx := r.Context()
+	kind, message := apierror.Describe(err, fallback, message)
+	switch {
+	case kind == apierror.Canceled:
+		slog.InfoContext(ctx, "request cancelled by client", "route", routeName(r), "error", err)
+		return
+	case kind == apierror.Timeout:
+		slog.ErrorContext(ctx, "database operation timed out", "route", routeName(r), "error", err)
+	case kind.ServerError():
+		slog.ErrorContext(ctx, message, "route", routeName(r), "error", err)
+	}
+	respondWithError(w, kind.HTTPStatus(), message)
+}
+
+// routeName returns the name of the mux route serving r, for log lines.
+func routeName(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route.GetName()
+	}
+	return ""
+}
+
 func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, map[string]string{"error": message})
 }
@@ -119,7 +189,7 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshaling JSON: %v", err)
+		slog.Error("failed to marshal JSON response", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("Internal Server Error"))
 		return
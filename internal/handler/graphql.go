@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"karyawan-app/internal/graphapi"
+)
+
+// GraphQLHandler serves a GraphQL schema. Results, including errors raised
+// while resolving, are answered with 200 as the GraphQL over HTTP
+// convention has it; only requests that are not GraphQL get a 400.
+type GraphQLHandler struct {
+	schema *graphapi.Schema
+}
+
+func NewGraphQLHandler(schema *graphapi.Schema) *GraphQLHandler {
+	return &GraphQLHandler{schema: schema}
+}
+
+func (h *GraphQLHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/graphql", h.Query).Methods("POST").Name("graphql")
+	router.HandleFunc("/graphql/schema", h.GetSchema).Methods("GET").Name("graphql.schema")
+}
+
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var req graphapi.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.schema.Execute(r.Context(), req))
+}
+
+// GetSchema returns the schema in SDL, for client code generators.
+func (h *GraphQLHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(graphapi.SDL()))
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"karyawan-app/internal/lifecycle"
+)
+
+// healthCheckTimeout bounds each readiness check so a hung dependency
+// cannot stall the probe past the orchestrator's own timeout.
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is a named dependency check run by /readyz.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+	lc     *lifecycle.Lifecycle
+	checks []HealthCheck
+}
+
+func NewHealthHandler(lc *lifecycle.Lifecycle, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{lc: lc, checks: checks}
+}
+
+// Liveness answers 200 as long as the process can serve HTTP. It does not
+// touch dependencies, so a database outage never gets the pod restarted.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness answers 200 when the server accepts traffic and every check
+// passes, and 503 otherwise. It fails without running the checks once
+// shutdown has started, so load balancers stop routing to it before
+// connections are closed.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.lc.Ready() {
+		respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+
+	status, code := "ready", http.StatusOK
+	results := make(map[string]string, len(h.checks))
+	for _, c := range h.checks {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		err := c.Check(ctx)
+		cancel()
+
+		if err != nil {
+			results[c.Name] = err.Error()
+			status, code = "not ready", http.StatusServiceUnavailable
+			continue
+		}
+		results[c.Name] = "ok"
+	}
+
+	respondWithJSON(w, code, map[string]interface{}{"status": status, "checks": results})
+}
+
+// DebugStatus configures the /debug/status endpoint.
+type DebugStatus struct {
+	// Token must be sent as "Authorization: Bearer <token>". The endpoint
+	// is not served when it is empty.
+	Token string
+	// DB, when set, contributes connection pool statistics.
+	DB *sql.DB
+	// Started is when the server booted, for reporting uptime.
+	Started time.Time
+	// Config is a summary of the effective settings. Values of secret
+	// looking keys are redacted before they are rendered.
+	Config map[string]string
+}
+
+// DebugStatusHandler reports pool statistics, build info, uptime and the
+// redacted configuration to operators holding the debug token.
+func DebugStatusHandler(s DebugStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "Invalid debug token")
+			return
+		}
+
+		status := map[string]interface{}{
+			"build":      buildInfo(),
+			"started_at": s.Started.UTC().Format(time.RFC3339),
+			"uptime":     time.Since(s.Started).Round(time.Second).String(),
+			"config":     RedactConfig(s.Config),
+		}
+		if s.DB != nil {
+			stats := s.DB.Stats()
+			status["db_pool"] = map[string]interface{}{
+				"max_open_connections": stats.MaxOpenConnections,
+				"open_connections":     stats.OpenConnections,
+				"in_use":               stats.InUse,
+				"idle":                 stats.Idle,
+				"wait_count":           stats.WaitCount,
+				"wait_duration":        stats.WaitDuration.String(),
+				"max_idle_closed":      stats.MaxIdleClosed,
+				"max_idle_time_closed": stats.MaxIdleTimeClosed,
+				"max_lifetime_closed":  stats.MaxLifetimeClosed,
+			}
+		}
+		respondWithJSON(w, http.StatusOK, status)
+	}
+}
+
+func buildInfo() map[string]string {
+	info := map[string]string{"go_version": runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info["module"] = bi.Main.Path
+	info["version"] = bi.Main.Version
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			info[strings.TrimPrefix(setting.Key, "vcs.")] = setting.Value
+		}
+	}
+	return info
+}
+
+// RedactConfig returns a copy of cfg with secrets masked. Keys containing
+// PASSWORD, SECRET, TOKEN or KEY are replaced outright; URL values keep
+// everything except the password.
+func RedactConfig(cfg map[string]string) map[string]string {
+	out := make(map[string]string, len(cfg))
+	for k, v := range cfg {
+		out[k] = redactValue(k, v)
+	}
+	return out
+}
+
+func redactValue(key, value string) string {
+	if value == "" {
+		return value
+	}
+	upper := strings.ToUpper(key)
+	for _, marker := range []string{"PASSWORD", "SECRET", "TOKEN", "KEY"} {
+		if strings.Contains(upper, marker) {
+			return "[REDACTED]"
+		}
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
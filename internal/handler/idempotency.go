@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+)
+
+const (
+	// maxIdempotencyKeyLength is the longest Idempotency-Key accepted.
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockMargin is how long past its deadline a request keeps
+	// its key reserved, to finish writing its response. A server dying
+	// mid-request frees its keys once the margin has passed.
+	idempotencyLockMargin = 10 * time.Second
+	// idempotencyLockDefault is how long requests on routes without a
+	// deadline keep their keys reserved.
+	idempotencyLockDefault = time.Minute
+	// idempotencyPruneInterval is how often expired keys are deleted.
+	idempotencyPruneInterval = time.Hour
+)
+
+// Idempotency makes POST and PATCH requests sent with an Idempotency-Key
+// header safe to retry: the first response for a key is stored and
+// replayed to every retry until the key expires, marked with
+// Idempotent-Replayed: true. Keys are scoped to the X-API-Key of the
+// client, and bound to the request they were first used with, so reusing
+// one for another method, path or body is answered with 422. A retry
+// arriving while the first request is still processed gets 409.
+//
+// Server failures (5xx) are not stored, so the request can be retried.
+type Idempotency struct {
+	store domain.IdempotencyStore
+	ttl   time.Duration
+}
+
+// NewIdempotency stores responses in store for ttl. Run Cleanup in the
+// background to delete expired keys.
+func NewIdempotency(store domain.IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{store: store, ttl: ttl}
+}
+
+// Cleanup deletes expired keys once an hour until ctx is cancelled.
+func (i *Idempotency) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := i.store.Prune(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				slog.Warn("failed to delete expired idempotency keys", "error", err)
+			}
+		}
+	}
+}
+
+// Middleware must run after the route is matched and its timeout set, and
+// before request validation, so rejected requests are replayed too.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		scoped := scopeIdempotencyKey(r.Header.Get("X-API-Key"), key)
+		fingerprint := requestFingerprint(r, body)
+		stored, err := i.store.Reserve(ctx, scoped, fingerprint, idempotencyLockUntil(ctx))
+		if err != nil {
+			respondWithServiceError(w, r, err, apierror.Internal, "Failed to check Idempotency-Key")
+			return
+		}
+		if stored != nil {
+			switch {
+			case stored.Fingerprint != fingerprint:
+				respondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case stored.Status == 0:
+				respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			default:
+				w.Header().Set("Content-Type", stored.ContentType)
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+			}
+			return
+		}
+
+		// The outcome is stored even if the client went away meanwhile,
+		// since it is the one retrying
+		rec := &recordedResponse{ResponseWriter: w}
+		defer func() {
+			ctx := context.WithoutCancel(ctx)
+			if rec.status == 0 || rec.status >= 500 {
+				if err := i.store.Release(ctx, scoped); err != nil {
+					slog.ErrorContext(ctx, "failed to release idempotency key", "error", err)
+				}
+				return
+			}
+			record := domain.IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      rec.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+				EmployeeIDs: responseEmployeeIDs(rec.body.Bytes()),
+			}
+			if err := i.store.Complete(ctx, scoped, record, time.Now().Add(i.ttl)); err != nil {
+				slog.ErrorContext(ctx, "failed to store idempotent response", "error", err)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// responseEmployeeIDs returns the employees whose data a JSON response
+// holds: every "id" in the responses of the API is an employee's.
+func responseEmployeeIDs(body []byte) []int {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	var ids []int
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for name, field := range v {
+				if id, ok := field.(float64); ok && name == "id" {
+					ids = append(ids, int(id))
+				}
+				walk(field)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+	return ids
+}
+
+// idempotencyLockUntil returns when the key of the request with ctx stops
+// being reserved: the margin after the route's deadline, which
+// TimeoutMiddleware has set, so a request cannot outlive its reservation.
+func idempotencyLockUntil(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline.Add(idempotencyLockMargin)
+	}
+	return time.Now().Add(idempotencyLockDefault)
+}
+
+// scopeIdempotencyKey hashes key together with the client's API key, so
+// clients cannot collide, and so the stored key has a fixed length.
+func scopeIdempotencyKey(apiKey, key string) string {
+	sum := sha256.Sum256([]byte(apiKey + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifies a request by its method, path, query and
+// body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordedResponse passes a response through while keeping a copy of its
+// status and body.
+type recordedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *recordedResponse) WriteHeader(code int) {
+	if rr.status == 0 {
+		rr.status = code
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *recordedResponse) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(p)
+	return rr.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rr *recordedResponse) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -1,133 +1,123 @@
 package handler
 
 import (
-	"log"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
-	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/time/rate"
-)
-
-var (
-	mutex sync.Mutex
+	"karyawan-app/internal/logging"
 )
 
 // Middleware type for chaining HTTP handlers
 type Middleware func(http.Handler) http.Handler
 
+// NamedMiddleware is a middleware with the name its span is given, e.g.
+// "RequestID" for the span "middleware RequestID".
+type NamedMiddleware struct {
+	Name       string
+	Middleware Middleware
+}
+
 // ChainMiddleware applies middlewares in order
 type ChainMiddleware struct {
-	middlewares []Middleware
+	middlewares []NamedMiddleware
 }
 
 // NewChain creates a new chain of middlewares
-func NewChain(middlewares ...Middleware) *ChainMiddleware {
+func NewChain(middlewares ...NamedMiddleware) *ChainMiddleware {
 	return &ChainMiddleware{
 		middlewares: middlewares,
 	}
 }
 
-// Then applies the middleware chain to a handler
+// Then applies the middleware chain to a handler. Each middleware runs in
+// its own span, nested in chain order.
 func (c *ChainMiddleware) Then(h http.Handler) http.Handler {
 	for i := range c.middlewares {
-		h = c.middlewares[len(c.middlewares)-1-i](h)
+		mw := c.middlewares[len(c.middlewares)-1-i]
+		h = traceMiddleware(mw.Name, mw.Middleware(h))
 	}
 	return h
 }
 
-// CORSMiddleware handles CORS headers
-func CORSMiddleware(next http.Handler) http.Handler {
+// maxRequestIDLength bounds client supplied request IDs so they cannot
+// bloat every log line.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware propagates the caller's X-Request-ID, or generates
+// one, stores it in the request context for logging and echoes it in the
+// response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow all origins for development
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
 		}
-
-		next.ServeHTTP(w, r)
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
 	})
 }
 
-// RateLimitMiddleware implements rate limiting
-func RateLimitMiddleware(requestsPerMinute int) Middleware {
-	// Create a rate limiter per IP
-	type client struct {
-		limiter  *rate.Limiter
-		lastSeen time.Time
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
 	}
-
-	var (
-		clients = make(map[string]*client)
-	)
-
-	// Clean up old entries
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-			mutex.Lock()
-			for ip, client := range clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
-			mutex.Unlock()
+	for _, c := range id {
+		if !(c == '-' || c == '_' || c == '.' || c == ':' ||
+			c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
 		}
-	}()
+	}
+	return true
+}
 
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewLoggingMiddleware writes an access log line per request. Only one in
+// every sample2xx successful responses is logged; everything else always is.
+func NewLoggingMiddleware(sample2xx int) Middleware {
+	var seen atomic.Uint64
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			srcIP := r.RemoteAddr
-
-			mutex.Lock()
-			if _, exists := clients[srcIP]; !exists {
-				clients[srcIP] = &client{
-					limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(requestsPerMinute)), requestsPerMinute),
+			start := time.Now()
+
+			// Create a response writer that captures the status code
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			// Process the request
+			next.ServeHTTP(rw, r)
+
+			level := slog.LevelInfo
+			switch {
+			case rw.status >= 500:
+				level = slog.LevelError
+			case rw.status >= 400:
+				level = slog.LevelWarn
+			default:
+				if sample2xx > 1 && seen.Add(1)%uint64(sample2xx) != 1 {
+					return
 				}
 			}
 
-			clients[srcIP].lastSeen = time.Now()
-
-			if !clients[srcIP].limiter.Allow() {
-				mutex.Unlock()
-				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
-				return
-			}
-			mutex.Unlock()
-
-			next.ServeHTTP(w, r)
+			slog.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.Int("status", rw.status),
+				slog.Duration("duration", time.Since(start)),
+			)
 		})
 	}
 }
 
-// LoggingMiddleware logs the request details
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Create a response writer that captures the status code
-		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
-
-		// Process the request
-		next.ServeHTTP(rw, r)
-
-		// Log the request details
-		log.Printf(
-			"[%s] %s %s %d %s",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			rw.status,
-			time.Since(start),
-		)
-	})
-}
-
 // responseWriter is a wrapper around http.ResponseWriter that captures the status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -140,6 +130,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // JSONContentTypeMiddleware sets the Content-Type header to application/json
 func JSONContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -147,3 +142,34 @@ func JSONContentTypeMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// bufferedResponse holds back the status and body of a response so
+// middleware can inspect or replace them. Headers go to the underlying
+// writer's map, which is only sent on WriteHeader.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// flush sends the buffered response, if anything was written.
+func (b *bufferedResponse) flush() {
+	if b.status == 0 {
+		return
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	b.ResponseWriter.Write(b.body.Bytes())
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"karyawan-app/internal/openapi"
+)
+
+// DocsHandler serves the OpenAPI document and a Swagger UI page for it.
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+func (h *DocsHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/openapi.json", h.GetSpec).Methods("GET").Name("docs.spec")
+	router.HandleFunc("/docs", h.GetSwaggerUI).Methods("GET").Name("docs.ui")
+}
+
+func (h *DocsHandler) GetSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openapi.Document())
+}
+
+// swaggerUI loads Swagger UI from a CDN and points it at the document
+// served next to it.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Karyawan API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func (h *DocsHandler) GetSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUI))
+}
+
+// ValidationMiddleware rejects requests whose parameters or body do not
+// match the operation of the matched route in spec, with 400, or 415 for
+// bodies of an unsupported media type. Routes without an operation, like
+// the docs, pass through. It must be installed with Router.Use so the route
+// is already matched.
+//
+// With validateResponses set, responses are buffered and checked too, and
+// replaced with a 500 naming the mismatch. That is meant for tests, which
+// thereby fail whenever a handler drifts from the document. Event streams
+// never end and are not checked.
+func ValidationMiddleware(spec *openapi.Spec, validateResponses bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := routeName(r)
+			if _, ok := spec.Operation(name); !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := spec.ValidateRequest(name, r, mux.Vars(r)); err != nil {
+				code := http.StatusBadRequest
+				if errors.Is(err, openapi.ErrUnsupportedMediaType) {
+					code = http.StatusUnsupportedMediaType
+				}
+				respondWithError(w, code, "Invalid request: "+err.Error())
+				return
+			}
+			if !validateResponses || spec.Streams(name) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := &bufferedResponse{ResponseWriter: w}
+			next.ServeHTTP(buf, r)
+			if buf.status == 0 {
+				// Nothing was written, e.g. because the client went away
+				return
+			}
+			if err := spec.ValidateResponse(name, buf.status, w.Header(), buf.body.Bytes()); err != nil {
+				slog.ErrorContext(r.Context(), "response does not match the API specification", "route", name, "status", buf.status, "error", err)
+				w.Header().Del("Content-Disposition")
+				respondWithError(w, http.StatusInternalServerError, "Response does not match the API specification: "+err.Error())
+				return
+			}
+			buf.flush()
+		})
+	}
+}
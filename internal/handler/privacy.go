@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"karyawan-app/internal/apierror"
+)
+
+// ExportEmployeeData answers a UU PDP data subject access request with a ZIP
+// of JSON documents holding everything stored about the employee.
+func (h *EmployeeHandler) ExportEmployeeData(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+
+	export, err := h.service.ExportEmployeeData(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, apierror.Internal, err.Error())
+		return
+	}
+	if export == nil {
+		respondWithError(w, http.StatusNotFound, apierror.MsgNotFound)
+		return
+	}
+
+	files := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"manifest.json", map[string]interface{}{
+			"employee_id": id,
+			"exported_at": export.ExportedAt,
+			"files":       []string{"employee.json", "audit_log.json", "events.json", "stored_responses.json"},
+		}},
+		{"employee.json", export.Employee},
+		{"audit_log.json", export.AuditLog},
+		{"events.json", export.Events},
+		{"stored_responses.json", export.StoredResponses},
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="employee-%d-data-export.zip"`, id))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to write data export", "employee_id", id, "error", err)
+			return
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.payload); err != nil {
+			slog.ErrorContext(r.Context(), "failed to write data export", "employee_id", id, "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write data export", "employee_id", id, "error", err)
+	}
+}
+
+// AnonymizeEmployee irreversibly erases the employee's personal data.
+func (h *EmployeeHandler) AnonymizeEmployee(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+
+	employee, err := h.service.AnonymizeEmployee(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err, apierror.Internal, err.Error())
+		return
+	}
+	if employee == nil {
+		respondWithError(w, http.StatusNotFound, apierror.MsgNotFound)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, employee)
+}
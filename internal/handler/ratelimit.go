@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+)
+
+// RateLimits configures per-client quotas, in requests per minute.
+type RateLimits struct {
+	// Default is the quota of clients without a tier, keyed by IP.
+	Default int
+	// Tiers maps a tier name to its quota.
+	Tiers map[string]int
+	// APIKeys maps API keys, sent in X-API-Key, to a tier. Unknown keys
+	// are ignored and the client is limited by IP.
+	APIKeys map[string]string
+	// Routes overrides the quota of named routes, either for every tier
+	// ("employees.data_export") or for one ("employees.data_export@premium").
+	// Overridden routes get a bucket of their own.
+	Routes map[string]int
+	// TrustedProxies are the networks whose X-Forwarded-For is believed.
+	TrustedProxies []*net.IPNet
+}
+
+// For returns the quota of a tier on a named route, and whether it is a
+// route override.
+func (l RateLimits) For(route, tier string) (int, bool) {
+	if route != "" {
+		if n, ok := l.Routes[route+"@"+tier]; ok {
+			return n, true
+		}
+		if n, ok := l.Routes[route]; ok {
+			return n, true
+		}
+	}
+	if n, ok := l.Tiers[tier]; ok {
+		return n, false
+	}
+	return l.Default, false
+}
+
+// ParseTrustedProxies parses a comma separated list of CIDRs or single IPs.
+func ParseTrustedProxies(spec string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// rateLimitWindow is the period quotas are expressed in.
+const rateLimitWindow = time.Minute
+
+// RateLimiter enforces RateLimits, counting requests in a RateLimitStore.
+type RateLimiter struct {
+	limits RateLimits
+	store  domain.RateLimitStore
+
+	// OnReject, when set, is called for every request rejected with 429.
+	OnReject func(r *http.Request)
+	// RouteName, when set, names the route a request will be served by, so
+	// per-route quotas apply. The limiter runs before mux matching.
+	RouteName func(r *http.Request) string
+}
+
+// NewRateLimiter creates a limiter enforcing limits with counts kept in
+// store. Run Cleanup in the background to expire old counts.
+func NewRateLimiter(limits RateLimits, store domain.RateLimitStore) *RateLimiter {
+	return &RateLimiter{limits: limits, store: store}
+}
+
+// Cleanup expires the store's old counts until ctx is cancelled.
+func (l *RateLimiter) Cleanup(ctx context.Context) {
+	l.store.Cleanup(ctx)
+}
+
+// Middleware sets RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// on every response and rejects requests over the quota with 429 and
+// Retry-After.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var route string
+		if l.RouteName != nil {
+			route = l.RouteName(r)
+		}
+		client, tier := l.Identify(r.Header.Get("X-API-Key"), ClientIP(r, l.limits.TrustedProxies))
+		limit, result, err := l.Take(r.Context(), client, tier, route)
+		if err != nil {
+			// Fail open: an unreachable store must not take the API down
+			slog.WarnContext(r.Context(), "rate limit store unavailable", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			if l.OnReject != nil {
+				l.OnReject(r)
+			}
+			respondWithError(w, http.StatusTooManyRequests, apierror.MsgRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Take counts a request by client in tier against its quota on route,
+// returning the quota. Other transports than HTTP, like gRPC, call it
+// directly with the identity from Identify.
+func (l *RateLimiter) Take(ctx context.Context, client, tier, route string) (int, domain.RateLimitResult, error) {
+	limit, perRoute := l.limits.For(route, tier)
+	key := client
+	if perRoute {
+		key += "|" + route
+	}
+	result, err := l.store.Take(ctx, key, limit, rateLimitWindow)
+	return limit, result, err
+}
+
+// Identify returns the bucket key and tier of the caller: a known API key,
+// else the client IP. The API has no user accounts, so API keys are the
+// only way into a tier. They are hashed since the key may be stored in a
+// shared database.
+func (l *RateLimiter) Identify(apiKey, ip string) (string, string) {
+	if apiKey != "" {
+		if tier, ok := l.limits.APIKeys[apiKey]; ok {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:16]), tier
+		}
+	}
+	return "ip:" + ip, ""
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ClientIP returns the IP of the client that sent r. X-Forwarded-For is
+// only believed when the connection comes from a trusted proxy, and is read
+// from the right, skipping further trusted proxies, so a client cannot
+// spoof its address by sending the header itself.
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !isTrusted(ip, trusted) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+	return ip
+}
+
+func isTrusted(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"karyawan-app/internal/region"
+)
+
+// RegionHandler serves the region reference dataset for cascading address
+// dropdowns: provinsi -> kota/kabupaten -> kecamatan -> kelurahan.
+type RegionHandler struct {
+	regions *region.Directory
+}
+
+func NewRegionHandler(regions *region.Directory) *RegionHandler {
+	return &RegionHandler{regions: regions}
+}
+
+func (h *RegionHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/regions/provinces", h.GetProvinces).Methods("GET").Name("regions.provinces")
+	router.HandleFunc("/regions/provinces/{code}/cities", h.GetCities).Methods("GET").Name("regions.cities")
+	router.HandleFunc("/regions/cities/{code}/districts", h.GetDistricts).Methods("GET").Name("regions.districts")
+	router.HandleFunc("/regions/districts/{code}/villages", h.GetVillages).Methods("GET").Name("regions.villages")
+}
+
+func (h *RegionHandler) GetProvinces(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.regions.Provinces())
+}
+
+func (h *RegionHandler) GetCities(w http.ResponseWriter, r *http.Request) {
+	cities, err := h.regions.Cities(mux.Vars(r)["code"])
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Province not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, cities)
+}
+
+func (h *RegionHandler) GetDistricts(w http.ResponseWriter, r *http.Request) {
+	districts, err := h.regions.Districts(mux.Vars(r)["code"])
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "City not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, districts)
+}
+
+func (h *RegionHandler) GetVillages(w http.ResponseWriter, r *http.Request) {
+	villages, err := h.regions.Villages(mux.Vars(r)["code"])
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "District not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, villages)
+}
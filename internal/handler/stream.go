@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"karyawan-app/internal/apierror"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/events"
+)
+
+// StreamConfig configures the server-sent event stream of employee changes.
+type StreamConfig struct {
+	// Heartbeat is the interval of comments sent on idle streams, so
+	// proxies and clients do not take them for dead.
+	Heartbeat time.Duration
+	// WriteTimeout bounds every write to the client. It takes the place of
+	// the server's WriteTimeout, which would end streams after it elapsed.
+	WriteTimeout time.Duration
+}
+
+// StreamHandler serves employee changes as server-sent events. Clients that
+// reconnect with Last-Event-ID first get the events they missed from the
+// persisted event log, then new events as they happen.
+type StreamHandler struct {
+	feed *events.Feed
+	cfg  StreamConfig
+}
+
+func NewStreamHandler(feed *events.Feed, cfg StreamConfig) *StreamHandler {
+	return &StreamHandler{feed: feed, cfg: cfg}
+}
+
+// RegisterRoutes must be called before EmployeeHandler.RegisterRoutes, or
+// /employees/{id} takes the requests.
+func (h *StreamHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/employees/stream", traced("StreamHandler.StreamEmployees", h.StreamEmployees)).Methods("GET").Name("employees.stream")
+}
+
+func (h *StreamHandler) StreamEmployees(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	afterID := int64(-1)
+	if resume := r.Header.Get("Last-Event-ID"); resume != "" {
+		id, err := strconv.ParseInt(resume, 10, 64)
+		if err != nil || id < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+		afterID = id
+	}
+	// Employees have no department column, so streams are filtered by role
+	// only
+	roles := make(map[string]bool)
+	for _, role := range r.URL.Query()["role"] {
+		roles[role] = true
+	}
+
+	watch, err := h.feed.Watch(ctx, afterID)
+	if err != nil {
+		respondWithServiceError(w, r, err, apierror.Internal, "Failed to read employee events")
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// Keep nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	s := &eventStream{w: w, rc: http.NewResponseController(w), timeout: h.cfg.WriteTimeout}
+	w.WriteHeader(http.StatusOK)
+	if err := s.write(": connected\n\n"); err != nil {
+		return
+	}
+
+	var heartbeat <-chan time.Time
+	if h.cfg.Heartbeat > 0 {
+		ticker := time.NewTicker(h.cfg.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	for {
+		select {
+		case event, ok := <-watch.C:
+			if !ok {
+				// The client went away, fell behind, the log could not be
+				// read or the server is shutting down. Ending the response
+				// makes clients reconnect and resume with Last-Event-ID.
+				if err := watch.Err(); ctx.Err() == nil && !errors.Is(err, events.ErrClosed) && !errors.Is(err, events.ErrFellBehind) {
+					slog.ErrorContext(ctx, "failed to read employee events", "error", err)
+				}
+				return
+			}
+			if len(roles) > 0 && !roles[event.Role] {
+				continue
+			}
+			if err := s.send(event); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if err := s.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// eventStream writes server-sent events, each flushed straight away.
+type eventStream struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+// send writes event with its ID, so clients can resume after it, and its
+// type as the event name. Events the log failed to store have no ID, which
+// leaves the client's Last-Event-ID at the previous event.
+func (s *eventStream) send(event domain.EmployeeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var id string
+	if event.ID != 0 {
+		id = fmt.Sprintf("id: %d\n", event.ID)
+	}
+	return s.write(fmt.Sprintf("%sevent: %s\ndata: %s\n\n", id, event.Type, data))
+}
+
+func (s *eventStream) write(message string) error {
+	if err := s.rc.SetWriteDeadline(s.deadline()); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := io.WriteString(s.w, message); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// deadline is the write deadline for a write starting now; the zero time
+// means none.
+func (s *eventStream) deadline() time.Time {
+	if s.timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(s.timeout)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteTimeouts bounds how long a request may spend in the service and
+// database layers. Routes are matched by the names given in RegisterRoutes;
+// a zero duration means no deadline.
+type RouteTimeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// For returns the deadline for a named route.
+func (t RouteTimeouts) For(route string) time.Duration {
+	if d, ok := t.Routes[route]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// streamingRoutes serve responses that last as long as the client stays
+// connected. Their handlers bound each query instead.
+var streamingRoutes = map[string]bool{
+	"employees.stream": true,
+}
+
+// TimeoutMiddleware attaches the matched route's deadline to the request
+// context, which the service passes down to every query. Streaming routes
+// get none. It must be installed with Router.Use so the route is already
+// matched.
+func TimeoutMiddleware(timeouts RouteTimeouts) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var name string
+			if route := mux.CurrentRoute(r); route != nil {
+				name = route.GetName()
+			}
+
+			d := timeouts.For(name)
+			if d <= 0 || streamingRoutes[name] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "karyawan-app/internal/handler"
+
+// TracingMiddleware starts the server span for a request, continuing the
+// caller's trace when it sends a W3C traceparent header. It wraps the whole
+// middleware chain so the per-middleware spans become its children.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(tracerName).Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(r.RemoteAddr),
+			),
+		)
+		defer span.End()
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.status))
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
+	})
+}
+
+// traceMiddleware runs h, a middleware already applied to the rest of the
+// chain, in a span named after the middleware.
+func traceMiddleware(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := otel.Tracer(tracerName).Start(r.Context(), "middleware "+name)
+		defer span.End()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traced runs a handler method in a span of the given name, e.g.
+// EmployeeHandler.GetEmployee. The route is recorded once mux has matched.
+func traced(name string, fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := otel.Tracer(tracerName).Start(r.Context(), name)
+		defer span.End()
+		span.SetAttributes(semconv.HTTPRoute(routeTemplate(r)))
+		fn(w, r.WithContext(ctx))
+	}
+}
+
+// routeTemplate returns the path template of the mux route serving r.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// LegacyErrorResponse is the error body of the v1 API, as returned by the
+// original single-file server.
+type LegacyErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// V1Middleware keeps the contract of the original server for the v1 API on
+// top of the current handlers: successful deletes answer 204 without a
+// body, and errors are LegacyErrorResponse bodies carrying the status text,
+// the current error message and the status code. Responses are buffered,
+// which is fine for the small JSON bodies of the v1 routes.
+func V1Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := &bufferedResponse{ResponseWriter: w}
+		next.ServeHTTP(buf, r)
+
+		switch {
+		case r.Method == http.MethodDelete && buf.status == http.StatusOK:
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+		case buf.status >= http.StatusBadRequest:
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(buf.body.Bytes(), &body); err != nil || body.Error == "" {
+				buf.flush()
+				return
+			}
+			respondWithJSON(w, buf.status, LegacyErrorResponse{
+				Error:   http.StatusText(buf.status),
+				Message: body.Error,
+				Code:    buf.status,
+			})
+		default:
+			buf.flush()
+		}
+	})
+}
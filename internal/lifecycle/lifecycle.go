@@ -0,0 +1,63 @@
+// Package lifecycle tracks whether the server is ready for traffic and owns
+// the background workers that must stop before the process exits.
+package lifecycle
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+type Lifecycle struct {
+	ready   atomic.Bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+func New() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// Ready reports whether the server should receive traffic.
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// SetReady flips the readiness flag.
+func (l *Lifecycle) SetReady(ready bool) {
+	l.ready.Store(ready)
+}
+
+// Go runs a background worker. Its context is cancelled when Shutdown is
+// called, and Shutdown waits for it to return.
+func (l *Lifecycle) Go(name string, worker func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		worker(l.ctx)
+		slog.Info("background worker stopped", "worker", name)
+	}()
+}
+
+// Shutdown marks the server not ready, stops the background workers and
+// waits for them until ctx expires.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.SetReady(false)
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
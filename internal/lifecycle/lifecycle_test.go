@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrainsWorkers(t *testing.T) {
+	l := New()
+	l.SetReady(true)
+
+	stopped := make(chan struct{})
+	l.Go("test", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Shutdown() returned before the worker stopped")
+	}
+	if l.Ready() {
+		t.Error("Shutdown() should clear readiness")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	l := New()
+	block := make(chan struct{})
+	defer close(block)
+	l.Go("stuck", func(context.Context) { <-block })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown() error = %v, expected deadline exceeded", err)
+	}
+}
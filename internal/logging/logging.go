@@ -0,0 +1,115 @@
+// Package logging configures the structured JSON logger shared by the
+// handler, service and repository layers. Log lines written with the
+// slog *Context functions carry the request ID of the request they belong
+// to, and personal data is redacted before anything is written.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config controls the logger built by New.
+type Config struct {
+	// Level is the minimum level written.
+	Level slog.Level
+	// Sample2xx logs one in every Sample2xx successful requests in the
+	// access log. Errors are always logged. Values below 1 log everything.
+	Sample2xx int
+}
+
+// New returns a JSON logger writing to w.
+func New(w io.Writer, cfg Config) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       cfg.Level,
+		ReplaceAttr: redactAttr,
+	})
+	return slog.New(contextHandler{h})
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and, when the request is traced, the
+// trace and span IDs from the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	phonePattern = regexp.MustCompile(`(\+62|\b62|\b0)8[0-9]{7,11}\b`)
+)
+
+// redactAttr masks attributes named after PII fields and scrubs email
+// addresses and phone numbers from every other string, including the
+// message, so they cannot leak through error texts.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindString {
+		return a
+	}
+	switch strings.ToLower(a.Key) {
+	case "email":
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	case "phone":
+		return slog.String(a.Key, MaskPhone(a.Value.String()))
+	}
+	return slog.String(a.Key, Redact(a.Value.String()))
+}
+
+// Redact masks every email address and Indonesian mobile number in s.
+func Redact(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+	return phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+}
+
+// MaskEmail keeps the first character of the local part and the domain,
+// e.g. "d***@example.com".
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// MaskPhone keeps the last three digits, e.g. "***890".
+func MaskPhone(phone string) string {
+	if len(phone) <= 3 {
+		return "***"
+	}
+	return "***" + phone[len(phone)-3:]
+}
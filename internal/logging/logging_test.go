@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDAndRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelInfo})
+
+	// Hex IDs can contain digit runs that look like phone numbers
+	ctx := WithRequestID(context.Background(), "a08111222333b")
+	logger.InfoContext(ctx, "lookup by dewi@example.com failed",
+		"email", "dewi@example.com",
+		"phone", "081234567890",
+		"error", "no employee with phone +6281234567890",
+		"employee_id", 7,
+	)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line is not JSON: %s", buf.String())
+	}
+	if line["request_id"] != "a08111222333b" {
+		t.Errorf("request_id = %v", line["request_id"])
+	}
+	if line["email"] != "d***@example.com" || line["phone"] != "***890" {
+		t.Errorf("email/phone not masked: %v, %v", line["email"], line["phone"])
+	}
+	if line["employee_id"] != float64(7) {
+		t.Errorf("employee_id = %v", line["employee_id"])
+	}
+	for _, leak := range []string{"dewi@example.com", "081234567890", "6281234567890"} {
+		if strings.Contains(buf.String(), leak) {
+			t.Errorf("log line leaks %s: %s", leak, buf.String())
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelWarn})
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("unexpected output for level warn: %s", buf.String())
+	}
+}
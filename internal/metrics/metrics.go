@@ -0,0 +1,162 @@
+// Package metrics exposes the server's Prometheus metrics: HTTP traffic by
+// route, database pool and query latencies, rate limiting and business
+// gauges.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "karyawan"
+
+// headcountTimeout bounds the query behind the headcount gauge so a slow
+// database cannot stall a scrape.
+const headcountTimeout = 2 * time.Second
+
+// Metrics owns a registry, so every server (and every test) gets its own
+// set of series.
+type Metrics struct {
+	registry    *prometheus.Registry
+	requests    *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	queries     *prometheus.HistogramVec
+	rateLimited prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Repository call latency by repository and method.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"repository", "method"}),
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Requests rejected with 429 by the rate limiter.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.queries,
+		m.rateLimited,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records request counts and latencies. It must be installed on
+// the mux router so requests are labelled with the route template (e.g.
+// /api/employees/{id}) rather than the raw URI, keeping cardinality bounded.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RateLimited counts a request rejected by the rate limiter.
+func (m *Metrics) RateLimited() {
+	m.rateLimited.Inc()
+}
+
+// RegisterDB exports the connection pool statistics of db as go_sql_*
+// gauges and counters.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterHeadcount exports karyawan_employees_headcount by role, computed
+// by count at scrape time.
+func (m *Metrics) RegisterHeadcount(count func(ctx context.Context) (map[string]int, error)) {
+	m.registry.MustRegister(&headcountCollector{
+		count: count,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "employees", "headcount"),
+			"Number of employees by role.",
+			[]string{"role"}, nil,
+		),
+	})
+}
+
+type headcountCollector struct {
+	count func(ctx context.Context) (map[string]int, error)
+	desc  *prometheus.Desc
+}
+
+func (c *headcountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect skips the gauge when the count fails, so a database outage does
+// not turn every scrape into an error.
+func (c *headcountCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), headcountTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		slog.Warn("failed to collect headcount metric", "error", err)
+		return
+	}
+	for role, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), role)
+	}
+}
+
+// statusRecorder captures the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
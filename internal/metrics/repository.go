@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// InstrumentEmployeeRepository wraps repo so every call is recorded in
+// karyawan_db_query_duration_seconds{repository="employee"}.
+func InstrumentEmployeeRepository(repo domain.EmployeeRepository, m *Metrics) domain.EmployeeRepository {
+	return &employeeRepository{next: repo, m: m}
+}
+
+// InstrumentAuditRepository wraps repo so every call is recorded in
+// karyawan_db_query_duration_seconds{repository="audit"}.
+func InstrumentAuditRepository(repo domain.AuditRepository, m *Metrics) domain.AuditRepository {
+	return &auditRepository{next: repo, m: m}
+}
+
+// InstrumentEventLog wraps log so every call is recorded in
+// karyawan_db_query_duration_seconds{repository="event"}.
+func InstrumentEventLog(log domain.EventLog, m *Metrics) domain.EventLog {
+	return &eventLog{next: log, m: m}
+}
+
+// InstrumentIdempotencyStore wraps store so every call is recorded in
+// karyawan_db_query_duration_seconds{repository="idempotency"}.
+func InstrumentIdempotencyStore(store domain.IdempotencyStore, m *Metrics) domain.IdempotencyStore {
+	return &idempotencyStore{next: store, m: m}
+}
+
+func (m *Metrics) observeQuery(repository, method string, start time.Time) {
+	m.queries.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
+
+type employeeRepository struct {
+	next domain.EmployeeRepository
+	m    *Metrics
+}
+
+func (r *employeeRepository) FindAll(ctx context.Context) ([]domain.Employee, error) {
+	defer r.m.observeQuery("employee", "FindAll", time.Now())
+	return r.next.FindAll(ctx)
+}
+
+func (r *employeeRepository) FindPage(ctx context.Context, beforeID, limit int) ([]domain.Employee, error) {
+	defer r.m.observeQuery("employee", "FindPage", time.Now())
+	return r.next.FindPage(ctx, beforeID, limit)
+}
+
+func (r *employeeRepository) FindByID(ctx context.Context, id int) (*domain.Employee, error) {
+	defer r.m.observeQuery("employee", "FindByID", time.Now())
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *employeeRepository) FindByIDs(ctx context.Context, ids []int) ([]domain.Employee, error) {
+	defer r.m.observeQuery("employee", "FindByIDs", time.Now())
+	return r.next.FindByIDs(ctx, ids)
+}
+
+func (r *employeeRepository) FindByEmail(ctx context.Context, email string) (*domain.Employee, error) {
+	defer r.m.observeQuery("employee", "FindByEmail", time.Now())
+	return r.next.FindByEmail(ctx, email)
+}
+
+func (r *employeeRepository) CountByEmails(ctx context.Context, emails []string) (int, error) {
+	defer r.m.observeQuery("employee", "CountByEmails", time.Now())
+	return r.next.CountByEmails(ctx, emails)
+}
+
+func (r *employeeRepository) FindByPhone(ctx context.Context, phone string) ([]domain.Employee, error) {
+	defer r.m.observeQuery("employee", "FindByPhone", time.Now())
+	return r.next.FindByPhone(ctx, phone)
+}
+
+func (r *employeeRepository) Create(ctx context.Context, employee *domain.Employee) error {
+	defer r.m.observeQuery("employee", "Create", time.Now())
+	return r.next.Create(ctx, employee)
+}
+
+func (r *employeeRepository) CreateBatch(ctx context.Context, employees []domain.Employee) error {
+	defer r.m.observeQuery("employee", "CreateBatch", time.Now())
+	return r.next.CreateBatch(ctx, employees)
+}
+
+func (r *employeeRepository) ApplyBatch(ctx context.Context, ops []domain.BulkOperation, atomic bool) ([]error, error) {
+	defer r.m.observeQuery("employee", "ApplyBatch", time.Now())
+	return r.next.ApplyBatch(ctx, ops, atomic)
+}
+
+func (r *employeeRepository) Update(ctx context.Context, employee *domain.Employee) error {
+	defer r.m.observeQuery("employee", "Update", time.Now())
+	return r.next.Update(ctx, employee)
+}
+
+func (r *employeeRepository) Delete(ctx context.Context, id int) error {
+	defer r.m.observeQuery("employee", "Delete", time.Now())
+	return r.next.Delete(ctx, id)
+}
+
+func (r *employeeRepository) Anonymize(ctx context.Context, employee *domain.Employee) error {
+	defer r.m.observeQuery("employee", "Anonymize", time.Now())
+	return r.next.Anonymize(ctx, employee)
+}
+
+func (r *employeeRepository) CountByRole(ctx context.Context) (map[string]int, error) {
+	defer r.m.observeQuery("employee", "CountByRole", time.Now())
+	return r.next.CountByRole(ctx)
+}
+
+type auditRepository struct {
+	next domain.AuditRepository
+	m    *Metrics
+}
+
+func (r *auditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	defer r.m.observeQuery("audit", "Record", time.Now())
+	return r.next.Record(ctx, entry)
+}
+
+func (r *auditRepository) FindByEmployee(ctx context.Context, employeeID int) ([]domain.AuditEntry, error) {
+	defer r.m.observeQuery("audit", "FindByEmployee", time.Now())
+	return r.next.FindByEmployee(ctx, employeeID)
+}
+
+func (r *auditRepository) FindByEmployees(ctx context.Context, employeeIDs []int) ([]domain.AuditEntry, error) {
+	defer r.m.observeQuery("audit", "FindByEmployees", time.Now())
+	return r.next.FindByEmployees(ctx, employeeIDs)
+}
+
+type eventLog struct {
+	next domain.EventLog
+	m    *Metrics
+}
+
+func (l *eventLog) Append(ctx context.Context, event *domain.EmployeeEvent) error {
+	defer l.m.observeQuery("event", "Append", time.Now())
+	return l.next.Append(ctx, event)
+}
+
+func (l *eventLog) FindAfter(ctx context.Context, afterID int64, limit int) ([]domain.EmployeeEvent, error) {
+	defer l.m.observeQuery("event", "FindAfter", time.Now())
+	return l.next.FindAfter(ctx, afterID, limit)
+}
+
+func (l *eventLog) FindByEmployee(ctx context.Context, employeeID int) ([]domain.EmployeeEvent, error) {
+	defer l.m.observeQuery("event", "FindByEmployee", time.Now())
+	return l.next.FindByEmployee(ctx, employeeID)
+}
+
+func (l *eventLog) LastID(ctx context.Context) (int64, error) {
+	defer l.m.observeQuery("event", "LastID", time.Now())
+	return l.next.LastID(ctx)
+}
+
+func (l *eventLog) Prune(ctx context.Context, before time.Time) (int64, error) {
+	defer l.m.observeQuery("event", "Prune", time.Now())
+	return l.next.Prune(ctx, before)
+}
+
+type idempotencyStore struct {
+	next domain.IdempotencyStore
+	m    *Metrics
+}
+
+func (s *idempotencyStore) Reserve(ctx context.Context, key, fingerprint string, until time.Time) (*domain.IdempotencyRecord, error) {
+	defer s.m.observeQuery("idempotency", "Reserve", time.Now())
+	return s.next.Reserve(ctx, key, fingerprint, until)
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, key string, record domain.IdempotencyRecord, expires time.Time) error {
+	defer s.m.observeQuery("idempotency", "Complete", time.Now())
+	return s.next.Complete(ctx, key, record, expires)
+}
+
+func (s *idempotencyStore) Release(ctx context.Context, key string) error {
+	defer s.m.observeQuery("idempotency", "Release", time.Now())
+	return s.next.Release(ctx, key)
+}
+
+func (s *idempotencyStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	defer s.m.observeQuery("idempotency", "Prune", time.Now())
+	return s.next.Prune(ctx, before)
+}
+
+func (s *idempotencyStore) PurgeEmployee(ctx context.Context, employeeID int) error {
+	defer s.m.observeQuery("idempotency", "PurgeEmployee", time.Now())
+	return s.next.PurgeEmployee(ctx, employeeID)
+}
+
+func (s *idempotencyStore) FindByEmployee(ctx context.Context, employeeID int) ([]domain.StoredResponse, error) {
+	defer s.m.observeQuery("idempotency", "FindByEmployee", time.Now())
+	return s.next.FindByEmployee(ctx, employeeID)
+}
@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// methods are the operation keys of a path item, in document order.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// loader walks the decoded document, following OpenAPI references, and
+// compiles every schema it reaches by its JSON pointer.
+type loader struct {
+	doc      interface{}
+	compiler *jsonschema.Compiler
+}
+
+func (l *loader) spec() (*Spec, error) {
+	root, _ := l.doc.(map[string]interface{})
+	if v, _ := root["openapi"].(string); !strings.HasPrefix(v, "3.1.") {
+		return nil, fmt.Errorf("openapi version %q, expected 3.1.x", v)
+	}
+	paths, _ := root["paths"].(map[string]interface{})
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths")
+	}
+
+	spec := &Spec{operations: make(map[string]*operation)}
+	for _, path := range sortedKeys(paths) {
+		ptr := "/paths/" + escape(path)
+		item, _, err := l.resolve(paths[path], ptr)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := l.parameters(item["parameters"], ptr+"/parameters")
+		if err != nil {
+			return nil, err
+		}
+		for _, method := range methods {
+			raw, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op, err := l.operation(raw, ptr+"/"+method, shared)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			op.Method = strings.ToUpper(method)
+			op.Path = path
+			if _, dup := spec.operations[op.ID]; dup {
+				return nil, fmt.Errorf("duplicate operationId %q", op.ID)
+			}
+			spec.operations[op.ID] = op
+		}
+	}
+	return spec, nil
+}
+
+func (l *loader) operation(raw map[string]interface{}, ptr string, shared []parameter) (*operation, error) {
+	id, _ := raw["operationId"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("missing operationId")
+	}
+	op := &operation{Operation: Operation{ID: id}, responses: make(map[string]content)}
+
+	own, err := l.parameters(raw["parameters"], ptr+"/parameters")
+	if err != nil {
+		return nil, err
+	}
+	// Operation parameters override path item parameters of the same name
+	// and location
+	for _, p := range shared {
+		overridden := false
+		for _, o := range own {
+			overridden = overridden || o.name == p.name && o.in == p.in
+		}
+		if !overridden {
+			op.params = append(op.params, p)
+		}
+	}
+	op.params = append(op.params, own...)
+
+	if raw["requestBody"] != nil {
+		body, bodyPtr, err := l.resolve(raw["requestBody"], ptr+"/requestBody")
+		if err != nil {
+			return nil, err
+		}
+		c, err := l.content(body["content"], bodyPtr+"/content")
+		if err != nil {
+			return nil, err
+		}
+		required, _ := body["required"].(bool)
+		op.body = &requestBody{required: required, content: c}
+	}
+
+	responses, _ := raw["responses"].(map[string]interface{})
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no responses")
+	}
+	for status, r := range responses {
+		resp, respPtr, err := l.resolve(r, ptr+"/responses/"+escape(status))
+		if err != nil {
+			return nil, err
+		}
+		if op.responses[status], err = l.content(resp["content"], respPtr+"/content"); err != nil {
+			return nil, err
+		}
+	}
+	return op, nil
+}
+
+func (l *loader) parameters(raw interface{}, ptr string) ([]parameter, error) {
+	list, _ := raw.([]interface{})
+	params := make([]parameter, 0, len(list))
+	for i, item := range list {
+		p, pPtr, err := l.resolve(item, fmt.Sprintf("%s/%d", ptr, i))
+		if err != nil {
+			return nil, err
+		}
+		param := parameter{}
+		param.name, _ = p["name"].(string)
+		param.in, _ = p["in"].(string)
+		param.required, _ = p["required"].(bool)
+		schema, _ := p["schema"].(map[string]interface{})
+		if param.name == "" || param.in == "" || schema == nil {
+			return nil, fmt.Errorf("parameter %s needs a name, a location and a schema", pPtr)
+		}
+		param.integer = schema["type"] == "integer"
+		if param.schema, err = l.compile(pPtr + "/schema"); err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+func (l *loader) content(raw interface{}, ptr string) (content, error) {
+	media, _ := raw.(map[string]interface{})
+	c := make(content, len(media))
+	for mediaType, m := range media {
+		c[mediaType] = nil
+		mt, _ := m.(map[string]interface{})
+		if _, ok := mt["schema"]; !ok || !isJSON(mediaType) {
+			continue
+		}
+		schema, err := l.compile(ptr + "/" + escape(mediaType) + "/schema")
+		if err != nil {
+			return nil, err
+		}
+		c[mediaType] = schema
+	}
+	return c, nil
+}
+
+func (l *loader) compile(ptr string) (*jsonschema.Schema, error) {
+	return l.compiler.Compile(documentURL + "#" + ptr)
+}
+
+// resolve follows a local $ref, returning the referenced object and its
+// JSON pointer. Objects without a $ref are returned as they are.
+func (l *loader) resolve(v interface{}, ptr string) (map[string]interface{}, string, error) {
+	for range 8 {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("%s is not an object", ptr)
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, ptr, nil
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, "", fmt.Errorf("%s: only local references are supported, got %q", ptr, ref)
+		}
+		ptr = ref[1:]
+		v = l.doc
+		for _, token := range strings.Split(ptr[1:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			m, _ := v.(map[string]interface{})
+			if v, ok = m[token]; !ok {
+				return nil, "", fmt.Errorf("unresolved reference %q", ref)
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("%s: too many nested references", ptr)
+}
+
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,278 @@
+// Package openapi holds the OpenAPI 3.1 description of the HTTP API and
+// validates requests and responses against it.
+//
+// The document is maintained by hand in openapi.json and embedded in the
+// binary. Every operation's operationId is the name of the mux route that
+// serves it, which is how requests are matched to operations. Schemas are
+// JSON Schema draft 2020-12, the dialect of OpenAPI 3.1.
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// documentURL is the URL the document is registered under for resolving
+// references. It is never fetched.
+const documentURL = "mem:///openapi.json"
+
+// ErrUnsupportedMediaType is returned for request bodies whose Content-Type
+// the operation does not accept.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// Document returns the embedded OpenAPI document.
+func Document() []byte {
+	return document
+}
+
+// Operation identifies an operation of the API.
+type Operation struct {
+	// ID is the operationId, which is also the mux route name.
+	ID     string
+	Method string
+	// Path is the path template relative to the /api/v2 server URL, e.g.
+	// /employees/{id}.
+	Path string
+}
+
+// Spec is the compiled document.
+type Spec struct {
+	operations map[string]*operation
+}
+
+type operation struct {
+	Operation
+	params    []parameter
+	body      *requestBody
+	responses map[string]content
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	// integer parameters are converted from their string form before
+	// validation
+	integer bool
+	schema  *jsonschema.Schema
+}
+
+type requestBody struct {
+	required bool
+	content  content
+}
+
+// content maps media types to their schema, which is nil for media types
+// that are not JSON.
+type content map[string]*jsonschema.Schema
+
+// Load compiles the embedded document.
+func Load() (*Spec, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	if err := c.AddResource(documentURL, doc); err != nil {
+		return nil, err
+	}
+	l := &loader{doc: doc, compiler: c}
+	spec, err := l.spec()
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return spec, nil
+}
+
+// Operations lists the operations of the API, sorted by ID.
+func (s *Spec) Operations() []Operation {
+	ops := make([]Operation, 0, len(s.operations))
+	for _, op := range s.operations {
+		ops = append(ops, op.Operation)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	return ops
+}
+
+// Operation returns the operation with the given ID.
+func (s *Spec) Operation(id string) (Operation, bool) {
+	op, ok := s.operations[id]
+	if !ok {
+		return Operation{}, false
+	}
+	return op.Operation, true
+}
+
+// Streams reports whether operation id responds with a stream of
+// server-sent events, which has no end to validate.
+func (s *Spec) Streams(id string) bool {
+	op, ok := s.operations[id]
+	if !ok {
+		return false
+	}
+	for _, c := range op.responses {
+		if _, ok := c["text/event-stream"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRequest checks the parameters and body of a request to operation
+// id. pathParams holds the values matched by the router. The body is read
+// and replaced, so the handler can still decode it.
+func (s *Spec) ValidateRequest(id string, r *http.Request, pathParams map[string]string) error {
+	op, ok := s.operations[id]
+	if !ok {
+		return fmt.Errorf("unknown operation %q", id)
+	}
+
+	for _, p := range op.params {
+		var value string
+		var present bool
+		switch p.in {
+		case "path":
+			value, present = pathParams[p.name]
+		case "query":
+			values, ok := r.URL.Query()[p.name]
+			if ok {
+				value, present = values[0], true
+			}
+		case "header":
+			value = r.Header.Get(p.name)
+			present = value != ""
+		}
+		if !present {
+			if p.required {
+				return fmt.Errorf("%s parameter %q is required", p.in, p.name)
+			}
+			continue
+		}
+		var v interface{} = value
+		if p.integer {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s parameter %q must be an integer", p.in, p.name)
+			}
+			v = n
+		}
+		if err := p.schema.Validate(v); err != nil {
+			return fmt.Errorf("%s parameter %q: %s", p.in, p.name, describe(err))
+		}
+	}
+
+	if op.body == nil {
+		return nil
+	}
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.body.required {
+			return errors.New("request body is required")
+		}
+		return nil
+	}
+	// Clients that leave out the Content-Type get the JSON they send
+	// decoded anyway
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+			return fmt.Errorf("%w %q", ErrUnsupportedMediaType, ct)
+		}
+	}
+	schema, ok := op.body.content[mediaType]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnsupportedMediaType, mediaType)
+	}
+	return validateJSON("request body", schema, body)
+}
+
+// ValidateResponse checks that a response of operation id has a documented
+// status code and media type, and that a JSON body matches its schema.
+func (s *Spec) ValidateResponse(id string, status int, header http.Header, body []byte) error {
+	op, ok := s.operations[id]
+	if !ok {
+		return fmt.Errorf("unknown operation %q", id)
+	}
+	c, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		if c, ok = op.responses["default"]; !ok {
+			return fmt.Errorf("status %d is not documented for %s", status, id)
+		}
+	}
+	if len(c) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("status %d of %s has no content, got %d bytes", status, id, len(body))
+		}
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	schema, ok := c[mediaType]
+	if !ok {
+		return fmt.Errorf("media type %q is not documented for status %d of %s", header.Get("Content-Type"), status, id)
+	}
+	return validateJSON("response body", schema, body)
+}
+
+// validateJSON validates a JSON document against schema. Media types
+// without a schema are not checked.
+func validateJSON(what string, schema *jsonschema.Schema, data []byte) error {
+	if schema == nil {
+		return nil
+	}
+	v, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s is not valid JSON", what)
+	}
+	if err := schema.Validate(v); err != nil {
+		return fmt.Errorf("%s: %s", what, describe(err))
+	}
+	return nil
+}
+
+// describe condenses a schema validation error into its root causes, e.g.
+// "missing property 'name'; /id: got string, want integer".
+func describe(err error) string {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+	var causes []string
+	var walk func(u jsonschema.OutputUnit)
+	walk = func(u jsonschema.OutputUnit) {
+		if len(u.Errors) == 0 && u.Error != nil {
+			cause := u.Error.String()
+			if u.InstanceLocation != "" {
+				cause = u.InstanceLocation + ": " + cause
+			}
+			causes = append(causes, cause)
+		}
+		for _, e := range u.Errors {
+			walk(e)
+		}
+	}
+	walk(*ve.DetailedOutput())
+	return strings.Join(causes, "; ")
+}
@@ -0,0 +1,109 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func load(t *testing.T) *Spec {
+	t.Helper()
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	return spec
+}
+
+func TestValidateRequest(t *testing.T) {
+	spec := load(t)
+	valid := `{"name":"Dewi","email":"dewi@example.com","position":"HR","role":"HR","phone":"0812","alamat":"Jakarta"}`
+
+	tests := []struct {
+		name        string
+		op          string
+		contentType string
+		body        string
+		params      map[string]string
+		want        string
+	}{
+		{"valid", "employees.create", "application/json", valid, nil, ""},
+		{"charset parameter", "employees.create", "application/json; charset=utf-8", valid, nil, ""},
+		{"no content type", "employees.create", "", valid, nil, ""},
+		{"missing body", "employees.create", "application/json", "", nil, "request body is required"},
+		{"malformed JSON", "employees.create", "application/json", "{", nil, "request body is not valid JSON"},
+		{"not an object", "employees.create", "application/json", "[]", nil, "got array, want object"},
+		{"empty name", "employees.create", "application/json", strings.Replace(valid, `"Dewi"`, `""`, 1), nil, "/name: minLength"},
+		{"other media type", "employees.create", "text/plain", valid, nil, `unsupported media type "text/plain"`},
+		{"integer path parameter", "employees.update", "application/json", valid, map[string]string{"id": "7"}, ""},
+		{"non-integer path parameter", "employees.update", "application/json", valid, map[string]string{"id": "7a"}, `path parameter "id" must be an integer`},
+		{"missing path parameter", "employees.get", "", "", nil, `path parameter "id" is required`},
+		{"body ignored without requestBody", "employees.get", "text/plain", "anything", map[string]string{"id": "1"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			op, _ := spec.Operation(test.op)
+			r := httptest.NewRequest(op.Method, "/api/employees", strings.NewReader(test.body))
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+			err := spec.ValidateRequest(test.op, r, test.params)
+			if test.want == "" && err != nil {
+				t.Fatalf("ValidateRequest() error: %v", err)
+			}
+			if test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)) {
+				t.Fatalf("ValidateRequest() error = %v, expected %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateRequestKeepsBody(t *testing.T) {
+	spec := load(t)
+	body := `{"name":"Dewi","email":"dewi@example.com","position":"HR","role":"HR","phone":"0812","alamat":"Jakarta"}`
+	r := httptest.NewRequest("POST", "/api/employees", strings.NewReader(body))
+	if err := spec.ValidateRequest("employees.create", r, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r.Body); string(got) != body {
+		t.Errorf("body after validation = %q", got)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	spec := load(t)
+	employee := `{"id":1,"name":"Dewi","email":"dewi@example.com","position":"HR","role":"HR","phone":"0812","alamat":"Jakarta",` +
+		`"created_at":"2026-01-02T03:04:05Z","updated_at":"2026-01-02T03:04:05Z"}`
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	tests := []struct {
+		name   string
+		op     string
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{"valid", "employees.get", 200, jsonHeader, employee, ""},
+		{"valid list", "employees.list", 200, jsonHeader, "[" + employee + "]", ""},
+		{"error", "employees.get", 404, jsonHeader, `{"error":"Employee not found"}`, ""},
+		{"undocumented field", "employees.get", 200, jsonHeader, strings.Replace(employee, `"id":1`, `"id":1,"salary":1`, 1), "additional properties 'salary' not allowed"},
+		{"missing field", "employees.get", 200, jsonHeader, strings.Replace(employee, `"role":"HR",`, "", 1), "missing property 'role'"},
+		{"undocumented status", "employees.get", 418, jsonHeader, `{"error":"teapot"}`, "status 418 is not documented"},
+		{"undocumented media type", "employees.get", 200, http.Header{"Content-Type": {"text/plain"}}, employee, `media type "text/plain" is not documented`},
+		{"binary content", "employees.data_export", 200, http.Header{"Content-Type": {"application/zip"}}, "PK\x03\x04", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := spec.ValidateResponse(test.op, test.status, test.header, []byte(test.body))
+			if test.want == "" && err != nil {
+				t.Fatalf("ValidateResponse() error: %v", err)
+			}
+			if test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)) {
+				t.Fatalf("ValidateResponse() error = %v, expected %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package pii
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"karyawan-app/internal/config"
+)
+
+// keyFile is the on-disk format read from the pii.key_file setting.
+type keyFile struct {
+	CurrentKeyID  string            `json:"current_key_id"`
+	MasterKeys    map[string]string `json:"master_keys"`
+	BlindIndexKey string            `json:"blind_index_key"`
+}
+
+// LoadKeyring builds a keyring from the configuration. KeyFile points at
+// a JSON key file and takes precedence; otherwise MasterKey
+// ("id:base64key"), the optional RetiredMasterKeys and BlindIndexKey are
+// used. It returns nil, nil when no key material is configured.
+func LoadKeyring(cfg config.PII) (*Keyring, error) {
+	if cfg.KeyFile != "" {
+		return LoadKeyFile(cfg.KeyFile)
+	}
+
+	current := cfg.MasterKey
+	if current == "" {
+		return nil, nil
+	}
+
+	masterKeys := make(map[string][]byte)
+	currentID, err := parseKeyEntry(current, masterKeys)
+	if err != nil {
+		return nil, fmt.Errorf("pii.master_key: %w", err)
+	}
+	for _, entry := range cfg.RetiredMasterKeys {
+		if _, err := parseKeyEntry(strings.TrimSpace(entry), masterKeys); err != nil {
+			return nil, fmt.Errorf("pii.retired_master_keys: %w", err)
+		}
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(cfg.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("pii.blind_index_key: %w", err)
+	}
+	return NewKeyring(currentID, masterKeys, indexKey)
+}
+
+// LoadKeyFile reads a JSON key file of the form
+//
+//	{"current_key_id": "2026-10", "master_keys": {"2026-10": "<base64>"}, "blind_index_key": "<base64>"}
+func LoadKeyFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	masterKeys := make(map[string][]byte, len(kf.MasterKeys))
+	for id, encoded := range kf.MasterKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		masterKeys[id] = key
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(kf.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key: %w", err)
+	}
+	return NewKeyring(kf.CurrentKeyID, masterKeys, indexKey)
+}
+
+// GenerateKey returns a random base64 encoded key suitable for a master key
+// or the blind index key.
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func parseKeyEntry(entry string, into map[string][]byte) (string, error) {
+	id, encoded, ok := strings.Cut(entry, ":")
+	if !ok {
+		return "", fmt.Errorf("expected id:base64key, got %q", entry)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("key %q: %w", id, err)
+	}
+	into[id] = key
+	return id, nil
+}
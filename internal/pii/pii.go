@@ -0,0 +1,213 @@
+// Package pii implements envelope encryption and blind indexes for
+// personally identifiable fields stored in the database.
+//
+// Every value is encrypted with a fresh AES-256-GCM data key, and the data
+// key is wrapped with the current master key. The stored form is
+//
+//	enc:v1:<master key id>:<wrapped data key>:<ciphertext>
+//
+// so values written under a retired master key can still be decrypted
+// while rows are rotated to the current one.
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	prefix  = "enc:v1:"
+	keySize = 32
+)
+
+var (
+	// ErrUnknownKey is returned when a value was encrypted with a master
+	// key that is not in the keyring.
+	ErrUnknownKey = errors.New("pii: unknown master key")
+	// ErrMalformed is returned for values that carry the encryption prefix
+	// but cannot be parsed.
+	ErrMalformed = errors.New("pii: malformed ciphertext")
+)
+
+// Keyring holds the master keys used to wrap data keys and the key used
+// for blind indexes.
+type Keyring struct {
+	currentID  string
+	masterKeys map[string][]byte
+	indexKey   []byte
+}
+
+// NewKeyring builds a keyring. currentID selects the master key used for
+// new values; the others are only used to decrypt.
+func NewKeyring(currentID string, masterKeys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := masterKeys[currentID]; !ok {
+		return nil, fmt.Errorf("pii: current master key %q not provided", currentID)
+	}
+	for id, key := range masterKeys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("pii: invalid master key id %q", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("pii: master key %q must be %d bytes, got %d", id, keySize, len(key))
+		}
+	}
+	if len(indexKey) < keySize {
+		return nil, fmt.Errorf("pii: blind index key must be at least %d bytes", keySize)
+	}
+	return &Keyring{currentID: currentID, masterKeys: masterKeys, indexKey: indexKey}, nil
+}
+
+// CurrentKeyID returns the id of the master key used for new values.
+func (k *Keyring) CurrentKeyID() string {
+	return k.currentID
+}
+
+// Encrypt seals plaintext under a new data key wrapped by the current
+// master key. Empty strings are stored as-is.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(k.masterKeys[k.currentID], dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawStdEncoding
+	return prefix + k.currentID + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the encryption
+// prefix are legacy plaintext and are returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+	masterKey, ok := k.masterKeys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, parts[0])
+	}
+
+	enc := base64.RawStdEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrMalformed
+	}
+	ciphertext, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	dataKey, err := open(masterKey, wrapped)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value is plaintext or was encrypted with a
+// master key other than the current one.
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	return !strings.HasPrefix(value, prefix+k.currentID+":")
+}
+
+// BlindIndex returns a deterministic HMAC-SHA256 of an already normalized
+// value, used for equality lookups on encrypted columns.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted reports whether value carries the encryption prefix.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// NormalizeEmail prepares an email address for blind indexing.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone prepares a phone number for blind indexing by keeping only
+// digits and rewriting the +62/62 country prefix to a leading 0.
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	return digits
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pii: decryption failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,87 @@
+package pii
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testKeyring(t *testing.T, currentID string, ids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for i, id := range ids {
+		keys[id] = bytes.Repeat([]byte{byte(i + 1)}, keySize)
+	}
+	k, err := NewKeyring(currentID, keys, bytes.Repeat([]byte{0xAA}, keySize))
+	if err != nil {
+		t.Fatalf("NewKeyring() error: %v", err)
+	}
+	return k
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	k := testKeyring(t, "k1", "k1")
+
+	enc, err := k.Encrypt("081234567890")
+	if err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+	if !IsEncrypted(enc) {
+		t.Fatalf("Encrypt() = %q, expected encryption prefix", enc)
+	}
+
+	again, _ := k.Encrypt("081234567890")
+	if again == enc {
+		t.Error("expected a fresh data key and nonce per value")
+	}
+
+	dec, err := k.Decrypt(enc)
+	if err != nil || dec != "081234567890" {
+		t.Errorf("Decrypt() = %q, %v", dec, err)
+	}
+
+	legacy, err := k.Decrypt("Jl. Sudirman No. 1")
+	if err != nil || legacy != "Jl. Sudirman No. 1" {
+		t.Errorf("Decrypt(plaintext) = %q, %v, expected passthrough", legacy, err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := testKeyring(t, "k1", "k1")
+	enc, _ := old.Encrypt("john@example.com")
+
+	rotated := testKeyring(t, "k2", "k1", "k2")
+	if !rotated.NeedsRotation(enc) {
+		t.Error("value sealed under retired key should need rotation")
+	}
+	if !rotated.NeedsRotation("plaintext") {
+		t.Error("plaintext should need rotation")
+	}
+	if dec, err := rotated.Decrypt(enc); err != nil || dec != "john@example.com" {
+		t.Errorf("Decrypt() with retired key = %q, %v", dec, err)
+	}
+
+	fresh, _ := rotated.Encrypt("john@example.com")
+	if rotated.NeedsRotation(fresh) {
+		t.Error("value sealed under current key should not need rotation")
+	}
+
+	withoutOld := testKeyring(t, "k2", "k2")
+	if _, err := withoutOld.Decrypt(enc); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() error = %v, expected ErrUnknownKey", err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := testKeyring(t, "k1", "k1")
+
+	if k.BlindIndex(NormalizeEmail(" John@Example.com")) != k.BlindIndex(NormalizeEmail("john@example.com")) {
+		t.Error("expected email blind index to ignore case and whitespace")
+	}
+	if k.BlindIndex(NormalizePhone("+62 812-3456-7890")) != k.BlindIndex(NormalizePhone("081234567890")) {
+		t.Error("expected phone blind index to ignore formatting and country code")
+	}
+	if k.BlindIndex("a") == k.BlindIndex("b") {
+		t.Error("expected different values to have different blind indexes")
+	}
+}
@@ -0,0 +1,205 @@
+package region
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"karyawan-app/internal/domain"
+)
+
+//go:embed data/regions.json
+var defaultDataset []byte
+
+// Province is a provinsi in the reference dataset.
+type Province struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Cities []City `json:"cities,omitempty"`
+}
+
+// City is a kota or kabupaten.
+type City struct {
+	Code      string     `json:"code"`
+	Name      string     `json:"name"`
+	Districts []District `json:"districts,omitempty"`
+}
+
+// District is a kecamatan.
+type District struct {
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	Villages []Village `json:"villages,omitempty"`
+}
+
+// Village is a kelurahan or desa.
+type Village struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	PostalCode string `json:"postal_code"`
+}
+
+// Directory indexes the region reference dataset for lookups and address
+// validation.
+type Directory struct {
+	provinces []Province
+	byCode    map[string]interface{}
+}
+
+// ErrNotFound is returned when a region code is not in the dataset.
+var ErrNotFound = errors.New("region not found")
+
+// Default returns a Directory built from the embedded dataset.
+func Default() (*Directory, error) {
+	return Load(defaultDataset)
+}
+
+// Load builds a Directory from a JSON dataset with the same shape as
+// data/regions.json.
+func Load(data []byte) (*Directory, error) {
+	var provinces []Province
+	if err := json.Unmarshal(data, &provinces); err != nil {
+		return nil, fmt.Errorf("failed to parse region dataset: %w", err)
+	}
+
+	d := &Directory{provinces: provinces, byCode: make(map[string]interface{})}
+	for i := range provinces {
+		p := &provinces[i]
+		d.byCode[p.Code] = p
+		for j := range p.Cities {
+			c := &p.Cities[j]
+			d.byCode[c.Code] = c
+			for k := range c.Districts {
+				ds := &c.Districts[k]
+				d.byCode[ds.Code] = ds
+			}
+		}
+	}
+	return d, nil
+}
+
+// Provinces lists every province without its children.
+func (d *Directory) Provinces() []Province {
+	out := make([]Province, 0, len(d.provinces))
+	for _, p := range d.provinces {
+		out = append(out, Province{Code: p.Code, Name: p.Name})
+	}
+	return out
+}
+
+// Cities lists the kota/kabupaten of a province.
+func (d *Directory) Cities(provinceCode string) ([]City, error) {
+	p, ok := d.byCode[provinceCode].(*Province)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := make([]City, 0, len(p.Cities))
+	for _, c := range p.Cities {
+		out = append(out, City{Code: c.Code, Name: c.Name})
+	}
+	return out, nil
+}
+
+// Districts lists the kecamatan of a kota/kabupaten.
+func (d *Directory) Districts(cityCode string) ([]District, error) {
+	c, ok := d.byCode[cityCode].(*City)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := make([]District, 0, len(c.Districts))
+	for _, ds := range c.Districts {
+		out = append(out, District{Code: ds.Code, Name: ds.Name})
+	}
+	return out, nil
+}
+
+// Villages lists the kelurahan of a kecamatan, including postal codes.
+func (d *Directory) Villages(districtCode string) ([]Village, error) {
+	ds, ok := d.byCode[districtCode].(*District)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]Village(nil), ds.Villages...), nil
+}
+
+// Normalize checks that every region in the address exists and belongs to
+// its parent, and that the postal code is one used in the kecamatan. Region
+// names are matched case-insensitively and rewritten to their canonical form.
+func (d *Directory) Normalize(addr *domain.Address) error {
+	p := d.findProvince(addr.Provinsi)
+	if p == nil {
+		return fmt.Errorf("unknown provinsi %q", addr.Provinsi)
+	}
+	c := findCity(p, addr.KotaKabupaten)
+	if c == nil {
+		return fmt.Errorf("kota/kabupaten %q is not in %s", addr.KotaKabupaten, p.Name)
+	}
+	ds := findDistrict(c, addr.Kecamatan)
+	if ds == nil {
+		return fmt.Errorf("kecamatan %q is not in %s", addr.Kecamatan, c.Name)
+	}
+	v := findVillage(ds, addr.Kelurahan)
+	if v == nil {
+		return fmt.Errorf("kelurahan %q is not in kecamatan %s", addr.Kelurahan, ds.Name)
+	}
+
+	postalMatch := false
+	for _, village := range ds.Villages {
+		if village.PostalCode == strings.TrimSpace(addr.KodePos) {
+			postalMatch = true
+			break
+		}
+	}
+	if !postalMatch {
+		return fmt.Errorf("kode pos %q does not match kecamatan %s", addr.KodePos, ds.Name)
+	}
+
+	addr.Provinsi = p.Name
+	addr.KotaKabupaten = c.Name
+	addr.Kecamatan = ds.Name
+	addr.Kelurahan = v.Name
+	addr.KodePos = strings.TrimSpace(addr.KodePos)
+	return nil
+}
+
+func (d *Directory) findProvince(name string) *Province {
+	for i := range d.provinces {
+		if sameName(d.provinces[i].Name, name) {
+			return &d.provinces[i]
+		}
+	}
+	return nil
+}
+
+func findCity(p *Province, name string) *City {
+	for i := range p.Cities {
+		if sameName(p.Cities[i].Name, name) {
+			return &p.Cities[i]
+		}
+	}
+	return nil
+}
+
+func findDistrict(c *City, name string) *District {
+	for i := range c.Districts {
+		if sameName(c.Districts[i].Name, name) {
+			return &c.Districts[i]
+		}
+	}
+	return nil
+}
+
+func findVillage(ds *District, name string) *Village {
+	for i := range ds.Villages {
+		if sameName(ds.Villages[i].Name, name) {
+			return &ds.Villages[i]
+		}
+	}
+	return nil
+}
+
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
@@ -0,0 +1,65 @@
+package region
+
+import (
+	"testing"
+
+	"karyawan-app/internal/domain"
+)
+
+func TestCascadingLookup(t *testing.T) {
+	d, err := Default()
+	if err != nil {
+		t.Fatalf("Default() error: %v", err)
+	}
+
+	if len(d.Provinces()) == 0 {
+		t.Fatal("expected provinces in embedded dataset")
+	}
+
+	cities, err := d.Cities("31")
+	if err != nil || len(cities) == 0 {
+		t.Fatalf("Cities(31) = %v, %v", cities, err)
+	}
+
+	districts, err := d.Districts(cities[0].Code)
+	if err != nil || len(districts) == 0 {
+		t.Fatalf("Districts(%s) = %v, %v", cities[0].Code, districts, err)
+	}
+
+	if _, err := d.Villages("99.99.99"); err != ErrNotFound {
+		t.Errorf("Villages(unknown) error = %v, expected ErrNotFound", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	d, err := Default()
+	if err != nil {
+		t.Fatalf("Default() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		addr    domain.Address
+		wantErr bool
+	}{
+		{"valid", domain.Address{Kelurahan: "senayan", Kecamatan: "Kebayoran Baru", KotaKabupaten: "Kota Jakarta Selatan", Provinsi: "dki jakarta", KodePos: "12190"}, false},
+		{"postal code from same kecamatan", domain.Address{Kelurahan: "Senayan", Kecamatan: "Kebayoran Baru", KotaKabupaten: "Kota Jakarta Selatan", Provinsi: "DKI Jakarta", KodePos: "12110"}, false},
+		{"postal code from other kecamatan", domain.Address{Kelurahan: "Senayan", Kecamatan: "Kebayoran Baru", KotaKabupaten: "Kota Jakarta Selatan", Provinsi: "DKI Jakarta", KodePos: "10310"}, true},
+		{"kota in wrong provinsi", domain.Address{Kelurahan: "Dago", Kecamatan: "Coblong", KotaKabupaten: "Kota Bandung", Provinsi: "DKI Jakarta", KodePos: "40135"}, true},
+		{"unknown kelurahan", domain.Address{Kelurahan: "Nowhere", Kecamatan: "Coblong", KotaKabupaten: "Kota Bandung", Provinsi: "Jawa Barat", KodePos: "40135"}, true},
+	}
+
+	for _, test := range tests {
+		addr := test.addr
+		err := d.Normalize(&addr)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: Normalize() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+
+	addr := tests[0].addr
+	d.Normalize(&addr)
+	if addr.Kelurahan != "Senayan" || addr.Provinsi != "DKI Jakarta" {
+		t.Errorf("Normalize() did not canonicalize names: %+v", addr)
+	}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"karyawan-app/internal/domain"
+)
+
+type auditRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewAuditRepository(db *sql.DB, dialect Dialect) domain.AuditRepository {
+	return &auditRepository{db: db, dialect: dialect}
+}
+
+func (r *auditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	query := `INSERT INTO employee_audit_log (employee_id, action, fields) VALUES (?, ?, ?)`
+	id, err := insertReturningID(ctx, r.db, r.dialect, query, entry.EmployeeID, entry.Action, strings.Join(entry.Fields, ","))
+	if err != nil {
+		return err
+	}
+
+	entry.ID = id
+	return nil
+}
+
+func (r *auditRepository) FindByEmployee(ctx context.Context, employeeID int) ([]domain.AuditEntry, error) {
+	return r.query(ctx, `SELECT id, employee_id, action, fields, created_at FROM employee_audit_log WHERE employee_id = ? ORDER BY id`, employeeID)
+}
+
+func (r *auditRepository) FindByEmployees(ctx context.Context, employeeIDs []int) ([]domain.AuditEntry, error) {
+	if len(employeeIDs) == 0 {
+		return []domain.AuditEntry{}, nil
+	}
+	placeholders, args := inList(employeeIDs)
+	return r.query(ctx, `SELECT id, employee_id, action, fields, created_at FROM employee_audit_log WHERE employee_id IN (`+placeholders+`) ORDER BY id`, args...)
+}
+
+func (r *auditRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var e domain.AuditEntry
+		var fields string
+		if err := rows.Scan(&e.ID, &e.EmployeeID, &e.Action, &fields, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if fields != "" {
+			e.Fields = strings.Split(fields, ",")
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"karyawan-app/internal/config"
+)
+
+// Dialect identifies the SQL database a repository talks to. Queries are
+// written with MySQL style "?" placeholders and rebound per dialect.
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// ParseDialect maps a DB_DRIVER value to a Dialect. An empty name selects
+// MySQL.
+func ParseDialect(name string) (Dialect, error) {
+	if driver, ok := config.CanonicalDriver(name); ok {
+		return Dialect(driver), nil
+	}
+	return "", fmt.Errorf("unsupported DB_DRIVER %q (expected mysql, sqlite or postgres)", name)
+}
+
+// DriverName returns the database/sql driver name registered for the
+// dialect.
+func (d Dialect) DriverName() string {
+	switch d {
+	case SQLite:
+		return "sqlite"
+	case Postgres:
+		return "pgx"
+	}
+	return "mysql"
+}
+
+// Rebind rewrites "?" placeholders to the dialect's bind syntax.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lockClause returns the row locking suffix for SELECTs inside a
+// transaction. SQLite locks the whole database on write instead.
+func (d Dialect) lockClause() string {
+	if d == SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// inList returns the placeholders and arguments of an IN (...) list.
+func inList(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
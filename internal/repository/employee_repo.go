@@ -1,23 +1,173 @@
 package repository
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
-	"time"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
 
 	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
 )
 
+const employeeColumns = `id, name, email, position, role, phone, alamat,
+	address_street, address_rt, address_rw, address_kelurahan, address_kecamatan,
+	address_kota, address_provinsi, address_kode_pos, created_at, updated_at, anonymized_at`
+
 type employeeRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
+	keys    *pii.Keyring
+}
+
+// NewEmployeeRepository returns a SQL backed repository for the given
+// dialect. When keys is not nil, email, phone and street address columns
+// are encrypted at rest. Email and phone are looked up through blind
+// indexes either way.
+func NewEmployeeRepository(db *sql.DB, dialect Dialect, keys *pii.Keyring) domain.EmployeeRepository {
+	return &employeeRepository{db: db, dialect: dialect, keys: keys}
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *employeeRepository) scanEmployee(ctx context.Context, row rowScanner) (*domain.Employee, error) {
+	var e domain.Employee
+	var createdAt, updatedAt, anonymizedAt sql.NullTime
+	var street, rt, rw, kelurahan, kecamatan, kota, provinsi, kodePos sql.NullString
+	err := row.Scan(&e.ID, &e.Name, &e.Email, &e.Position, &e.Role, &e.Phone, &e.Alamat,
+		&street, &rt, &rw, &kelurahan, &kecamatan, &kota, &provinsi, &kodePos,
+		&createdAt, &updatedAt, &anonymizedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	e.CreatedAt = createdAt.Time
+	e.UpdatedAt = updatedAt.Time
+	if anonymizedAt.Valid {
+		e.AnonymizedAt = &anonymizedAt.Time
+	}
+
+	// Rows created before structured addresses only have alamat
+	if provinsi.Valid {
+		e.Address = &domain.Address{
+			Street:        street.String,
+			RT:            rt.String,
+			RW:            rw.String,
+			Kelurahan:     kelurahan.String,
+			Kecamatan:     kecamatan.String,
+			KotaKabupaten: kota.String,
+			Provinsi:      provinsi.String,
+			KodePos:       kodePos.String,
+		}
+	}
+
+	if err := r.decrypt(&e); err != nil {
+		// Usually a retired master key missing from the keyring
+		slog.ErrorContext(ctx, "failed to decrypt employee PII", "employee_id", e.ID, "error", err)
+		return nil, err
+	}
+	return &e, nil
+}
+
+// encrypt replaces the PII fields of a copy of employee with ciphertext.
+func (r *employeeRepository) encrypt(employee *domain.Employee) (*domain.Employee, error) {
+	if r.keys == nil {
+		return employee, nil
+	}
+
+	sealed := *employee
+	fields := []*string{&sealed.Email, &sealed.Phone, &sealed.Alamat}
+	if employee.Address != nil {
+		addr := *employee.Address
+		sealed.Address = &addr
+		fields = append(fields, &addr.Street)
+	}
+	for _, f := range fields {
+		v, err := r.keys.Encrypt(*f)
+		if err != nil {
+			return nil, err
+		}
+		*f = v
+	}
+	return &sealed, nil
+}
+
+func (r *employeeRepository) decrypt(employee *domain.Employee) error {
+	if r.keys == nil {
+		return nil
+	}
+
+	fields := []*string{&employee.Email, &employee.Phone, &employee.Alamat}
+	if employee.Address != nil {
+		fields = append(fields, &employee.Address.Street)
+	}
+	for _, f := range fields {
+		v, err := r.keys.Decrypt(*f)
+		if err != nil {
+			return err
+		}
+		*f = v
+	}
+	return nil
+}
+
+// blindIndexes returns the email and phone blind index values.
+func (r *employeeRepository) blindIndexes(employee *domain.Employee) (string, string) {
+	return blindIndex(r.keys, pii.NormalizeEmail(employee.Email)), blindIndex(r.keys, pii.NormalizePhone(employee.Phone))
+}
+
+// blindIndex indexes a normalized value with keys. Without keys it is a
+// plain SHA-256, which hides nothing since the value is stored unencrypted
+// beside it, but keeps email_bidx filled: its unique index is the only one
+// on email.
+func blindIndex(keys *pii.Keyring, value string) string {
+	if keys == nil {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+	return keys.BlindIndex(value)
+}
+
+// dbtx is implemented by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertReturningID runs an INSERT and returns the generated id, using
+// RETURNING on PostgreSQL which has no LastInsertId.
+func insertReturningID(ctx context.Context, db dbtx, d Dialect, query string, args ...interface{}) (int, error) {
+	if d == Postgres {
+		var id int
+		err := db.QueryRowContext(ctx, d.Rebind(query+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
 }
 
-func NewEmployeeRepository(db *sql.DB) domain.EmployeeRepository {
-	return &employeeRepository{db: db}
+// addressArgs returns the address column values, all NULL when the employee
+// has no structured address.
+func addressArgs(a *domain.Address) []interface{} {
+	if a == nil {
+		return []interface{}{nil, nil, nil, nil, nil, nil, nil, nil}
+	}
+	return []interface{}{a.Street, a.RT, a.RW, a.Kelurahan, a.Kecamatan, a.KotaKabupaten, a.Provinsi, a.KodePos}
 }
 
-func (r *employeeRepository) FindAll() ([]domain.Employee, error) {
-	query := `SELECT id, name, email, position, role, phone, alamat, created_at, updated_at FROM employees ORDER BY created_at DESC`
-	rows, err := r.db.Query(query)
+func (r *employeeRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.Employee, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -25,66 +175,389 @@ func (r *employeeRepository) FindAll() ([]domain.Employee, error) {
 
 	var employees []domain.Employee
 	for rows.Next() {
-		var e domain.Employee
-		var createdAtStr, updatedAtStr sql.NullString
-		if err := rows.Scan(&e.ID, &e.Name, &e.Email, &e.Position, &e.Role, &e.Phone, &e.Alamat, &createdAtStr, &updatedAtStr); err != nil {
+		e, err := r.scanEmployee(ctx, rows)
+		if err != nil {
 			return nil, err
 		}
-		// Convert string time to time.Time if needed
-		e.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr.String)
-		if updatedAtStr.Valid {
-			e.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr.String)
+		employees = append(employees, *e)
+	}
+	return employees, rows.Err()
+}
+
+func (r *employeeRepository) FindAll(ctx context.Context) ([]domain.Employee, error) {
+	return r.query(ctx, `SELECT `+employeeColumns+` FROM employees ORDER BY created_at DESC, id DESC`)
+}
+
+func (r *employeeRepository) FindPage(ctx context.Context, beforeID, limit int) ([]domain.Employee, error) {
+	if beforeID <= 0 {
+		return r.query(ctx, `SELECT `+employeeColumns+` FROM employees ORDER BY id DESC LIMIT ?`, limit)
+	}
+	return r.query(ctx, `SELECT `+employeeColumns+` FROM employees WHERE id < ? ORDER BY id DESC LIMIT ?`, beforeID, limit)
+}
+
+func (r *employeeRepository) FindByID(ctx context.Context, id int) (*domain.Employee, error) {
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = ?`
+	e, err := r.scanEmployee(ctx, r.db.QueryRowContext(ctx, r.dialect.Rebind(query), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
-		employees = append(employees, e)
+		return nil, err
 	}
-	return employees, nil
+	return e, nil
 }
 
-func (r *employeeRepository) FindByID(id int) (*domain.Employee, error) {
-	query := `SELECT id, name, email, position, role, phone, alamat, created_at, updated_at FROM employees WHERE id = ?`
-	row := r.db.QueryRow(query, id)
+func (r *employeeRepository) FindByIDs(ctx context.Context, ids []int) ([]domain.Employee, error) {
+	if len(ids) == 0 {
+		return []domain.Employee{}, nil
+	}
+	placeholders, args := inList(ids)
+	return r.query(ctx, `SELECT `+employeeColumns+` FROM employees WHERE id IN (`+placeholders+`)`, args...)
+}
 
-	var e domain.Employee
-	var createdAtStr, updatedAtStr sql.NullString
-	err := row.Scan(&e.ID, &e.Name, &e.Email, &e.Position, &e.Role, &e.Phone, &e.Alamat, &createdAtStr, &updatedAtStr)
+func (r *employeeRepository) FindByEmail(ctx context.Context, email string) (*domain.Employee, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.Rebind(`SELECT `+employeeColumns+` FROM employees WHERE email_bidx = ?`),
+		blindIndex(r.keys, pii.NormalizeEmail(email)))
+	e, err := r.scanEmployee(ctx, row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	return e, nil
+}
+
+// countByEmailsChunk bounds the placeholders of one CountByEmails query,
+// well under SQLite's limit.
+const countByEmailsChunk = 500
 
-	e.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr.String)
-	if updatedAtStr.Valid {
-		e.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr.String)
+func (r *employeeRepository) CountByEmails(ctx context.Context, emails []string) (int, error) {
+	total := 0
+	for len(emails) > 0 {
+		chunk := emails[:min(len(emails), countByEmailsChunk)]
+		emails = emails[len(chunk):]
+		args := make([]interface{}, len(chunk))
+		for i, email := range chunk {
+			args[i] = blindIndex(r.keys, pii.NormalizeEmail(email))
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+		var n int
+		if err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`SELECT COUNT(*) FROM employees WHERE email_bidx IN (`+placeholders+`)`), args...).Scan(&n); err != nil {
+			return 0, err
+		}
+		total += n
 	}
-	return &e, nil
+	return total, nil
 }
 
-func (r *employeeRepository) Create(employee *domain.Employee) error {
-	query := `INSERT INTO employees (name, email, position, role, phone, alamat) VALUES (?, ?, ?, ?, ?, ?)`
-	result, err := r.db.Exec(query, employee.Name, employee.Email, employee.Position, employee.Role, employee.Phone, employee.Alamat)
+func (r *employeeRepository) FindByPhone(ctx context.Context, phone string) ([]domain.Employee, error) {
+	return r.query(ctx, `SELECT `+employeeColumns+` FROM employees WHERE phone_bidx = ? ORDER BY created_at DESC, id DESC`,
+		blindIndex(r.keys, pii.NormalizePhone(phone)))
+}
+
+func (r *employeeRepository) Create(ctx context.Context, employee *domain.Employee) error {
+	return r.insert(ctx, r.db, employee)
+}
+
+func (r *employeeRepository) CreateBatch(ctx context.Context, employees []domain.Employee) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	id, err := result.LastInsertId()
+	ids := make([]int, len(employees))
+	for i := range employees {
+		// IDs are only set once the transaction commits
+		e := employees[i]
+		if err := r.insert(ctx, tx, &e); err != nil {
+			return fmt.Errorf("employee %d of %d: %w", i+1, len(employees), err)
+		}
+		ids[i] = e.ID
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i := range employees {
+		employees[i].ID = ids[i]
+	}
+	return nil
+}
+
+func (r *employeeRepository) insert(ctx context.Context, db dbtx, employee *domain.Employee) error {
+	args, err := r.insertArgs(employee)
+	if err != nil {
+		return err
+	}
+	id, err := insertReturningID(ctx, db, r.dialect, insertEmployeeQuery, args...)
 	if err != nil {
 		return err
 	}
 
-	employee.ID = int(id)
+	employee.ID = id
 	return nil
 }
 
-func (r *employeeRepository) Update(employee *domain.Employee) error {
-	query := `UPDATE employees SET name=?, email=?, position=?, role=?, phone=?, alamat=?, updated_at=NOW() WHERE id=?`
-	_, err := r.db.Exec(query, employee.Name, employee.Email, employee.Position, employee.Role, employee.Phone, employee.Alamat, employee.ID)
+const insertEmployeeQuery = `INSERT INTO employees (name, email, position, role, phone, alamat,
+		address_street, address_rt, address_rw, address_kelurahan, address_kecamatan,
+		address_kota, address_provinsi, address_kode_pos, email_bidx, phone_bidx)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// insertArgs returns the values of insertEmployeeQuery, encrypted.
+func (r *employeeRepository) insertArgs(employee *domain.Employee) ([]interface{}, error) {
+	sealed, err := r.encrypt(employee)
+	if err != nil {
+		return nil, err
+	}
+	emailIdx, phoneIdx := r.blindIndexes(employee)
+
+	args := append([]interface{}{sealed.Name, sealed.Email, sealed.Position, sealed.Role, sealed.Phone, sealed.Alamat},
+		addressArgs(sealed.Address)...)
+	return append(args, emailIdx, phoneIdx), nil
+}
+
+func (r *employeeRepository) Update(ctx context.Context, employee *domain.Employee) error {
+	return r.update(ctx, employee, "")
+}
+
+func (r *employeeRepository) Anonymize(ctx context.Context, employee *domain.Employee) error {
+	return r.update(ctx, employee, ", anonymized_at=CURRENT_TIMESTAMP")
+}
+
+// update writes every column of employee, plus any extra SET assignments.
+func (r *employeeRepository) update(ctx context.Context, employee *domain.Employee, extraSet string) error {
+	args, err := r.updateArgs(employee)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.Rebind(updateEmployeeQuery(extraSet)), args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	// MySQL counts changed rows, not matched ones, so no rows may still
+	// mean an unchanged employee
+	var id int
+	err = r.db.QueryRowContext(ctx, r.dialect.Rebind("SELECT id FROM employees WHERE id = ?"), employee.ID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return domain.ErrEmployeeNotFound
+	}
 	return err
 }
 
-func (r *employeeRepository) Delete(id int) error {
-	query := `DELETE FROM employees WHERE id=?`
-	_, err := r.db.Exec(query, id)
+func updateEmployeeQuery(extraSet string) string {
+	return `UPDATE employees SET name=?, email=?, position=?, role=?, phone=?, alamat=?,
+		address_street=?, address_rt=?, address_rw=?, address_kelurahan=?, address_kecamatan=?,
+		address_kota=?, address_provinsi=?, address_kode_pos=?, email_bidx=?, phone_bidx=?,
+		updated_at=CURRENT_TIMESTAMP` + extraSet + ` WHERE id=?`
+}
+
+// updateArgs returns the values of updateEmployeeQuery, encrypted.
+func (r *employeeRepository) updateArgs(employee *domain.Employee) ([]interface{}, error) {
+	args, err := r.insertArgs(employee)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, employee.ID), nil
+}
+
+const deleteEmployeeQuery = `DELETE FROM employees WHERE id=?`
+
+func (r *employeeRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, r.dialect.Rebind(deleteEmployeeQuery), id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err == nil && n == 0 {
+		return domain.ErrEmployeeNotFound
+	}
 	return err
 }
+
+func (r *employeeRepository) ApplyBatch(ctx context.Context, ops []domain.BulkOperation, atomic bool) ([]error, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	b := &batch{tx: tx, dialect: r.dialect, stmts: make(map[string]*sql.Stmt)}
+	defer b.close()
+
+	errs := make([]error, len(ops))
+	ids := make([]int, len(ops))
+	for i, op := range ops {
+		if atomic {
+			if errs[i] = r.apply(ctx, b, op, &ids[i]); errs[i] != nil {
+				return errs, nil
+			}
+			continue
+		}
+
+		// A failed statement aborts the whole transaction on PostgreSQL
+		// unless it is rolled back to a savepoint
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT bulk_op"); err != nil {
+			return nil, err
+		}
+		errs[i] = r.apply(ctx, b, op, &ids[i])
+		release := "RELEASE SAVEPOINT bulk_op"
+		if errs[i] != nil {
+			release = "ROLLBACK TO SAVEPOINT bulk_op"
+		}
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if op.Op == domain.BulkCreate && errs[i] == nil {
+			op.Employee.ID = ids[i]
+		}
+	}
+	return errs, nil
+}
+
+// apply runs one operation of ApplyBatch, storing the ID of a created
+// employee in id.
+func (r *employeeRepository) apply(ctx context.Context, b *batch, op domain.BulkOperation, id *int) error {
+	switch op.Op {
+	case domain.BulkCreate:
+		args, err := r.insertArgs(op.Employee)
+		if err != nil {
+			return err
+		}
+		*id, err = b.insert(ctx, args...)
+		return err
+	case domain.BulkUpdate:
+		employee := *op.Employee
+		employee.ID = op.ID
+		args, err := r.updateArgs(&employee)
+		if err != nil {
+			return err
+		}
+		// Unlike deletes, the affected row count cannot tell a missing
+		// target: MySQL counts changed rows, not matched ones
+		if err := b.lock(ctx, op.ID); err != nil {
+			return err
+		}
+		_, err = b.exec(ctx, updateEmployeeQuery(""), args...)
+		return err
+	case domain.BulkDelete:
+		n, err := b.exec(ctx, deleteEmployeeQuery, op.ID)
+		if err == nil && n == 0 {
+			return domain.ErrEmployeeNotFound
+		}
+		return err
+	}
+	return fmt.Errorf("unknown bulk operation %q", op.Op)
+}
+
+// batch prepares each statement of a transaction once, on first use, so
+// repeated writes only send their arguments. Creates are not folded into
+// multi-row INSERTs: MySQL reports only the first generated ID of one, and
+// with innodb_autoinc_lock_mode=2 the others need not follow it. One
+// statement per operation also lets each be rolled back to its savepoint
+// and its error reported against it.
+type batch struct {
+	tx      *sql.Tx
+	dialect Dialect
+	stmts   map[string]*sql.Stmt
+}
+
+func (b *batch) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	if stmt, ok := b.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := b.tx.PrepareContext(ctx, b.dialect.Rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	b.stmts[query] = stmt
+	return stmt, nil
+}
+
+// lock locks the employee with the given id until the transaction ends,
+// failing with ErrEmployeeNotFound when there is none.
+func (b *batch) lock(ctx context.Context, id int) error {
+	stmt, err := b.stmt(ctx, "SELECT id FROM employees WHERE id = ?"+b.dialect.lockClause())
+	if err != nil {
+		return err
+	}
+	err = stmt.QueryRowContext(ctx, id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return domain.ErrEmployeeNotFound
+	}
+	return err
+}
+
+// insert runs insertEmployeeQuery and returns the generated id, see
+// insertReturningID.
+func (b *batch) insert(ctx context.Context, args ...interface{}) (int, error) {
+	if b.dialect == Postgres {
+		stmt, err := b.stmt(ctx, insertEmployeeQuery+" RETURNING id")
+		if err != nil {
+			return 0, err
+		}
+		var id int
+		err = stmt.QueryRowContext(ctx, args...).Scan(&id)
+		return id, err
+	}
+
+	stmt, err := b.stmt(ctx, insertEmployeeQuery)
+	if err != nil {
+		return 0, err
+	}
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// exec runs a statement and returns the number of rows it affected.
+func (b *batch) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	stmt, err := b.stmt(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (b *batch) close() {
+	for _, stmt := range b.stmts {
+		stmt.Close()
+	}
+}
+
+func (r *employeeRepository) CountByRole(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT role, COUNT(*) FROM employees GROUP BY role")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var role string
+		var n int
+		if err := rows.Scan(&role, &n); err != nil {
+			return nil, err
+		}
+		counts[role] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+type eventLog struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewEventLog returns the event log in the employee_events table, shared
+// by every server connected to the database.
+func NewEventLog(db *sql.DB, dialect Dialect) domain.EventLog {
+	return &eventLog{db: db, dialect: dialect}
+}
+
+func (l *eventLog) Append(ctx context.Context, event *domain.EmployeeEvent) error {
+	// Stored in UTC with second precision, the format Prune compares with
+	at := time.Now().UTC().Truncate(time.Second)
+	query := `INSERT INTO employee_events (type, employee_id, role, created_at) VALUES (?, ?, ?, ?)`
+	id, err := insertReturningID(ctx, l.db, l.dialect, query, event.Type, event.EmployeeID, event.Role, at)
+	if err != nil {
+		return err
+	}
+
+	event.ID = int64(id)
+	event.At = at
+	return nil
+}
+
+func (l *eventLog) FindAfter(ctx context.Context, afterID int64, limit int) ([]domain.EmployeeEvent, error) {
+	query := `SELECT id, type, employee_id, role, created_at FROM employee_events WHERE id > ? ORDER BY id LIMIT ?`
+	rows, err := l.db.QueryContext(ctx, l.dialect.Rebind(query), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.EmployeeEvent{}
+	for rows.Next() {
+		var e domain.EmployeeEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.EmployeeID, &e.Role, &e.At); err != nil {
+			return nil, err
+		}
+		e.At = e.At.UTC()
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// FindByEmployee is only used for data exports, so it scans the log, which
+// retention keeps short, rather than have every append maintain an index.
+func (l *eventLog) FindByEmployee(ctx context.Context, employeeID int) ([]domain.EmployeeEvent, error) {
+	query := `SELECT id, type, employee_id, role, created_at FROM employee_events WHERE employee_id = ? ORDER BY id`
+	rows, err := l.db.QueryContext(ctx, l.dialect.Rebind(query), employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.EmployeeEvent{}
+	for rows.Next() {
+		var e domain.EmployeeEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.EmployeeID, &e.Role, &e.At); err != nil {
+			return nil, err
+		}
+		e.At = e.At.UTC()
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (l *eventLog) LastID(ctx context.Context) (int64, error) {
+	var id int64
+	err := l.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM employee_events`).Scan(&id)
+	return id, err
+}
+
+func (l *eventLog) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := l.db.ExecContext(ctx, l.dialect.Rebind(`DELETE FROM employee_events WHERE created_at < ?`), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CheckSchemaVersion reports an error unless the newest migration this
+// build knows about has been applied, e.g. when a new release is deployed
+// against a database its migrations have not run on yet.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB, d Dialect) error {
+	var applied int
+	err := db.QueryRowContext(ctx, d.Rebind("SELECT COUNT(*) FROM migrations WHERE version = ?"), SchemaVersion()).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+	if applied == 0 {
+		return fmt.Errorf("schema is behind, expected migration %s", SchemaVersion())
+	}
+	return nil
+}
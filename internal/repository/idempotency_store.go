@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
+)
+
+type idempotencyStore struct {
+	db      *sql.DB
+	dialect Dialect
+	keys    *pii.Keyring
+}
+
+// NewIdempotencyStore returns an idempotency key store in the
+// idempotency_keys table, shared by every server connected to the
+// database. Expiry times are stored as Unix milliseconds. Responses hold
+// employee data, so with keys, which may be nil, they are encrypted like
+// the employees table, and the employees of each are kept in
+// idempotency_key_employees for PurgeEmployee.
+func NewIdempotencyStore(db *sql.DB, dialect Dialect, keys *pii.Keyring) domain.IdempotencyStore {
+	return &idempotencyStore{db: db, dialect: dialect, keys: keys}
+}
+
+func (s *idempotencyStore) insertIgnore() string {
+	if s.dialect == MySQL {
+		return `INSERT IGNORE INTO idempotency_keys (idem_key, fingerprint, expires_at) VALUES (?, ?, ?)`
+	}
+	return `INSERT INTO idempotency_keys (idem_key, fingerprint, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (idem_key) DO NOTHING`
+}
+
+// Reserve relies on the primary key: of concurrent requests with the same
+// key exactly one inserts its row, and the others read it.
+func (s *idempotencyStore) Reserve(ctx context.Context, key, fingerprint string, until time.Time) (*domain.IdempotencyRecord, error) {
+	now := time.Now().UnixMilli()
+	if _, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`DELETE FROM idempotency_keys WHERE idem_key = ? AND expires_at <= ?`), key, now); err != nil {
+		return nil, err
+	}
+	result, err := s.db.ExecContext(ctx, s.dialect.Rebind(s.insertIgnore()), key, fingerprint, until.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 1 {
+		if err == nil {
+			// Drop the employees of the expired response the key held
+			_, err = s.db.ExecContext(ctx, s.dialect.Rebind(
+				`DELETE FROM idempotency_key_employees WHERE idem_key = ?`), key)
+		}
+		return nil, err
+	}
+
+	var record domain.IdempotencyRecord
+	err = s.db.QueryRowContext(ctx, s.dialect.Rebind(
+		`SELECT fingerprint, status, content_type, body FROM idempotency_keys WHERE idem_key = ?`), key).
+		Scan(&record.Fingerprint, &record.Status, &record.ContentType, &record.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released meanwhile; the client may retry
+		return &domain.IdempotencyRecord{Fingerprint: fingerprint}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.keys != nil {
+		body, err := s.keys.Decrypt(string(record.Body))
+		if err != nil {
+			return nil, err
+		}
+		record.Body = []byte(body)
+	}
+	return &record, nil
+}
+
+// Complete stores the response and its employees in one transaction.
+func (s *idempotencyStore) Complete(ctx context.Context, key string, record domain.IdempotencyRecord, expires time.Time) error {
+	body := record.Body
+	if s.keys != nil {
+		sealed, err := s.keys.Encrypt(string(record.Body))
+		if err != nil {
+			return err
+		}
+		body = []byte(sealed)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.dialect.Rebind(
+		`UPDATE idempotency_keys SET status = ?, content_type = ?, body = ?, expires_at = ? WHERE idem_key = ?`),
+		record.Status, record.ContentType, body, expires.UnixMilli(), key); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.dialect.Rebind(
+		`DELETE FROM idempotency_key_employees WHERE idem_key = ?`), key); err != nil {
+		return err
+	}
+	linked := make(map[int]bool, len(record.EmployeeIDs))
+	for _, id := range record.EmployeeIDs {
+		if linked[id] {
+			continue
+		}
+		linked[id] = true
+		if _, err := tx.ExecContext(ctx, s.dialect.Rebind(
+			`INSERT INTO idempotency_key_employees (idem_key, employee_id) VALUES (?, ?)`), key, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Release only frees keys still being processed, which have no employees
+// stored yet.
+func (s *idempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`DELETE FROM idempotency_keys WHERE idem_key = ? AND status = 0`), key)
+	return err
+}
+
+func (s *idempotencyStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	if _, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`DELETE FROM idempotency_key_employees WHERE idem_key IN
+			(SELECT idem_key FROM idempotency_keys WHERE expires_at < ?)`), before.UnixMilli()); err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`DELETE FROM idempotency_keys WHERE expires_at < ?`), before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *idempotencyStore) PurgeEmployee(ctx context.Context, employeeID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, s.dialect.Rebind(
+		`SELECT idem_key FROM idempotency_key_employees WHERE employee_id = ?`), employeeID)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return err
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Keys of bulk responses are linked to other employees as well
+	for _, key := range keys {
+		for _, query := range []string{
+			`DELETE FROM idempotency_keys WHERE idem_key = ?`,
+			`DELETE FROM idempotency_key_employees WHERE idem_key = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, s.dialect.Rebind(query), key); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *idempotencyStore) FindByEmployee(ctx context.Context, employeeID int) ([]domain.StoredResponse, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(
+		`SELECT k.status, k.content_type, k.body, k.expires_at FROM idempotency_keys k
+			JOIN idempotency_key_employees e ON e.idem_key = k.idem_key
+			WHERE e.employee_id = ? AND k.status <> 0 AND k.expires_at > ? ORDER BY k.expires_at`),
+		employeeID, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := []domain.StoredResponse{}
+	for rows.Next() {
+		var (
+			r       domain.StoredResponse
+			body    []byte
+			expires int64
+		)
+		if err := rows.Scan(&r.Status, &r.ContentType, &body, &expires); err != nil {
+			return nil, err
+		}
+		r.Body = string(body)
+		if s.keys != nil {
+			if r.Body, err = s.keys.Decrypt(r.Body); err != nil {
+				return nil, err
+			}
+		}
+		r.ExpiresAt = time.UnixMilli(expires).UTC()
+		responses = append(responses, r)
+	}
+	return responses, rows.Err()
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"karyawan-app/internal/pii"
+)
+
+// RotateEmployeeKeys re-encrypts every employee row whose PII columns are
+// plaintext or sealed under a retired master key, and refreshes the blind
+// indexes. Rows are processed in id order, batchSize rows per transaction,
+// so an interrupted run can simply be restarted. It returns the number of
+// rows rewritten.
+func RotateEmployeeKeys(ctx context.Context, db *sql.DB, dialect Dialect, keys *pii.Keyring, batchSize int, progress func(rotated int)) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	rotated, lastID := 0, 0
+	for {
+		n, nextID, err := rotateBatch(ctx, db, dialect, keys, lastID, batchSize)
+		if err != nil {
+			return rotated, fmt.Errorf("rotating batch after id %d: %w", lastID, err)
+		}
+		rotated += n
+		if progress != nil {
+			progress(rotated)
+		}
+		if nextID == lastID {
+			return rotated, nil
+		}
+		lastID = nextID
+	}
+}
+
+func rotateBatch(ctx context.Context, db *sql.DB, d Dialect, keys *pii.Keyring, afterID, limit int) (int, int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, afterID, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, d.Rebind(`SELECT id, email, phone, alamat, address_street FROM employees
+		WHERE id > ? ORDER BY id LIMIT ?`+d.lockClause()), afterID, limit)
+	if err != nil {
+		return 0, afterID, err
+	}
+
+	type piiRow struct {
+		id                   int
+		email, phone, alamat string
+		street               sql.NullString
+	}
+	var batch []piiRow
+	for rows.Next() {
+		var row piiRow
+		if err := rows.Scan(&row.id, &row.email, &row.phone, &row.alamat, &row.street); err != nil {
+			rows.Close()
+			return 0, afterID, err
+		}
+		batch = append(batch, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, afterID, err
+	}
+
+	lastID, rotated := afterID, 0
+	for _, row := range batch {
+		lastID = row.id
+		if !keys.NeedsRotation(row.email) && !keys.NeedsRotation(row.phone) &&
+			!keys.NeedsRotation(row.alamat) && !keys.NeedsRotation(row.street.String) {
+			continue
+		}
+
+		email, err := keys.Decrypt(row.email)
+		if err != nil {
+			return 0, afterID, fmt.Errorf("employee %d: %w", row.id, err)
+		}
+		phone, err := keys.Decrypt(row.phone)
+		if err != nil {
+			return 0, afterID, fmt.Errorf("employee %d: %w", row.id, err)
+		}
+		alamat, err := keys.Decrypt(row.alamat)
+		if err != nil {
+			return 0, afterID, fmt.Errorf("employee %d: %w", row.id, err)
+		}
+		street, err := keys.Decrypt(row.street.String)
+		if err != nil {
+			return 0, afterID, fmt.Errorf("employee %d: %w", row.id, err)
+		}
+
+		sealed := make([]interface{}, 0, 4)
+		for _, v := range []string{email, phone, alamat} {
+			enc, err := keys.Encrypt(v)
+			if err != nil {
+				return 0, afterID, err
+			}
+			sealed = append(sealed, enc)
+		}
+		var sealedStreet interface{}
+		if row.street.Valid {
+			enc, err := keys.Encrypt(street)
+			if err != nil {
+				return 0, afterID, err
+			}
+			sealedStreet = enc
+		}
+
+		_, err = tx.ExecContext(ctx, d.Rebind(`UPDATE employees SET email=?, phone=?, alamat=?, address_street=?,
+			email_bidx=?, phone_bidx=?, updated_at=updated_at WHERE id=?`),
+			sealed[0], sealed[1], sealed[2], sealedStreet,
+			keys.BlindIndex(pii.NormalizeEmail(email)), keys.BlindIndex(pii.NormalizePhone(phone)), row.id)
+		if err != nil {
+			return 0, afterID, fmt.Errorf("employee %d: %w", row.id, err)
+		}
+		rotated++
+	}
+	return rotated, lastID, tx.Commit()
+}
+
+// BackfillBlindIndexes fills the blind indexes of plaintext employee rows
+// that lack them or, when keys is not nil, were indexed without keys: rows
+// written before the blind index columns existed, by an older release, or
+// before encryption was enabled. Encrypted rows are left to
+// RotateEmployeeKeys. Rows whose email is already indexed for another
+// employee are logged and skipped, since the unique index would reject
+// them; they keep their old index until the duplicate is resolved. It
+// returns the number of rows updated.
+func BackfillBlindIndexes(ctx context.Context, db *sql.DB, dialect Dialect, keys *pii.Keyring) (int, error) {
+	query := `SELECT id, email, phone, email_bidx, phone_bidx FROM employees
+		WHERE (email_bidx IS NULL OR phone_bidx IS NULL) AND id > ? ORDER BY id LIMIT ?`
+	if keys != nil {
+		// Indexes computed without keys must be replaced too
+		query = `SELECT id, email, phone, email_bidx, phone_bidx FROM employees
+			WHERE email NOT LIKE 'enc:%' AND id > ? ORDER BY id LIMIT ?`
+	}
+
+	const limit = 500
+	updated, lastID := 0, 0
+	for {
+		type indexRow struct {
+			id                 int
+			email, phone       string
+			emailIdx, phoneIdx sql.NullString
+		}
+		rows, err := db.QueryContext(ctx, dialect.Rebind(query), lastID, limit)
+		if err != nil {
+			return updated, err
+		}
+		var batch []indexRow
+		for rows.Next() {
+			var row indexRow
+			if err := rows.Scan(&row.id, &row.email, &row.phone, &row.emailIdx, &row.phoneIdx); err != nil {
+				rows.Close()
+				return updated, err
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, err
+		}
+
+		for _, row := range batch {
+			lastID = row.id
+			if pii.IsEncrypted(row.email) || pii.IsEncrypted(row.phone) {
+				continue
+			}
+			emailIdx := blindIndex(keys, pii.NormalizeEmail(row.email))
+			phoneIdx := blindIndex(keys, pii.NormalizePhone(row.phone))
+			if row.emailIdx.String == emailIdx && row.phoneIdx.String == phoneIdx {
+				continue
+			}
+			var other int
+			err := db.QueryRowContext(ctx, dialect.Rebind(
+				`SELECT id FROM employees WHERE email_bidx = ? AND id <> ?`), emailIdx, row.id).Scan(&other)
+			if err == nil {
+				slog.WarnContext(ctx, "skipped blind index of duplicate email", "employee_id", row.id, "duplicate_of", other)
+				continue
+			}
+			if err != sql.ErrNoRows {
+				return updated, err
+			}
+			if _, err := db.ExecContext(ctx, dialect.Rebind(
+				`UPDATE employees SET email_bidx=?, phone_bidx=?, updated_at=updated_at WHERE id=?`),
+				emailIdx, phoneIdx, row.id); err != nil {
+				return updated, fmt.Errorf("employee %d: %w", row.id, err)
+			}
+			updated++
+		}
+		if len(batch) < limit {
+			return updated, nil
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+type idempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	record  domain.IdempotencyRecord
+	expires time.Time
+}
+
+// NewIdempotencyStore returns an in-process idempotency key store. Each
+// replica keeps its own keys.
+func NewIdempotencyStore() domain.IdempotencyStore {
+	return &idempotencyStore{records: make(map[string]idempotencyEntry)}
+}
+
+func (s *idempotencyStore) Reserve(ctx context.Context, key, fingerprint string, until time.Time) (*domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.records[key]; ok && time.Now().Before(entry.expires) {
+		record := entry.record
+		record.Body = append([]byte(nil), record.Body...)
+		record.EmployeeIDs = nil
+		return &record, nil
+	}
+	s.records[key] = idempotencyEntry{record: domain.IdempotencyRecord{Fingerprint: fingerprint}, expires: until}
+	return nil, nil
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, key string, record domain.IdempotencyRecord, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[key]; ok {
+		record.Body = append([]byte(nil), record.Body...)
+		record.EmployeeIDs = append([]int(nil), record.EmployeeIDs...)
+		s.records[key] = idempotencyEntry{record: record, expires: expires}
+	}
+	return nil
+}
+
+func (s *idempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.records[key]; ok && entry.record.Status == 0 {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *idempotencyStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned int64
+	for key, entry := range s.records {
+		if entry.expires.Before(before) {
+			delete(s.records, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+func (s *idempotencyStore) PurgeEmployee(ctx context.Context, employeeID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.records {
+		for _, id := range entry.record.EmployeeIDs {
+			if id == employeeID {
+				delete(s.records, key)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *idempotencyStore) FindByEmployee(ctx context.Context, employeeID int) ([]domain.StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	responses := []domain.StoredResponse{}
+	now := time.Now()
+	for _, entry := range s.records {
+		if entry.record.Status == 0 || !now.Before(entry.expires) {
+			continue
+		}
+		for _, id := range entry.record.EmployeeIDs {
+			if id == employeeID {
+				responses = append(responses, domain.StoredResponse{
+					Status:      entry.record.Status,
+					ContentType: entry.record.ContentType,
+					Body:        string(entry.record.Body),
+					ExpiresAt:   entry.expires.UTC(),
+				})
+				break
+			}
+		}
+	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].ExpiresAt.Before(responses[j].ExpiresAt) })
+	return responses, nil
+}
@@ -0,0 +1,416 @@
+// Package memory provides thread-safe in-memory implementations of the
+// domain repositories, for tests and for running the API without a
+// database.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
+)
+
+type employeeRepository struct {
+	mu        sync.RWMutex
+	nextID    int
+	employees map[int]domain.Employee
+}
+
+func NewEmployeeRepository() domain.EmployeeRepository {
+	return &employeeRepository{nextID: 1, employees: make(map[int]domain.Employee)}
+}
+
+// clone returns a copy that shares no pointers with the stored employee.
+func clone(e domain.Employee) domain.Employee {
+	if e.Address != nil {
+		addr := *e.Address
+		e.Address = &addr
+	}
+	if e.AnonymizedAt != nil {
+		at := *e.AnonymizedAt
+		e.AnonymizedAt = &at
+	}
+	return e
+}
+
+// sorted returns the employees matching keep, newest first.
+func (r *employeeRepository) sorted(keep func(domain.Employee) bool) []domain.Employee {
+	employees := []domain.Employee{}
+	for _, e := range r.employees {
+		if keep(e) {
+			employees = append(employees, clone(e))
+		}
+	}
+	sort.Slice(employees, func(i, j int) bool {
+		if !employees[i].CreatedAt.Equal(employees[j].CreatedAt) {
+			return employees[i].CreatedAt.After(employees[j].CreatedAt)
+		}
+		return employees[i].ID > employees[j].ID
+	})
+	return employees
+}
+
+func (r *employeeRepository) FindAll(ctx context.Context) ([]domain.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sorted(func(domain.Employee) bool { return true }), nil
+}
+
+func (r *employeeRepository) FindPage(ctx context.Context, beforeID, limit int) ([]domain.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	employees := []domain.Employee{}
+	for _, e := range r.employees {
+		if beforeID <= 0 || e.ID < beforeID {
+			employees = append(employees, clone(e))
+		}
+	}
+	sort.Slice(employees, func(i, j int) bool { return employees[i].ID > employees[j].ID })
+	if len(employees) > limit {
+		employees = employees[:limit]
+	}
+	return employees, nil
+}
+
+func (r *employeeRepository) FindByID(ctx context.Context, id int) (*domain.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.employees[id]
+	if !ok {
+		return nil, nil
+	}
+	e = clone(e)
+	return &e, nil
+}
+
+func (r *employeeRepository) FindByIDs(ctx context.Context, ids []int) ([]domain.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	employees := []domain.Employee{}
+	for _, id := range ids {
+		if e, ok := r.employees[id]; ok {
+			employees = append(employees, clone(e))
+		}
+	}
+	return employees, nil
+}
+
+func (r *employeeRepository) FindByEmail(ctx context.Context, email string) (*domain.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.sorted(func(e domain.Employee) bool {
+		return pii.NormalizeEmail(e.Email) == pii.NormalizeEmail(email)
+	})
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return &matches[0], nil
+}
+
+func (r *employeeRepository) CountByEmails(ctx context.Context, emails []string) (int, error) {
+	wanted := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		wanted[pii.NormalizeEmail(email)] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, e := range r.employees {
+		if wanted[pii.NormalizeEmail(e.Email)] {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *employeeRepository) FindByPhone(ctx context.Context, phone string) ([]domain.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted(func(e domain.Employee) bool {
+		return pii.NormalizePhone(e.Phone) == pii.NormalizePhone(phone)
+	}), nil
+}
+
+func (r *employeeRepository) Create(ctx context.Context, employee *domain.Employee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	employee.ID = r.nextID
+	r.nextID++
+	employee.CreatedAt = time.Now().UTC()
+	employee.UpdatedAt = employee.CreatedAt
+	r.employees[employee.ID] = clone(*employee)
+	return nil
+}
+
+func (r *employeeRepository) CreateBatch(ctx context.Context, employees []domain.Employee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for i := range employees {
+		e := &employees[i]
+		e.ID = r.nextID
+		r.nextID++
+		e.CreatedAt = now
+		e.UpdatedAt = now
+		r.employees[e.ID] = clone(*e)
+	}
+	return nil
+}
+
+func (r *employeeRepository) ApplyBatch(ctx context.Context, ops []domain.BulkOperation, atomic bool) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Operations run on a copy that replaces the stored employees once all
+	// of them ran, like a transaction
+	employees := make(map[int]domain.Employee, len(r.employees))
+	for id, e := range r.employees {
+		employees[id] = e
+	}
+	nextID := r.nextID
+	now := time.Now().UTC()
+
+	errs := make([]error, len(ops))
+	ids := make([]int, len(ops))
+	for i, op := range ops {
+		switch op.Op {
+		case domain.BulkCreate:
+			e := clone(*op.Employee)
+			e.ID = nextID
+			nextID++
+			e.CreatedAt = now
+			e.UpdatedAt = now
+			employees[e.ID] = e
+			ids[i] = e.ID
+		case domain.BulkUpdate:
+			existing, ok := employees[op.ID]
+			if !ok {
+				errs[i] = domain.ErrEmployeeNotFound
+				break
+			}
+			e := clone(*op.Employee)
+			e.ID = op.ID
+			e.CreatedAt = existing.CreatedAt
+			e.UpdatedAt = now
+			e.AnonymizedAt = existing.AnonymizedAt
+			employees[op.ID] = e
+		case domain.BulkDelete:
+			if _, ok := employees[op.ID]; !ok {
+				errs[i] = domain.ErrEmployeeNotFound
+			}
+			delete(employees, op.ID)
+		default:
+			errs[i] = fmt.Errorf("unknown bulk operation %q", op.Op)
+		}
+		if errs[i] != nil && atomic {
+			return errs, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.employees = employees
+	r.nextID = nextID
+	for i, op := range ops {
+		if op.Op == domain.BulkCreate && errs[i] == nil {
+			op.Employee.ID = ids[i]
+			op.Employee.CreatedAt = now
+			op.Employee.UpdatedAt = now
+		}
+	}
+	return errs, nil
+}
+
+func (r *employeeRepository) Update(ctx context.Context, employee *domain.Employee) error {
+	return r.update(ctx, employee, false)
+}
+
+func (r *employeeRepository) Anonymize(ctx context.Context, employee *domain.Employee) error {
+	return r.update(ctx, employee, true)
+}
+
+// update mirrors the SQL repositories: unknown ids fail with
+// ErrEmployeeNotFound and created_at is never overwritten.
+func (r *employeeRepository) update(ctx context.Context, employee *domain.Employee, anonymize bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.employees[employee.ID]
+	if !ok {
+		return domain.ErrEmployeeNotFound
+	}
+
+	updated := clone(*employee)
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = time.Now().UTC()
+	updated.AnonymizedAt = existing.AnonymizedAt
+	if anonymize {
+		now := updated.UpdatedAt
+		updated.AnonymizedAt = &now
+	}
+	r.employees[employee.ID] = updated
+	return nil
+}
+
+func (r *employeeRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.employees[id]; !ok {
+		return domain.ErrEmployeeNotFound
+	}
+	delete(r.employees, id)
+	return nil
+}
+
+func (r *employeeRepository) CountByRole(ctx context.Context) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, e := range r.employees {
+		counts[e.Role]++
+	}
+	return counts, nil
+}
+
+type auditRepository struct {
+	mu      sync.RWMutex
+	entries []domain.AuditEntry
+}
+
+func NewAuditRepository() domain.AuditRepository {
+	return &auditRepository{}
+}
+
+func (r *auditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = len(r.entries) + 1
+	entry.CreatedAt = time.Now().UTC()
+	stored := *entry
+	stored.Fields = append([]string(nil), entry.Fields...)
+	r.entries = append(r.entries, stored)
+	return nil
+}
+
+func (r *auditRepository) FindByEmployee(ctx context.Context, employeeID int) ([]domain.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []domain.AuditEntry{}
+	for _, e := range r.entries {
+		if e.EmployeeID == employeeID {
+			e.Fields = append([]string(nil), e.Fields...)
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *auditRepository) FindByEmployees(ctx context.Context, employeeIDs []int) ([]domain.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(employeeIDs))
+	for _, id := range employeeIDs {
+		wanted[id] = true
+	}
+	entries := []domain.AuditEntry{}
+	for _, e := range r.entries {
+		if wanted[e.EmployeeID] {
+			e.Fields = append([]string(nil), e.Fields...)
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+type eventLog struct {
+	mu     sync.RWMutex
+	lastID int64
+	events []domain.EmployeeEvent
+}
+
+func NewEventLog() domain.EventLog {
+	return &eventLog{}
+}
+
+func (l *eventLog) Append(ctx context.Context, event *domain.EmployeeEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastID++
+	event.ID = l.lastID
+	event.At = time.Now().UTC()
+	stored := *event
+	stored.Employee = nil
+	l.events = append(l.events, stored)
+	return nil
+}
+
+func (l *eventLog) FindAfter(ctx context.Context, afterID int64, limit int) ([]domain.EmployeeEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	// Events are appended in ID order
+	i := sort.Search(len(l.events), func(i int) bool { return l.events[i].ID > afterID })
+	events := []domain.EmployeeEvent{}
+	for ; i < len(l.events) && len(events) < limit; i++ {
+		events = append(events, l.events[i])
+	}
+	return events, nil
+}
+
+func (l *eventLog) FindByEmployee(ctx context.Context, employeeID int) ([]domain.EmployeeEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	events := []domain.EmployeeEvent{}
+	for _, e := range l.events {
+		if e.EmployeeID == employeeID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (l *eventLog) LastID(ctx context.Context) (int64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.events) == 0 {
+		return 0, nil
+	}
+	return l.events[len(l.events)-1].ID, nil
+}
+
+func (l *eventLog) Prune(ctx context.Context, before time.Time) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := l.events[:0]
+	for _, e := range l.events {
+		if !e.At.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	pruned := int64(len(l.events) - len(kept))
+	l.events = kept
+	return pruned, nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/repository/repotest"
+)
+
+var ctx = context.Background()
+
+func TestEmployeeRepositoryConformance(t *testing.T) {
+	repotest.RunEmployeeRepositoryTests(t, func(t *testing.T) domain.EmployeeRepository {
+		return NewEmployeeRepository()
+	})
+}
+
+func TestAuditRepositoryConformance(t *testing.T) {
+	repotest.RunAuditRepositoryTests(t, func(t *testing.T) domain.AuditRepository {
+		return NewAuditRepository()
+	})
+}
+
+func TestEventLogConformance(t *testing.T) {
+	repotest.RunEventLogTests(t, func(t *testing.T) domain.EventLog {
+		return NewEventLog()
+	})
+}
+
+func TestRateLimitStoreConformance(t *testing.T) {
+	repotest.RunRateLimitStoreTests(t, func(t *testing.T) domain.RateLimitStore {
+		return NewRateLimitStore()
+	})
+}
+
+func TestIdempotencyStoreConformance(t *testing.T) {
+	repotest.RunIdempotencyStoreTests(t, func(t *testing.T) domain.IdempotencyStore {
+		return NewIdempotencyStore()
+	})
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	r := NewEmployeeRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r.Create(ctx, &domain.Employee{Name: "Siti", Email: fmt.Sprintf("siti%d@example.com", i)})
+			r.FindAll(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	all, _ := r.FindAll(ctx)
+	if len(all) != 50 {
+		t.Errorf("FindAll() returned %d employees, expected 50", len(all))
+	}
+}
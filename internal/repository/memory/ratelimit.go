@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"karyawan-app/internal/domain"
+)
+
+// rateLimitIdle is how long a bucket may go unused before Cleanup evicts
+// it. It must exceed the window so evicted buckets were full anyway.
+const rateLimitIdle = 3 * time.Minute
+
+type rateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*rateLimitBucket
+}
+
+type rateLimitBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimitStore returns an in-process rate limit store using token
+// buckets that hold limit tokens and refill over the window. Each replica
+// counts separately.
+func NewRateLimitStore() domain.RateLimitStore {
+	return &rateLimitStore{buckets: make(map[string]*rateLimitBucket)}
+}
+
+func (s *rateLimitStore) Take(ctx context.Context, key string, limit int, window time.Duration) (domain.RateLimitResult, error) {
+	now := time.Now()
+	every := window / time.Duration(limit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok || bucket.limiter.Burst() != limit {
+		bucket = &rateLimitBucket{limiter: rate.NewLimiter(rate.Every(every), limit)}
+		s.buckets[key] = bucket
+	}
+	bucket.lastSeen = now
+
+	result := domain.RateLimitResult{Allowed: bucket.limiter.AllowN(now, 1)}
+	tokens := math.Max(0, bucket.limiter.TokensAt(now))
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = time.Duration((float64(limit) - tokens) * float64(every))
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(every))
+	}
+	return result, nil
+}
+
+// Cleanup evicts buckets idle for more than three minutes, once a minute,
+// until ctx is cancelled.
+func (s *rateLimitStore) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			for key, bucket := range s.buckets {
+				if time.Since(bucket.lastSeen) > rateLimitIdle {
+					delete(s.buckets, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// migration is one schema change, written once per dialect. Versions are
+// recorded in the migrations table and must never be renumbered.
+type migration struct {
+	version     string
+	description string
+	statements  map[Dialect][]string
+}
+
+var migrations = []migration{
+	{
+		version:     "001_create_employees",
+		description: "Create employees table",
+		statements: map[Dialect][]string{
+			MySQL: {`CREATE TABLE IF NOT EXISTS employees (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				email VARCHAR(100) NOT NULL UNIQUE,
+				position VARCHAR(100) NOT NULL DEFAULT '',
+				role VARCHAR(50) NOT NULL,
+				phone VARCHAR(20) NOT NULL,
+				alamat TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+				// Tables created before position was part of the schema
+				"ALTER TABLE employees ADD COLUMN position VARCHAR(100) NOT NULL DEFAULT '' AFTER email",
+			},
+			SQLite: {`CREATE TABLE IF NOT EXISTS employees (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				email TEXT NOT NULL,
+				position TEXT NOT NULL DEFAULT '',
+				role TEXT NOT NULL,
+				phone TEXT NOT NULL,
+				alamat TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`},
+			Postgres: {`CREATE TABLE IF NOT EXISTS employees (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				email VARCHAR(100) NOT NULL,
+				position VARCHAR(100) NOT NULL DEFAULT '',
+				role VARCHAR(50) NOT NULL,
+				phone VARCHAR(20) NOT NULL,
+				alamat TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`},
+		},
+	},
+	{
+		version:     "002_structured_address",
+		description: "Add structured address columns",
+		statements: map[Dialect][]string{
+			MySQL: {
+				"ALTER TABLE employees ADD COLUMN address_street VARCHAR(255) NULL",
+				"ALTER TABLE employees ADD COLUMN address_rt VARCHAR(3) NULL",
+				"ALTER TABLE employees ADD COLUMN address_rw VARCHAR(3) NULL",
+				"ALTER TABLE employees ADD COLUMN address_kelurahan VARCHAR(100) NULL",
+				"ALTER TABLE employees ADD COLUMN address_kecamatan VARCHAR(100) NULL",
+				"ALTER TABLE employees ADD COLUMN address_kota VARCHAR(100) NULL",
+				"ALTER TABLE employees ADD COLUMN address_provinsi VARCHAR(100) NULL",
+				"ALTER TABLE employees ADD COLUMN address_kode_pos VARCHAR(5) NULL",
+				"CREATE INDEX idx_employees_address_kota ON employees(address_kota)",
+				"CREATE INDEX idx_employees_address_provinsi ON employees(address_provinsi)",
+			},
+			SQLite: {
+				"ALTER TABLE employees ADD COLUMN address_street TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_rt TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_rw TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_kelurahan TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_kecamatan TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_kota TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_provinsi TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN address_kode_pos TEXT NULL",
+				"CREATE INDEX IF NOT EXISTS idx_employees_address_kota ON employees(address_kota)",
+				"CREATE INDEX IF NOT EXISTS idx_employees_address_provinsi ON employees(address_provinsi)",
+			},
+			Postgres: {
+				`ALTER TABLE employees
+					ADD COLUMN IF NOT EXISTS address_street VARCHAR(255) NULL,
+					ADD COLUMN IF NOT EXISTS address_rt VARCHAR(3) NULL,
+					ADD COLUMN IF NOT EXISTS address_rw VARCHAR(3) NULL,
+					ADD COLUMN IF NOT EXISTS address_kelurahan VARCHAR(100) NULL,
+					ADD COLUMN IF NOT EXISTS address_kecamatan VARCHAR(100) NULL,
+					ADD COLUMN IF NOT EXISTS address_kota VARCHAR(100) NULL,
+					ADD COLUMN IF NOT EXISTS address_provinsi VARCHAR(100) NULL,
+					ADD COLUMN IF NOT EXISTS address_kode_pos VARCHAR(5) NULL`,
+				"CREATE INDEX IF NOT EXISTS idx_employees_address_kota ON employees(address_kota)",
+				"CREATE INDEX IF NOT EXISTS idx_employees_address_provinsi ON employees(address_provinsi)",
+			},
+		},
+	},
+	{
+		version:     "003_encrypt_pii",
+		description: "Widen PII columns for ciphertext and add blind indexes",
+		statements: map[Dialect][]string{
+			MySQL: {
+				"ALTER TABLE employees MODIFY COLUMN email VARCHAR(512) NOT NULL",
+				"ALTER TABLE employees MODIFY COLUMN phone VARCHAR(512) NOT NULL",
+				"ALTER TABLE employees MODIFY COLUMN address_street TEXT NULL",
+				"ALTER TABLE employees DROP INDEX email",
+				"ALTER TABLE employees ADD COLUMN email_bidx CHAR(64) NULL",
+				"ALTER TABLE employees ADD COLUMN phone_bidx CHAR(64) NULL",
+				"CREATE UNIQUE INDEX idx_employees_email_bidx ON employees(email_bidx)",
+				"CREATE INDEX idx_employees_phone_bidx ON employees(phone_bidx)",
+			},
+			SQLite: {
+				"ALTER TABLE employees ADD COLUMN email_bidx TEXT NULL",
+				"ALTER TABLE employees ADD COLUMN phone_bidx TEXT NULL",
+				"CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_email_bidx ON employees(email_bidx)",
+				"CREATE INDEX IF NOT EXISTS idx_employees_phone_bidx ON employees(phone_bidx)",
+			},
+			Postgres: {
+				`ALTER TABLE employees
+					ALTER COLUMN email TYPE VARCHAR(512),
+					ALTER COLUMN phone TYPE VARCHAR(512),
+					ALTER COLUMN address_street TYPE TEXT,
+					ADD COLUMN IF NOT EXISTS email_bidx CHAR(64) NULL,
+					ADD COLUMN IF NOT EXISTS phone_bidx CHAR(64) NULL`,
+				"CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_email_bidx ON employees(email_bidx)",
+				"CREATE INDEX IF NOT EXISTS idx_employees_phone_bidx ON employees(phone_bidx)",
+			},
+		},
+	},
+	{
+		version:     "004_audit_log",
+		description: "Create employee audit log and add anonymized_at",
+		statements: map[Dialect][]string{
+			MySQL: {
+				`CREATE TABLE IF NOT EXISTS employee_audit_log (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					employee_id INT NOT NULL,
+					action VARCHAR(20) NOT NULL,
+					fields TEXT NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					INDEX idx_employee_audit_log_employee (employee_id)
+				) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+				"ALTER TABLE employees ADD COLUMN anonymized_at TIMESTAMP NULL DEFAULT NULL",
+			},
+			SQLite: {
+				`CREATE TABLE IF NOT EXISTS employee_audit_log (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					employee_id INTEGER NOT NULL,
+					action TEXT NOT NULL,
+					fields TEXT NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_employee_audit_log_employee ON employee_audit_log(employee_id)",
+				"ALTER TABLE employees ADD COLUMN anonymized_at DATETIME NULL",
+			},
+			Postgres: {
+				`CREATE TABLE IF NOT EXISTS employee_audit_log (
+					id SERIAL PRIMARY KEY,
+					employee_id INT NOT NULL,
+					action VARCHAR(20) NOT NULL,
+					fields TEXT NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_employee_audit_log_employee ON employee_audit_log(employee_id)",
+				"ALTER TABLE employees ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP NULL",
+			},
+		},
+	},
+	{
+		version:     "005_rate_limit_counters",
+		description: "Create shared rate limit counters",
+		statements: map[Dialect][]string{
+			MySQL: {`CREATE TABLE IF NOT EXISTS rate_limit_counters (
+				bucket VARCHAR(255) NOT NULL,
+				window_start BIGINT NOT NULL,
+				count INT NOT NULL DEFAULT 0,
+				PRIMARY KEY (bucket, window_start),
+				INDEX idx_rate_limit_counters_window (window_start)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`},
+			SQLite: {
+				`CREATE TABLE IF NOT EXISTS rate_limit_counters (
+					bucket TEXT NOT NULL,
+					window_start INTEGER NOT NULL,
+					count INTEGER NOT NULL DEFAULT 0,
+					PRIMARY KEY (bucket, window_start)
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_window ON rate_limit_counters(window_start)",
+			},
+			Postgres: {
+				`CREATE TABLE IF NOT EXISTS rate_limit_counters (
+					bucket VARCHAR(255) NOT NULL,
+					window_start BIGINT NOT NULL,
+					count INT NOT NULL DEFAULT 0,
+					PRIMARY KEY (bucket, window_start)
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_window ON rate_limit_counters(window_start)",
+			},
+		},
+	},
+	{
+		version:     "006_employee_events",
+		description: "Create employee event log",
+		statements: map[Dialect][]string{
+			MySQL: {`CREATE TABLE IF NOT EXISTS employee_events (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				type VARCHAR(20) NOT NULL,
+				employee_id INT NOT NULL,
+				role VARCHAR(50) NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				INDEX idx_employee_events_created_at (created_at)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`},
+			SQLite: {
+				`CREATE TABLE IF NOT EXISTS employee_events (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					type TEXT NOT NULL,
+					employee_id INTEGER NOT NULL,
+					role TEXT NOT NULL,
+					created_at DATETIME NOT NULL
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_employee_events_created_at ON employee_events(created_at)",
+			},
+			Postgres: {
+				`CREATE TABLE IF NOT EXISTS employee_events (
+					id BIGSERIAL PRIMARY KEY,
+					type VARCHAR(20) NOT NULL,
+					employee_id INT NOT NULL,
+					role VARCHAR(50) NOT NULL,
+					created_at TIMESTAMP NOT NULL
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_employee_events_created_at ON employee_events(created_at)",
+			},
+		},
+	},
+	{
+		version:     "007_idempotency_keys",
+		description: "Create idempotency key store",
+		statements: map[Dialect][]string{
+			MySQL: {`CREATE TABLE IF NOT EXISTS idempotency_keys (
+				idem_key VARCHAR(64) NOT NULL PRIMARY KEY,
+				fingerprint VARCHAR(64) NOT NULL,
+				status INT NOT NULL DEFAULT 0,
+				content_type VARCHAR(100) NOT NULL DEFAULT '',
+				body MEDIUMBLOB,
+				expires_at BIGINT NOT NULL,
+				INDEX idx_idempotency_keys_expires_at (expires_at)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`},
+			SQLite: {
+				`CREATE TABLE IF NOT EXISTS idempotency_keys (
+					idem_key TEXT NOT NULL PRIMARY KEY,
+					fingerprint TEXT NOT NULL,
+					status INTEGER NOT NULL DEFAULT 0,
+					content_type TEXT NOT NULL DEFAULT '',
+					body BLOB,
+					expires_at INTEGER NOT NULL
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at)",
+			},
+			Postgres: {
+				`CREATE TABLE IF NOT EXISTS idempotency_keys (
+					idem_key VARCHAR(64) NOT NULL PRIMARY KEY,
+					fingerprint VARCHAR(64) NOT NULL,
+					status INT NOT NULL DEFAULT 0,
+					content_type VARCHAR(100) NOT NULL DEFAULT '',
+					body BYTEA,
+					expires_at BIGINT NOT NULL
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at)",
+			},
+		},
+	},
+	{
+		version:     "008_idempotency_key_employees",
+		description: "Link idempotency keys to the employees in their responses",
+		statements: map[Dialect][]string{
+			MySQL: {`CREATE TABLE IF NOT EXISTS idempotency_key_employees (
+				idem_key VARCHAR(64) NOT NULL,
+				employee_id INT NOT NULL,
+				PRIMARY KEY (idem_key, employee_id),
+				INDEX idx_idempotency_key_employees_employee_id (employee_id)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`},
+			SQLite: {
+				`CREATE TABLE IF NOT EXISTS idempotency_key_employees (
+					idem_key TEXT NOT NULL,
+					employee_id INTEGER NOT NULL,
+					PRIMARY KEY (idem_key, employee_id)
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_idempotency_key_employees_employee_id ON idempotency_key_employees(employee_id)",
+			},
+			Postgres: {
+				`CREATE TABLE IF NOT EXISTS idempotency_key_employees (
+					idem_key VARCHAR(64) NOT NULL,
+					employee_id INT NOT NULL,
+					PRIMARY KEY (idem_key, employee_id)
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_idempotency_key_employees_employee_id ON idempotency_key_employees(employee_id)",
+			},
+		},
+	},
+}
+
+// migrationsTable has the same shape as the table created by the legacy
+// config.ConnectDB, so both can share a MySQL database.
+var migrationsTable = map[Dialect]string{
+	MySQL: `CREATE TABLE IF NOT EXISTS migrations (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		version VARCHAR(50) NOT NULL UNIQUE,
+		description TEXT,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	SQLite: `CREATE TABLE IF NOT EXISTS migrations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version TEXT NOT NULL UNIQUE,
+		description TEXT,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	Postgres: `CREATE TABLE IF NOT EXISTS migrations (
+		id SERIAL PRIMARY KEY,
+		version VARCHAR(50) NOT NULL UNIQUE,
+		description TEXT,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+// Migrate applies every pending migration for the dialect, recording each
+// one in the migrations table.
+func Migrate(db *sql.DB, d Dialect) error {
+	if _, err := db.Exec(migrationsTable[d]); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	applied, err := appliedVersions(db, d)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		for _, stmt := range m.statements[d] {
+			if _, err := db.Exec(stmt); err != nil && !isAlreadyAppliedError(d, err) {
+				return fmt.Errorf("migration %s failed: %w", m.version, err)
+			}
+		}
+		if _, err := db.Exec(d.Rebind("INSERT INTO migrations (version, description) VALUES (?, ?)"), m.version, m.description); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", m.version, err)
+		}
+		slog.Info("applied migration", "version", m.version, "description", m.description)
+	}
+	return nil
+}
+
+// SchemaVersion returns the latest migration version this build knows
+// about, which is what Migrate brings a database up to.
+func SchemaVersion() string {
+	return migrations[len(migrations)-1].version
+}
+
+func appliedVersions(db *sql.DB, d Dialect) (map[string]bool, error) {
+	rows, err := db.Query("SELECT version FROM migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// isAlreadyAppliedError reports whether err is MySQL's duplicate column
+// (1060), duplicate key name (1061) or can't drop missing key (1091) error.
+// MySQL has no IF [NOT] EXISTS for these statements, and databases set up
+// by earlier releases may already have some of the changes.
+func isAlreadyAppliedError(d Dialect, err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if d != MySQL || !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case 1060, 1061, 1091:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// rateLimitCleanupInterval is how often expired counters are deleted.
+const rateLimitCleanupInterval = time.Minute
+
+type rateLimitStore struct {
+	db      *sql.DB
+	dialect Dialect
+	// maxWindow is the longest window seen by Take, in milliseconds, so
+	// Cleanup knows which counters can no longer be read.
+	maxWindow atomic.Int64
+}
+
+// NewRateLimitStore returns a rate limit store in the rate_limit_counters
+// table, shared by every server connected to the database. It implements a
+// sliding window by weighing the previous fixed window's count by how much
+// of it still overlaps the window ending now.
+func NewRateLimitStore(db *sql.DB, dialect Dialect) domain.RateLimitStore {
+	return &rateLimitStore{db: db, dialect: dialect}
+}
+
+func (s *rateLimitStore) increment() string {
+	if s.dialect == MySQL {
+		return `INSERT INTO rate_limit_counters (bucket, window_start, count) VALUES (?, ?, 1)
+			ON DUPLICATE KEY UPDATE count = count + 1`
+	}
+	return `INSERT INTO rate_limit_counters (bucket, window_start, count) VALUES (?, ?, 1)
+		ON CONFLICT (bucket, window_start) DO UPDATE SET count = rate_limit_counters.count + 1`
+}
+
+// Take increments the counter first and gives the request back when it is
+// over the limit, so concurrent replicas can never admit more than limit
+// requests between them; at worst both reject near the boundary.
+func (s *rateLimitStore) Take(ctx context.Context, key string, limit int, window time.Duration) (domain.RateLimitResult, error) {
+	size := window.Milliseconds()
+	if size > s.maxWindow.Load() {
+		s.maxWindow.Store(size)
+	}
+	now := time.Now().UnixMilli()
+	start := now - now%size
+	elapsed := now - start
+
+	if _, err := s.db.ExecContext(ctx, s.dialect.Rebind(s.increment()), key, start); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(
+		`SELECT window_start, count FROM rate_limit_counters WHERE bucket = ? AND window_start IN (?, ?)`),
+		key, start, start-size)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	var current, previous float64
+	for rows.Next() {
+		var windowStart int64
+		var count int
+		if err := rows.Scan(&windowStart, &count); err != nil {
+			rows.Close()
+			return domain.RateLimitResult{}, err
+		}
+		if windowStart == start {
+			current = float64(count)
+		} else {
+			previous = float64(count)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	weight := 1 - float64(elapsed)/float64(size)
+	result := domain.RateLimitResult{
+		Allowed: previous*weight+current <= float64(limit),
+		Reset:   time.Duration(size-elapsed) * time.Millisecond,
+	}
+	if !result.Allowed {
+		if _, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+			`UPDATE rate_limit_counters SET count = count - 1 WHERE bucket = ? AND window_start = ?`), key, start); err != nil {
+			return domain.RateLimitResult{}, err
+		}
+		current--
+		result.RetryAfter = retryAfter(previous, current, float64(limit), float64(elapsed), float64(size))
+	}
+	result.Remaining = int(math.Max(0, math.Floor(float64(limit)-(previous*weight+current))))
+	return result, nil
+}
+
+// retryAfter returns how long until previous*weight+current+1 <= limit,
+// with times in milliseconds. Once the current window ends its count
+// becomes the previous one and starts decaying in turn.
+func retryAfter(previous, current, limit, elapsed, size float64) time.Duration {
+	var wait float64
+	if room := limit - 1 - current; room >= 0 {
+		// The previous window's weight has to fall to room/previous
+		wait = size*(1-room/previous) - elapsed
+	} else {
+		wait = size - elapsed + size*(1-(limit-1)/current)
+	}
+	return time.Duration(math.Max(wait, 0)) * time.Millisecond
+}
+
+// Cleanup deletes counters of windows no longer read, once a minute, until
+// ctx is cancelled.
+func (s *rateLimitStore) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			size := s.maxWindow.Load()
+			if size == 0 {
+				continue
+			}
+			cutoff := time.Now().UnixMilli() - 2*size
+			if _, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+				`DELETE FROM rate_limit_counters WHERE window_start < ?`), cutoff); err != nil && ctx.Err() == nil {
+				slog.Warn("failed to delete expired rate limit counters", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "karyawan-app/internal/database" // registers the SQL drivers
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
+	repo "karyawan-app/internal/repository"
+	"karyawan-app/internal/repository/repotest"
+)
+
+var ctx = context.Background()
+
+// openTestDB returns a migrated, empty database for the dialect. SQLite
+// always runs against a temporary file; MySQL and PostgreSQL run only when
+// TEST_MYSQL_DSN or TEST_POSTGRES_DSN point at a disposable database.
+func openTestDB(t *testing.T, dialect repo.Dialect) *sql.DB {
+	t.Helper()
+
+	var dsn string
+	switch dialect {
+	case repo.SQLite:
+		dsn = "file:" + filepath.Join(t.TempDir(), "test.db")
+	case repo.MySQL:
+		dsn = os.Getenv("TEST_MYSQL_DSN")
+	case repo.Postgres:
+		dsn = os.Getenv("TEST_POSTGRES_DSN")
+	}
+	if dsn == "" {
+		t.Skipf("no test database configured for %s", dialect)
+	}
+
+	db, err := sql.Open(dialect.DriverName(), dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := repo.Migrate(db, dialect); err != nil {
+		t.Fatalf("Migrate() error: %v", err)
+	}
+	for _, table := range []string{"employees", "employee_audit_log", "rate_limit_counters", "employee_events", "idempotency_keys", "idempotency_key_employees"} {
+		if _, err := db.Exec("DELETE FROM " + table); err != nil {
+			t.Fatalf("failed to clear %s: %v", table, err)
+		}
+	}
+	return db
+}
+
+func testKeyring(t *testing.T) *pii.Keyring {
+	t.Helper()
+	keys, err := pii.NewKeyring("test", map[string][]byte{"test": bytes.Repeat([]byte{1}, 32)}, bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring() error: %v", err)
+	}
+	return keys
+}
+
+func runConformance(t *testing.T, dialect repo.Dialect) {
+	t.Run("Plaintext", func(t *testing.T) {
+		repotest.RunEmployeeRepositoryTests(t, func(t *testing.T) domain.EmployeeRepository {
+			return repo.NewEmployeeRepository(openTestDB(t, dialect), dialect, nil)
+		})
+	})
+	t.Run("Encrypted", func(t *testing.T) {
+		repotest.RunEmployeeRepositoryTests(t, func(t *testing.T) domain.EmployeeRepository {
+			return repo.NewEmployeeRepository(openTestDB(t, dialect), dialect, testKeyring(t))
+		})
+	})
+	t.Run("Audit", func(t *testing.T) {
+		repotest.RunAuditRepositoryTests(t, func(t *testing.T) domain.AuditRepository {
+			return repo.NewAuditRepository(openTestDB(t, dialect), dialect)
+		})
+	})
+	t.Run("EventLog", func(t *testing.T) {
+		repotest.RunEventLogTests(t, func(t *testing.T) domain.EventLog {
+			return repo.NewEventLog(openTestDB(t, dialect), dialect)
+		})
+	})
+	t.Run("RateLimitStore", func(t *testing.T) {
+		repotest.RunRateLimitStoreTests(t, func(t *testing.T) domain.RateLimitStore {
+			return repo.NewRateLimitStore(openTestDB(t, dialect), dialect)
+		})
+	})
+	t.Run("IdempotencyStore", func(t *testing.T) {
+		repotest.RunIdempotencyStoreTests(t, func(t *testing.T) domain.IdempotencyStore {
+			return repo.NewIdempotencyStore(openTestDB(t, dialect), dialect, nil)
+		})
+	})
+	t.Run("EncryptedIdempotencyStore", func(t *testing.T) {
+		repotest.RunIdempotencyStoreTests(t, func(t *testing.T) domain.IdempotencyStore {
+			return repo.NewIdempotencyStore(openTestDB(t, dialect), dialect, testKeyring(t))
+		})
+	})
+}
+
+func TestSQLiteRepository(t *testing.T)   { runConformance(t, repo.SQLite) }
+func TestMySQLRepository(t *testing.T)    { runConformance(t, repo.MySQL) }
+func TestPostgresRepository(t *testing.T) { runConformance(t, repo.Postgres) }
+
+func TestRateLimitStoreIsShared(t *testing.T) {
+	db := openTestDB(t, repo.SQLite)
+	// Two replicas pointing at the same database
+	a := repo.NewRateLimitStore(db, repo.SQLite)
+	b := repo.NewRateLimitStore(db, repo.SQLite)
+
+	for i, s := range []domain.RateLimitStore{a, b, a} {
+		res, err := s.Take(ctx, "ip:10.0.0.1", 2, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("Take() error: %v", err)
+		}
+		if expected := i < 2; res.Allowed != expected {
+			t.Errorf("request %d: Allowed = %v, expected %v", i+1, res.Allowed, expected)
+		}
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openTestDB(t, repo.SQLite)
+	if err := repo.Migrate(db, repo.SQLite); err != nil {
+		t.Fatalf("second Migrate() error: %v", err)
+	}
+
+	var version string
+	if err := db.QueryRow("SELECT version FROM migrations ORDER BY id DESC LIMIT 1").Scan(&version); err != nil {
+		t.Fatalf("failed to read migrations: %v", err)
+	}
+	if version != repo.SchemaVersion() {
+		t.Errorf("latest migration = %s, expected %s", version, repo.SchemaVersion())
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	db := openTestDB(t, repo.SQLite)
+	if err := repo.CheckSchemaVersion(ctx, db, repo.SQLite); err != nil {
+		t.Fatalf("CheckSchemaVersion() after Migrate error: %v", err)
+	}
+
+	if _, err := db.Exec("DELETE FROM migrations WHERE version = ?", repo.SchemaVersion()); err != nil {
+		t.Fatalf("failed to remove migration: %v", err)
+	}
+	if err := repo.CheckSchemaVersion(ctx, db, repo.SQLite); err == nil {
+		t.Error("CheckSchemaVersion() on an outdated schema should fail")
+	}
+}
+
+func TestRotateEmployeeKeys(t *testing.T) {
+	db := openTestDB(t, repo.SQLite)
+
+	// Rows written before encryption was enabled
+	plain := repo.NewEmployeeRepository(db, repo.SQLite, nil)
+	for i := 0; i < 5; i++ {
+		e := &domain.Employee{Name: "Budi", Email: "budi" + string(rune('a'+i)) + "@example.com",
+			Position: "Staff", Role: "HR", Phone: "0812000000" + string(rune('0'+i)), Alamat: "Jl. Melawai No. 2"}
+		if err := plain.Create(ctx, e); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+	}
+
+	keys := testKeyring(t)
+	rotated, err := repo.RotateEmployeeKeys(ctx, db, repo.SQLite, keys, 2, nil)
+	if err != nil || rotated != 5 {
+		t.Fatalf("RotateEmployeeKeys() = %d, %v, expected 5 rows", rotated, err)
+	}
+
+	var email string
+	if err := db.QueryRow("SELECT email FROM employees LIMIT 1").Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+	if !pii.IsEncrypted(email) {
+		t.Errorf("email not encrypted after rotation: %q", email)
+	}
+
+	encrypted := repo.NewEmployeeRepository(db, repo.SQLite, keys)
+	got, err := encrypted.FindByEmail(ctx, "budic@example.com")
+	if err != nil || got == nil || got.Phone != "08120000002" {
+		t.Errorf("FindByEmail() after rotation = %+v, %v", got, err)
+	}
+
+	if again, err := repo.RotateEmployeeKeys(ctx, db, repo.SQLite, keys, 2, nil); err != nil || again != 0 {
+		t.Errorf("second RotateEmployeeKeys() = %d, %v, expected no work", again, err)
+	}
+}
+
+func TestIdempotentResponsesAreEncrypted(t *testing.T) {
+	db := openTestDB(t, repo.SQLite)
+	store := repo.NewIdempotencyStore(db, repo.SQLite, testKeyring(t))
+	until := time.Now().Add(time.Minute)
+	if _, err := store.Reserve(ctx, "k1", "fp1", until); err != nil {
+		t.Fatalf("Reserve() error: %v", err)
+	}
+	body := `{"id":1,"email":"dewi@example.com"}`
+	stored := domain.IdempotencyRecord{Fingerprint: "fp1", Status: 201, Body: []byte(body), EmployeeIDs: []int{1}}
+	if err := store.Complete(ctx, "k1", stored, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	var raw []byte
+	if err := db.QueryRow("SELECT body FROM idempotency_keys WHERE idem_key = ?", "k1").Scan(&raw); err != nil {
+		t.Fatalf("failed to read stored body: %v", err)
+	}
+	if !pii.IsEncrypted(string(raw)) {
+		t.Errorf("stored body = %s, expected ciphertext", raw)
+	}
+	record, err := store.Reserve(ctx, "k1", "fp1", until)
+	if err != nil || record == nil || string(record.Body) != body {
+		t.Errorf("Reserve() = %+v, %v, expected the body decrypted", record, err)
+	}
+}
+
+func TestBackfillBlindIndexes(t *testing.T) {
+	db := openTestDB(t, repo.SQLite)
+
+	plain := repo.NewEmployeeRepository(db, repo.SQLite, nil)
+	e := &domain.Employee{Name: "Budi", Email: "budi@example.com", Position: "Staff", Role: "HR",
+		Phone: "081200000000", Alamat: "Jl. Melawai No. 2"}
+	if err := plain.Create(ctx, e); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	dup := *e
+	dup.ID, dup.Email = 0, "BUDI@example.com"
+	if err := plain.Create(ctx, &dup); err == nil {
+		t.Error("Create() without keys accepted an email differing only in case")
+	}
+
+	// A row written by an older release
+	if _, err := db.Exec("UPDATE employees SET email_bidx = NULL, phone_bidx = NULL"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.BackfillBlindIndexes(ctx, db, repo.SQLite, nil); err != nil || n != 1 {
+		t.Fatalf("BackfillBlindIndexes() = %d, %v, expected 1 row", n, err)
+	}
+	if got, err := plain.FindByEmail(ctx, "Budi@Example.com"); err != nil || got == nil || got.ID != e.ID {
+		t.Errorf("FindByEmail() after backfill = %+v, %v", got, err)
+	}
+
+	// Encryption enabled later: the plaintext row is indexed with the keys
+	keys := testKeyring(t)
+	if n, err := repo.BackfillBlindIndexes(ctx, db, repo.SQLite, keys); err != nil || n != 1 {
+		t.Fatalf("BackfillBlindIndexes() with keys = %d, %v, expected 1 row", n, err)
+	}
+	encrypted := repo.NewEmployeeRepository(db, repo.SQLite, keys)
+	if got, err := encrypted.FindByPhone(ctx, "+6281200000000"); err != nil || len(got) != 1 {
+		t.Errorf("FindByPhone() after backfill = %+v, %v", got, err)
+	}
+	if n, err := repo.BackfillBlindIndexes(ctx, db, repo.SQLite, keys); err != nil || n != 0 {
+		t.Errorf("second BackfillBlindIndexes() = %d, %v, expected no work", n, err)
+	}
+
+	// A duplicate written without a blind index is skipped, not fatal
+	if _, err := db.Exec(`INSERT INTO employees (name, email, position, role, phone, alamat)
+		VALUES ('Budi', 'BUDI@example.com', 'Staff', 'HR', '081200000001', 'Jl. Melawai No. 2')`); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.BackfillBlindIndexes(ctx, db, repo.SQLite, keys); err != nil || n != 0 {
+		t.Errorf("BackfillBlindIndexes() with a duplicate email = %d, %v, expected it skipped", n, err)
+	}
+}
@@ -0,0 +1,725 @@
+// Package repotest is the conformance suite every domain.EmployeeRepository,
+// domain.AuditRepository, domain.EventLog, domain.RateLimitStore and
+// domain.IdempotencyStore implementation must pass.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+var ctx = context.Background()
+
+func newEmployee(n int) *domain.Employee {
+	return &domain.Employee{
+		Name:     fmt.Sprintf("Karyawan %d", n),
+		Email:    fmt.Sprintf("karyawan%d@example.com", n),
+		Position: "Software Engineer",
+		Role:     "Developer",
+		Phone:    fmt.Sprintf("08123456%04d", n),
+		Alamat:   "Jl. Sudirman No. 1, Jakarta Selatan",
+	}
+}
+
+// RunEmployeeRepositoryTests runs the suite. newRepo must return a
+// repository backed by an empty store for every call.
+func RunEmployeeRepositoryTests(t *testing.T, newRepo func(t *testing.T) domain.EmployeeRepository) {
+	t.Run("CreateAndFindByID", func(t *testing.T) {
+		r := newRepo(t)
+		e := newEmployee(1)
+		e.Address = &domain.Address{
+			Street: "Jl. Asia Afrika No. 8", RT: "001", RW: "002",
+			Kelurahan: "Senayan", Kecamatan: "Kebayoran Baru", KotaKabupaten: "Kota Jakarta Selatan",
+			Provinsi: "DKI Jakarta", KodePos: "12190",
+		}
+		if err := r.Create(ctx, e); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		if e.ID == 0 {
+			t.Fatal("Create() did not assign an ID")
+		}
+
+		got, err := r.FindByID(ctx, e.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID(%d) = %v, %v", e.ID, got, err)
+		}
+		if got.Name != e.Name || got.Email != e.Email || got.Position != e.Position || got.Role != e.Role ||
+			got.Phone != e.Phone || got.Alamat != e.Alamat {
+			t.Errorf("FindByID() = %+v, expected %+v", got, e)
+		}
+		if got.Address == nil || *got.Address != *e.Address {
+			t.Errorf("FindByID() address = %+v, expected %+v", got.Address, e.Address)
+		}
+		if got.CreatedAt.IsZero() {
+			t.Error("FindByID() returned zero created_at")
+		}
+		if got.AnonymizedAt != nil {
+			t.Error("new employee should not be anonymized")
+		}
+	})
+
+	t.Run("FindByIDMissing", func(t *testing.T) {
+		r := newRepo(t)
+		got, err := r.FindByID(ctx, 424242)
+		if err != nil || got != nil {
+			t.Errorf("FindByID(missing) = %v, %v, expected nil, nil", got, err)
+		}
+	})
+
+	t.Run("FindAllNewestFirst", func(t *testing.T) {
+		r := newRepo(t)
+		if got, err := r.FindAll(ctx); err != nil || len(got) != 0 {
+			t.Fatalf("FindAll() on empty store = %v, %v", got, err)
+		}
+
+		var ids []int
+		for i := 1; i <= 3; i++ {
+			e := newEmployee(i)
+			if err := r.Create(ctx, e); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+			ids = append(ids, e.ID)
+		}
+
+		got, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("FindAll() error: %v", err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("FindAll() returned %d employees, expected 3", len(got))
+		}
+		if got[0].ID != ids[2] || got[2].ID != ids[0] {
+			t.Errorf("FindAll() order = %d,%d,%d, expected newest first", got[0].ID, got[1].ID, got[2].ID)
+		}
+	})
+
+	t.Run("FindByIDs", func(t *testing.T) {
+		r := newRepo(t)
+		if got, err := r.FindByIDs(ctx, nil); err != nil || len(got) != 0 {
+			t.Fatalf("FindByIDs(nil) = %v, %v", got, err)
+		}
+
+		var ids []int
+		for i := 1; i <= 3; i++ {
+			e := newEmployee(i)
+			if err := r.Create(ctx, e); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+			ids = append(ids, e.ID)
+		}
+		got, err := r.FindByIDs(ctx, []int{ids[2], ids[0], 424242})
+		if err != nil {
+			t.Fatalf("FindByIDs() error: %v", err)
+		}
+		emails := make(map[int]string)
+		for _, e := range got {
+			emails[e.ID] = e.Email
+		}
+		if len(got) != 2 || emails[ids[0]] != "karyawan1@example.com" || emails[ids[2]] != "karyawan3@example.com" {
+			t.Errorf("FindByIDs() = %v, expected employees 1 and 3 only", emails)
+		}
+	})
+
+	t.Run("FindPage", func(t *testing.T) {
+		r := newRepo(t)
+		var ids []int
+		for i := 1; i <= 5; i++ {
+			e := newEmployee(i)
+			if err := r.Create(ctx, e); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+			ids = append(ids, e.ID)
+		}
+
+		first, err := r.FindPage(ctx, 0, 2)
+		if err != nil {
+			t.Fatalf("FindPage(0, 2) error: %v", err)
+		}
+		if len(first) != 2 || first[0].ID != ids[4] || first[1].ID != ids[3] {
+			t.Fatalf("FindPage(0, 2) = %d employees, expected IDs %d,%d", len(first), ids[4], ids[3])
+		}
+		if first[0].Email != "karyawan5@example.com" {
+			t.Errorf("FindPage() email = %q", first[0].Email)
+		}
+
+		rest, err := r.FindPage(ctx, first[1].ID, 10)
+		if err != nil {
+			t.Fatalf("FindPage(%d, 10) error: %v", first[1].ID, err)
+		}
+		if len(rest) != 3 || rest[0].ID != ids[2] || rest[2].ID != ids[0] {
+			t.Errorf("FindPage(%d, 10) returned %d employees, expected IDs %d..%d", first[1].ID, len(rest), ids[2], ids[0])
+		}
+
+		if got, err := r.FindPage(ctx, ids[0], 10); err != nil || len(got) != 0 {
+			t.Errorf("FindPage() past the oldest = %v, %v", got, err)
+		}
+	})
+
+	t.Run("CreateBatch", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.CreateBatch(ctx, nil); err != nil {
+			t.Fatalf("CreateBatch(nil) error: %v", err)
+		}
+
+		batch := make([]domain.Employee, 3)
+		for i := range batch {
+			batch[i] = *newEmployee(i + 1)
+		}
+		if err := r.CreateBatch(ctx, batch); err != nil {
+			t.Fatalf("CreateBatch() error: %v", err)
+		}
+		seen := make(map[int]bool)
+		for _, e := range batch {
+			if e.ID == 0 || seen[e.ID] {
+				t.Fatalf("CreateBatch() assigned IDs %d, %d, %d", batch[0].ID, batch[1].ID, batch[2].ID)
+			}
+			seen[e.ID] = true
+			got, err := r.FindByID(ctx, e.ID)
+			if err != nil || got == nil || got.Email != e.Email {
+				t.Errorf("FindByID(%d) = %+v, %v, expected %s", e.ID, got, err, e.Email)
+			}
+		}
+	})
+
+	t.Run("ApplyBatch", func(t *testing.T) {
+		r := newRepo(t)
+		kept, removed := newEmployee(1), newEmployee(2)
+		if err := r.Create(ctx, kept); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		if err := r.Create(ctx, removed); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+
+		created := newEmployee(3)
+		changed := *kept
+		changed.Name = "Nama Baru"
+		ops := []domain.BulkOperation{
+			{Op: domain.BulkCreate, Employee: created},
+			{Op: domain.BulkUpdate, ID: kept.ID, Employee: &changed},
+			{Op: domain.BulkDelete, ID: removed.ID},
+		}
+		errs, err := r.ApplyBatch(ctx, ops, true)
+		if err != nil {
+			t.Fatalf("ApplyBatch() error: %v", err)
+		}
+		for i, err := range errs {
+			if err != nil {
+				t.Errorf("ApplyBatch() operation %d error: %v", i, err)
+			}
+		}
+		if created.ID == 0 {
+			t.Fatal("ApplyBatch() did not set the ID of the created employee")
+		}
+		if got, err := r.FindByID(ctx, created.ID); err != nil || got == nil || got.Email != created.Email {
+			t.Errorf("FindByID(%d) = %+v, %v, expected %s", created.ID, got, err, created.Email)
+		}
+		if got, err := r.FindByID(ctx, kept.ID); err != nil || got == nil || got.Name != "Nama Baru" {
+			t.Errorf("FindByID(%d) = %+v, %v, expected the update", kept.ID, got, err)
+		}
+		if got, err := r.FindByID(ctx, removed.ID); err != nil || got != nil {
+			t.Errorf("FindByID(%d) after delete = %+v, %v", removed.ID, got, err)
+		}
+	})
+
+	t.Run("ApplyBatchFailures", func(t *testing.T) {
+		for _, atomic := range []bool{true, false} {
+			t.Run(fmt.Sprintf("atomic=%t", atomic), func(t *testing.T) {
+				r := newRepo(t)
+				first, second := newEmployee(1), newEmployee(2)
+				ops := []domain.BulkOperation{
+					{Op: domain.BulkCreate, Employee: first},
+					{Op: domain.BulkDelete, ID: 999999},
+					{Op: domain.BulkCreate, Employee: second},
+					{Op: domain.BulkUpdate, ID: 999999, Employee: newEmployee(3)},
+				}
+				errs, err := r.ApplyBatch(ctx, ops, atomic)
+				if err != nil {
+					t.Fatalf("ApplyBatch() error: %v", err)
+				}
+				if len(errs) != len(ops) || !errors.Is(errs[1], domain.ErrEmployeeNotFound) {
+					t.Fatalf("ApplyBatch() = %v, expected ErrEmployeeNotFound for operation 1", errs)
+				}
+				if !atomic && !errors.Is(errs[3], domain.ErrEmployeeNotFound) {
+					t.Errorf("ApplyBatch() update of an unknown ID = %v, expected ErrEmployeeNotFound", errs[3])
+				}
+
+				all, err := r.FindAll(ctx)
+				if err != nil {
+					t.Fatalf("FindAll() error: %v", err)
+				}
+				if atomic {
+					if len(all) != 0 || first.ID != 0 {
+						t.Errorf("atomic ApplyBatch() committed %d employees, first ID %d", len(all), first.ID)
+					}
+					return
+				}
+				if errs[0] != nil || errs[2] != nil || len(all) != 2 {
+					t.Errorf("best effort ApplyBatch() = %v with %d employees stored, expected the creates applied", errs, len(all))
+				}
+				if first.ID == 0 || second.ID == 0 {
+					t.Errorf("best effort ApplyBatch() set IDs %d and %d", first.ID, second.ID)
+				}
+			})
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := newRepo(t)
+		e := newEmployee(1)
+		e.Address = &domain.Address{Street: "Jl. Braga", Kelurahan: "Braga", Kecamatan: "Sumur Bandung",
+			KotaKabupaten: "Kota Bandung", Provinsi: "Jawa Barat", KodePos: "40111"}
+		if err := r.Create(ctx, e); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+
+		e.Name = "Nama Baru"
+		e.Email = "baru@example.com"
+		e.Address = nil
+		if err := r.Update(ctx, e); err != nil {
+			t.Fatalf("Update() error: %v", err)
+		}
+
+		got, err := r.FindByID(ctx, e.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID() = %v, %v", got, err)
+		}
+		if got.Name != "Nama Baru" || got.Email != "baru@example.com" {
+			t.Errorf("Update() not persisted: %+v", got)
+		}
+		if got.Address != nil {
+			t.Errorf("Update() should clear address, got %+v", got.Address)
+		}
+
+		if err := r.Update(ctx, got); err != nil {
+			t.Errorf("Update() without changes error: %v", err)
+		}
+		missing := newEmployee(2)
+		missing.ID = 999999
+		if err := r.Update(ctx, missing); !errors.Is(err, domain.ErrEmployeeNotFound) {
+			t.Errorf("Update() of an unknown ID = %v, expected ErrEmployeeNotFound", err)
+		}
+	})
+
+	t.Run("CountByEmails", func(t *testing.T) {
+		r := newRepo(t)
+		for i := 1; i <= 3; i++ {
+			if err := r.Create(ctx, newEmployee(i)); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+		}
+		if n, err := r.CountByEmails(ctx, nil); err != nil || n != 0 {
+			t.Errorf("CountByEmails(nil) = %d, %v", n, err)
+		}
+		emails := []string{"KARYAWAN1@example.com", "karyawan3@example.com", "karyawan9@example.com"}
+		for i := 0; i < 600; i++ {
+			// Spans several queries of the SQL repositories
+			emails = append(emails, fmt.Sprintf("lain%d@example.com", i))
+		}
+		if n, err := r.CountByEmails(ctx, emails); err != nil || n != 2 {
+			t.Errorf("CountByEmails() = %d, %v, expected 2", n, err)
+		}
+	})
+
+	t.Run("FindByEmailAndPhone", func(t *testing.T) {
+		r := newRepo(t)
+		a, b := newEmployee(1), newEmployee(2)
+		b.Phone = a.Phone
+		for _, e := range []*domain.Employee{a, b} {
+			if err := r.Create(ctx, e); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+		}
+
+		got, err := r.FindByEmail(ctx, b.Email)
+		if err != nil || got == nil || got.ID != b.ID {
+			t.Errorf("FindByEmail(%q) = %v, %v", b.Email, got, err)
+		}
+		if got, err := r.FindByEmail(ctx, "nobody@example.com"); err != nil || got != nil {
+			t.Errorf("FindByEmail(missing) = %v, %v, expected nil, nil", got, err)
+		}
+
+		byPhone, err := r.FindByPhone(ctx, a.Phone)
+		if err != nil || len(byPhone) != 2 {
+			t.Errorf("FindByPhone(%q) = %d results, %v, expected 2", a.Phone, len(byPhone), err)
+		}
+	})
+
+	t.Run("Anonymize", func(t *testing.T) {
+		r := newRepo(t)
+		e := newEmployee(1)
+		if err := r.Create(ctx, e); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+
+		e.Name = "Anonymized Employee"
+		e.Phone = ""
+		if err := r.Anonymize(ctx, e); err != nil {
+			t.Fatalf("Anonymize() error: %v", err)
+		}
+
+		got, err := r.FindByID(ctx, e.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID() = %v, %v", got, err)
+		}
+		if got.AnonymizedAt == nil || got.Name != "Anonymized Employee" || got.Phone != "" {
+			t.Errorf("Anonymize() not persisted: %+v", got)
+		}
+	})
+
+	t.Run("CountByRole", func(t *testing.T) {
+		r := newRepo(t)
+		for i, role := range []string{"Developer", "HR", "Developer"} {
+			e := newEmployee(i)
+			e.Role = role
+			if err := r.Create(ctx, e); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+		}
+		counts, err := r.CountByRole(ctx)
+		if err != nil {
+			t.Fatalf("CountByRole() error: %v", err)
+		}
+		if len(counts) != 2 || counts["Developer"] != 2 || counts["HR"] != 1 {
+			t.Errorf("CountByRole() = %v", counts)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r := newRepo(t)
+		e := newEmployee(1)
+		if err := r.Create(ctx, e); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		if err := r.Delete(ctx, e.ID); err != nil {
+			t.Fatalf("Delete() error: %v", err)
+		}
+		if got, err := r.FindByID(ctx, e.ID); err != nil || got != nil {
+			t.Errorf("FindByID() after Delete = %v, %v", got, err)
+		}
+		if err := r.Delete(ctx, e.ID); !errors.Is(err, domain.ErrEmployeeNotFound) {
+			t.Errorf("second Delete() = %v, expected ErrEmployeeNotFound", err)
+		}
+	})
+}
+
+// RunAuditRepositoryTests runs the audit log suite. newRepo must return a
+// repository backed by an empty store for every call.
+func RunAuditRepositoryTests(t *testing.T, newRepo func(t *testing.T) domain.AuditRepository) {
+	r := newRepo(t)
+
+	entries := []domain.AuditEntry{
+		{EmployeeID: 1, Action: domain.AuditCreate},
+		{EmployeeID: 2, Action: domain.AuditCreate},
+		{EmployeeID: 1, Action: domain.AuditUpdate, Fields: []string{"name", "email"}},
+	}
+	for i := range entries {
+		if err := r.Record(ctx, &entries[i]); err != nil {
+			t.Fatalf("Record() error: %v", err)
+		}
+		if entries[i].ID == 0 {
+			t.Fatal("Record() did not assign an ID")
+		}
+	}
+
+	got, err := r.FindByEmployee(ctx, 1)
+	if err != nil {
+		t.Fatalf("FindByEmployee() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("FindByEmployee(1) returned %d entries, expected 2", len(got))
+	}
+	if got[0].Action != domain.AuditCreate || got[1].Action != domain.AuditUpdate {
+		t.Errorf("FindByEmployee() order = %s,%s", got[0].Action, got[1].Action)
+	}
+	if len(got[1].Fields) != 2 || got[1].Fields[0] != "name" {
+		t.Errorf("FindByEmployee() fields = %v", got[1].Fields)
+	}
+	if got[0].CreatedAt.IsZero() {
+		t.Error("FindByEmployee() returned zero created_at")
+	}
+
+	if none, err := r.FindByEmployee(ctx, 99); err != nil || len(none) != 0 {
+		t.Errorf("FindByEmployee(99) = %v, %v, expected empty", none, err)
+	}
+
+	got, err = r.FindByEmployees(ctx, []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("FindByEmployees() error: %v", err)
+	}
+	if len(got) != 3 || got[0].ID != entries[0].ID || got[1].EmployeeID != 2 || got[2].Action != domain.AuditUpdate {
+		t.Errorf("FindByEmployees() = %+v, expected every entry oldest first", got)
+	}
+	if got, err := r.FindByEmployees(ctx, nil); err != nil || len(got) != 0 {
+		t.Errorf("FindByEmployees(nil) = %v, %v", got, err)
+	}
+}
+
+// RunEventLogTests runs the event log suite. newLog must return a log
+// backed by an empty store for every call.
+func RunEventLogTests(t *testing.T, newLog func(t *testing.T) domain.EventLog) {
+	l := newLog(t)
+
+	appended := []domain.EmployeeEvent{
+		{Type: domain.EventCreated, EmployeeID: 1, Role: "Developer", Employee: newEmployee(1)},
+		{Type: domain.EventUpdated, EmployeeID: 1, Role: "HR"},
+		{Type: domain.EventDeleted, EmployeeID: 2, Role: "Developer"},
+	}
+	for i := range appended {
+		if err := l.Append(ctx, &appended[i]); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+		if appended[i].ID == 0 || appended[i].At.IsZero() {
+			t.Fatalf("Append() did not set ID and At: %+v", appended[i])
+		}
+		if i > 0 && appended[i].ID <= appended[i-1].ID {
+			t.Fatalf("Append() IDs %d, %d do not increase", appended[i-1].ID, appended[i].ID)
+		}
+	}
+
+	if last, err := l.LastID(ctx); err != nil || last != appended[2].ID {
+		t.Errorf("LastID() = %d, %v, expected %d", last, err, appended[2].ID)
+	}
+
+	got, err := l.FindAfter(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("FindAfter() error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("FindAfter(0) returned %d events, expected 3", len(got))
+	}
+	for i, e := range got {
+		want := appended[i]
+		if e.ID != want.ID || e.Type != want.Type || e.EmployeeID != want.EmployeeID || e.Role != want.Role {
+			t.Errorf("event %d = %+v, expected %+v", i, e, want)
+		}
+		if e.Employee != nil {
+			t.Errorf("event %d holds the employee record", i)
+		}
+	}
+
+	if got, err := l.FindByEmployee(ctx, 1); err != nil || len(got) != 2 || got[0].ID != appended[0].ID || got[1].ID != appended[1].ID {
+		t.Errorf("FindByEmployee(1) = %+v, %v, expected the first two events", got, err)
+	}
+
+	got, err = l.FindAfter(ctx, appended[0].ID, 1)
+	if err != nil || len(got) != 1 || got[0].ID != appended[1].ID {
+		t.Errorf("FindAfter(first, 1) = %+v, %v, expected the second event", got, err)
+	}
+	if got, err := l.FindAfter(ctx, appended[2].ID, 10); err != nil || len(got) != 0 {
+		t.Errorf("FindAfter(last) = %+v, %v, expected none", got, err)
+	}
+
+	if n, err := l.Prune(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("Prune(an hour ago) = %d, %v, expected nothing pruned", n, err)
+	}
+	if n, err := l.Prune(ctx, time.Now().Add(time.Hour)); err != nil || n != 3 {
+		t.Errorf("Prune(in an hour) = %d, %v, expected 3", n, err)
+	}
+	if got, err := l.FindAfter(ctx, 0, 10); err != nil || len(got) != 0 {
+		t.Errorf("FindAfter() after Prune = %+v, %v, expected none", got, err)
+	}
+	if last, err := l.LastID(ctx); err != nil || last != 0 {
+		t.Errorf("LastID() of an empty log = %d, %v, expected 0", last, err)
+	}
+
+	// IDs are not reused once pruned, or resuming watchers would skip
+	// events
+	next := domain.EmployeeEvent{Type: domain.EventCreated, EmployeeID: 3, Role: "HR"}
+	if err := l.Append(ctx, &next); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if next.ID <= appended[2].ID {
+		t.Errorf("Append() after Prune assigned ID %d, expected above %d", next.ID, appended[2].ID)
+	}
+}
+
+// RunIdempotencyStoreTests runs the idempotency key suite. newStore must
+// return a store backed by an empty table for every call.
+func RunIdempotencyStoreTests(t *testing.T, newStore func(t *testing.T) domain.IdempotencyStore) {
+	t.Run("ReserveAndReplay", func(t *testing.T) {
+		s := newStore(t)
+		until := time.Now().Add(time.Minute)
+		if record, err := s.Reserve(ctx, "k1", "fp1", until); err != nil || record != nil {
+			t.Fatalf("first Reserve() = %+v, %v, expected the key", record, err)
+		}
+
+		// A concurrent retry sees the request in progress
+		record, err := s.Reserve(ctx, "k1", "fp1", until)
+		if err != nil || record == nil || record.Status != 0 || record.Fingerprint != "fp1" {
+			t.Fatalf("Reserve() while in progress = %+v, %v", record, err)
+		}
+
+		stored := domain.IdempotencyRecord{Fingerprint: "fp1", Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+		if err := s.Complete(ctx, "k1", stored, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Complete() error: %v", err)
+		}
+		record, err = s.Reserve(ctx, "k1", "fp2", until)
+		if err != nil || record == nil {
+			t.Fatalf("Reserve() after Complete = %+v, %v", record, err)
+		}
+		if record.Fingerprint != "fp1" || record.Status != 201 || record.ContentType != "application/json" || string(record.Body) != `{"id":1}` {
+			t.Errorf("Reserve() after Complete = %+v, expected %+v", record, stored)
+		}
+
+		// Completed keys are not released
+		if err := s.Release(ctx, "k1"); err != nil {
+			t.Fatalf("Release() error: %v", err)
+		}
+		if record, err := s.Reserve(ctx, "k1", "fp1", until); err != nil || record == nil || record.Status != 201 {
+			t.Errorf("Reserve() after releasing a completed key = %+v, %v", record, err)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		s := newStore(t)
+		until := time.Now().Add(time.Minute)
+		if _, err := s.Reserve(ctx, "k1", "fp1", until); err != nil {
+			t.Fatalf("Reserve() error: %v", err)
+		}
+		if err := s.Release(ctx, "k1"); err != nil {
+			t.Fatalf("Release() error: %v", err)
+		}
+		if record, err := s.Reserve(ctx, "k1", "fp2", until); err != nil || record != nil {
+			t.Errorf("Reserve() after Release = %+v, %v, expected the key", record, err)
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		s := newStore(t)
+		if _, err := s.Reserve(ctx, "expired", "fp1", time.Now().Add(time.Minute)); err != nil {
+			t.Fatalf("Reserve() error: %v", err)
+		}
+		stored := domain.IdempotencyRecord{Fingerprint: "fp1", Status: 200, Body: []byte("{}")}
+		if err := s.Complete(ctx, "expired", stored, time.Now().Add(-time.Second)); err != nil {
+			t.Fatalf("Complete() error: %v", err)
+		}
+		if _, err := s.Reserve(ctx, "live", "fp1", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Reserve() error: %v", err)
+		}
+
+		pruned, err := s.Prune(ctx, time.Now())
+		if err != nil || pruned != 1 {
+			t.Errorf("Prune() = %d, %v, expected 1", pruned, err)
+		}
+		if record, err := s.Reserve(ctx, "expired", "fp2", time.Now().Add(time.Minute)); err != nil || record != nil {
+			t.Errorf("Reserve() of an expired key = %+v, %v, expected the key", record, err)
+		}
+		if record, err := s.Reserve(ctx, "live", "fp1", time.Now().Add(time.Minute)); err != nil || record == nil {
+			t.Errorf("Reserve() of a live key = %+v, %v, expected it held", record, err)
+		}
+	})
+
+	t.Run("PurgeEmployee", func(t *testing.T) {
+		s := newStore(t)
+		until := time.Now().Add(time.Minute)
+		for key, ids := range map[string][]int{"single": {1}, "bulk": {1, 2}, "other": {3}} {
+			if _, err := s.Reserve(ctx, key, "fp1", until); err != nil {
+				t.Fatalf("Reserve() error: %v", err)
+			}
+			stored := domain.IdempotencyRecord{Fingerprint: "fp1", Status: 201, Body: []byte("{}"), EmployeeIDs: ids}
+			if err := s.Complete(ctx, key, stored, time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("Complete() error: %v", err)
+			}
+		}
+
+		if got, err := s.FindByEmployee(ctx, 1); err != nil || len(got) != 2 || got[0].Status != 201 || got[0].Body != "{}" {
+			t.Errorf("FindByEmployee(1) = %+v, %v, expected the single and bulk responses", got, err)
+		}
+		if err := s.PurgeEmployee(ctx, 1); err != nil {
+			t.Fatalf("PurgeEmployee() error: %v", err)
+		}
+		if got, err := s.FindByEmployee(ctx, 1); err != nil || len(got) != 0 {
+			t.Errorf("FindByEmployee(1) after PurgeEmployee = %+v, %v", got, err)
+		}
+		for _, key := range []string{"single", "bulk"} {
+			if record, err := s.Reserve(ctx, key, "fp2", until); err != nil || record != nil {
+				t.Errorf("Reserve(%q) after PurgeEmployee = %+v, %v, expected the key", key, record, err)
+			}
+		}
+		if record, err := s.Reserve(ctx, "other", "fp2", until); err != nil || record == nil || record.Status != 201 {
+			t.Errorf("Reserve() of another employee's key = %+v, %v, expected it kept", record, err)
+		}
+
+		// The purged bulk key no longer belongs to employee 2
+		stored := domain.IdempotencyRecord{Fingerprint: "fp2", Status: 201, Body: []byte("{}"), EmployeeIDs: []int{3}}
+		if err := s.Complete(ctx, "bulk", stored, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Complete() error: %v", err)
+		}
+		if err := s.PurgeEmployee(ctx, 2); err != nil {
+			t.Fatalf("PurgeEmployee() error: %v", err)
+		}
+		if record, err := s.Reserve(ctx, "bulk", "fp2", until); err != nil || record == nil || record.Status != 201 {
+			t.Errorf("Reserve() of a reused key = %+v, %v, expected it kept", record, err)
+		}
+	})
+}
+
+// RunRateLimitStoreTests runs the suite. newStore must return a store with
+// no counts for every call. A day long window keeps the counts from
+// refilling or crossing a window boundary while the test runs.
+func RunRateLimitStoreTests(t *testing.T, newStore func(t *testing.T) domain.RateLimitStore) {
+	const window = 24 * time.Hour
+
+	t.Run("EnforcesLimit", func(t *testing.T) {
+		s := newStore(t)
+		for i := 1; i <= 3; i++ {
+			res, err := s.Take(ctx, "ip:10.0.0.1", 3, window)
+			if err != nil {
+				t.Fatalf("Take() error: %v", err)
+			}
+			if !res.Allowed {
+				t.Fatalf("request %d rejected, expected a limit of 3", i)
+			}
+			if res.Remaining != 3-i {
+				t.Errorf("request %d: Remaining = %d, expected %d", i, res.Remaining, 3-i)
+			}
+			if res.Reset <= 0 || res.Reset > window {
+				t.Errorf("request %d: Reset = %v, expected within the window", i, res.Reset)
+			}
+		}
+
+		res, err := s.Take(ctx, "ip:10.0.0.1", 3, window)
+		if err != nil {
+			t.Fatalf("Take() error: %v", err)
+		}
+		if res.Allowed || res.Remaining != 0 {
+			t.Errorf("4th request = %+v, expected rejected with nothing remaining", res)
+		}
+		if res.RetryAfter <= 0 {
+			t.Errorf("RetryAfter = %v, expected positive", res.RetryAfter)
+		}
+	})
+
+	t.Run("RejectionsDoNotCount", func(t *testing.T) {
+		s := newStore(t)
+		for i := 0; i < 5; i++ {
+			if _, err := s.Take(ctx, "ip:10.0.0.1", 1, window); err != nil {
+				t.Fatalf("Take() error: %v", err)
+			}
+		}
+		// A higher limit on the same key must see one request, not five
+		res, err := s.Take(ctx, "ip:10.0.0.1", 3, window)
+		if err != nil || !res.Allowed {
+			t.Errorf("Take() with a raised limit = %+v, %v, expected allowed", res, err)
+		}
+	})
+
+	t.Run("KeysAreIndependent", func(t *testing.T) {
+		s := newStore(t)
+		if res, err := s.Take(ctx, "ip:10.0.0.1", 1, window); err != nil || !res.Allowed {
+			t.Fatalf("Take() = %+v, %v", res, err)
+		}
+		if res, err := s.Take(ctx, "ip:10.0.0.1", 1, window); err != nil || res.Allowed {
+			t.Fatalf("second Take() = %+v, %v, expected rejected", res, err)
+		}
+		if res, err := s.Take(ctx, "ip:10.0.0.2", 1, window); err != nil || !res.Allowed {
+			t.Errorf("Take() for another key = %+v, %v, expected allowed", res, err)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package seed
+
+// department is a part of the organization with the staff positions in it.
+// weight is its relative headcount.
+type department struct {
+	name      string
+	weight    int
+	positions []string
+}
+
+var departments = []department{
+	{"Teknologi Informasi", 18, []string{"Software Engineer", "Analis Sistem", "Administrator Jaringan", "Staf IT Support", "Database Administrator", "QA Engineer"}},
+	{"Keuangan", 12, []string{"Akuntan", "Staf Keuangan", "Analis Keuangan", "Staf Perpajakan", "Kasir"}},
+	{"Sumber Daya Manusia", 8, []string{"Staf Rekrutmen", "Staf Penggajian", "Spesialis Pelatihan", "Staf Administrasi SDM"}},
+	{"Pemasaran", 10, []string{"Staf Pemasaran", "Desainer Grafis", "Content Writer", "Spesialis Media Sosial"}},
+	{"Penjualan", 20, []string{"Sales Executive", "Account Manager", "Staf Penjualan", "Admin Penjualan"}},
+	{"Operasional", 22, []string{"Staf Operasional", "Staf Gudang", "Koordinator Logistik", "Pengemudi", "Teknisi"}},
+	{"Hukum", 3, []string{"Staf Legal", "Analis Kepatuhan"}},
+	{"Pengadaan", 7, []string{"Staf Pengadaan", "Analis Pembelian"}},
+}
+
+var totalDepartmentWeight = func() int {
+	total := 0
+	for _, d := range departments {
+		total += d.weight
+	}
+	return total
+}()
+
+var maleNames = []string{
+	"Agus", "Ahmad", "Andi", "Arif", "Bambang", "Bayu", "Budi", "Dedi", "Dimas", "Eko",
+	"Fajar", "Farhan", "Gilang", "Hadi", "Hendra", "Irfan", "Joko", "Krisna", "Made", "Muhammad",
+	"Nugroho", "Putu", "Rahmat", "Rizky", "Slamet", "Taufik", "Teguh", "Wahyu", "Yoga", "Yusuf",
+}
+
+var femaleNames = []string{
+	"Ayu", "Dewi", "Dian", "Dwi", "Eka", "Fitri", "Indah", "Intan", "Kartika", "Lestari",
+	"Maya", "Mega", "Nur", "Nurul", "Putri", "Rani", "Ratna", "Rina", "Sari", "Siti",
+	"Sri", "Tri", "Wulan", "Yuni", "Anisa", "Citra", "Laras", "Novi", "Ratih", "Wati",
+}
+
+var familyNames = []string{
+	"Santoso", "Wijaya", "Saputra", "Pratama", "Hidayat", "Setiawan", "Kurniawan", "Susanto", "Nugraha", "Gunawan",
+	"Siregar", "Nasution", "Harahap", "Lubis", "Simanjuntak", "Sitompul", "Pangaribuan", "Tanjung", "Hutapea", "Sinaga",
+	"Rahayu", "Wahyuni", "Permata", "Lestari", "Handayani", "Kusuma", "Utami", "Purnama", "Anggraini", "Safitri",
+	"Firmansyah", "Ramadhan", "Hakim", "Syahputra", "Maulana", "Wibowo", "Suryadi", "Prasetyo", "Halim", "Tanoto",
+}
+
+var emailDomains = []string{"example.com", "example.co.id", "example.id"}
+
+// phonePrefixes are the mobile prefixes of the Indonesian operators.
+var phonePrefixes = []string{
+	"0811", "0812", "0813", "0821", "0822", "0823", "0852", "0853", // Telkomsel
+	"0814", "0815", "0816", "0855", "0856", "0857", "0858", // Indosat
+	"0817", "0818", "0819", "0859", "0877", "0878", // XL
+	"0831", "0832", "0833", "0838", // Axis
+	"0895", "0896", "0897", "0898", "0899", // Tri
+	"0881", "0882", "0883", "0887", "0888", // Smartfren
+}
+
+var streets = []string{
+	"Jl. Sudirman", "Jl. Merdeka", "Jl. Diponegoro", "Jl. Gatot Subroto", "Jl. Ahmad Yani",
+	"Jl. Pahlawan", "Jl. Imam Bonjol", "Jl. Pemuda", "Jl. Veteran", "Jl. Gajah Mada",
+	"Jl. Hayam Wuruk", "Jl. Kartini", "Jl. Cendrawasih", "Jl. Melati", "Jl. Kenanga",
+	"Jl. Anggrek", "Jl. Mawar", "Jl. Flamboyan", "Jl. Raya Bogor", "Jl. Kebon Jeruk",
+	"Gg. Masjid", "Gg. Damai", "Gg. Sawo", "Jl. Pondok Indah", "Jl. Cempaka Putih",
+}
@@ -0,0 +1,207 @@
+// Package seed generates reproducible employee data for development and
+// load testing: Indonesian names, 08xx mobile numbers and addresses drawn
+// from the region dataset, spread over departments that each have their
+// own manager and supervisors. Departments only show in the positions and
+// roles: the schema has no department, reporting line or contract records
+// to fill in.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/region"
+)
+
+// Every department gets a manager as its first hire, then one more per
+// managerSpan hires, and a supervisor per supervisorSpan.
+const (
+	managerSpan    = 25
+	supervisorSpan = 6
+)
+
+// Generator produces a deterministic sequence of employees: generators
+// created with the same seed and region dataset yield the same employees
+// in the same order.
+type Generator struct {
+	rng      *rand.Rand
+	villages []village
+	hires    []int // per department
+	n        int
+}
+
+// village is a village with the names of the regions above it.
+type village struct {
+	provinsi, kota, kecamatan string
+	region.Village
+}
+
+// NewGenerator returns a generator for seed. Addresses are picked from
+// the villages in regions.
+func NewGenerator(seed uint64, regions *region.Directory) (*Generator, error) {
+	var villages []village
+	for _, p := range regions.Provinces() {
+		cities, err := regions.Cities(p.Code)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cities {
+			districts, err := regions.Districts(c.Code)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range districts {
+				vs, err := regions.Villages(d.Code)
+				if err != nil {
+					return nil, err
+				}
+				for _, v := range vs {
+					villages = append(villages, village{p.Name, c.Name, d.Name, v})
+				}
+			}
+		}
+	}
+	if len(villages) == 0 {
+		return nil, fmt.Errorf("region dataset has no villages")
+	}
+
+	return &Generator{
+		rng:      rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		villages: villages,
+		hires:    make([]int, len(departments)),
+	}, nil
+}
+
+// Next returns the next employee. Emails are unique within a sequence.
+func (g *Generator) Next() domain.Employee {
+	g.n++
+	first, last := g.name()
+
+	d := g.weightedDepartment()
+	hire := g.hires[d]
+	g.hires[d]++
+	dept := departments[d]
+	var position, role string
+	switch {
+	case hire%managerSpan == 0:
+		position, role = "Manajer "+dept.name, "Manager"
+	case hire%supervisorSpan == 0:
+		position, role = "Supervisor "+dept.name, "Supervisor"
+	default:
+		position, role = pick(g.rng, dept.positions), "Staff"
+	}
+
+	name := first
+	if last != "" {
+		name += " " + last
+	}
+	addr := g.address()
+	return domain.Employee{
+		Name:     name,
+		Email:    g.email(first, last),
+		Position: position,
+		Role:     role,
+		Phone:    g.phone(),
+		Alamat:   addr.Render(),
+		Address:  &addr,
+	}
+}
+
+// name returns a given name and a family name, which is empty for the
+// share of Indonesians known by a single name.
+func (g *Generator) name() (string, string) {
+	given := maleNames
+	if g.rng.IntN(2) == 0 {
+		given = femaleNames
+	}
+	first := pick(g.rng, given)
+	switch r := g.rng.IntN(20); {
+	case r == 0:
+		return first, ""
+	case r <= 3:
+		// Two given names, e.g. "Dwi Ayu"
+		return first + " " + pick(g.rng, given), pick(g.rng, familyNames)
+	default:
+		return first, pick(g.rng, familyNames)
+	}
+}
+
+// email builds a unique address from the name and the position in the
+// sequence.
+func (g *Generator) email(first, last string) string {
+	local := strings.ToLower(strings.ReplaceAll(first, " ", "."))
+	if last != "" {
+		local += "." + strings.ToLower(last)
+	}
+	return fmt.Sprintf("%s%d@%s", local, g.n, pick(g.rng, emailDomains))
+}
+
+// phone returns an Indonesian mobile number: an operator prefix such as
+// 0812 followed by 7 or 8 digits.
+func (g *Generator) phone() string {
+	var b strings.Builder
+	b.WriteString(pick(g.rng, phonePrefixes))
+	for i, n := 0, 7+g.rng.IntN(2); i < n; i++ {
+		b.WriteByte(byte('0' + g.rng.IntN(10)))
+	}
+	return b.String()
+}
+
+func (g *Generator) address() domain.Address {
+	v := g.villages[g.rng.IntN(len(g.villages))]
+	return domain.Address{
+		Street:        fmt.Sprintf("%s No. %d", pick(g.rng, streets), 1+g.rng.IntN(150)),
+		RT:            fmt.Sprintf("%03d", 1+g.rng.IntN(15)),
+		RW:            fmt.Sprintf("%03d", 1+g.rng.IntN(12)),
+		Kelurahan:     v.Name,
+		Kecamatan:     v.kecamatan,
+		KotaKabupaten: v.kota,
+		Provinsi:      v.provinsi,
+		KodePos:       v.PostalCode,
+	}
+}
+
+func (g *Generator) weightedDepartment() int {
+	n := g.rng.IntN(totalDepartmentWeight)
+	for i, d := range departments {
+		if n < d.weight {
+			return i
+		}
+		n -= d.weight
+	}
+	return len(departments) - 1
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.IntN(len(values))]
+}
+
+// Run inserts count employees from g into repo, batchSize per transaction,
+// and reports the running total to progress after every batch. It returns
+// the number of employees inserted, which is a whole number of batches
+// when a batch fails.
+func Run(ctx context.Context, repo domain.EmployeeRepository, g *Generator, count, batchSize int, progress func(inserted int)) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	inserted := 0
+	batch := make([]domain.Employee, 0, batchSize)
+	for inserted < count {
+		batch = batch[:0]
+		for len(batch) < batchSize && inserted+len(batch) < count {
+			batch = append(batch, g.Next())
+		}
+		if err := repo.CreateBatch(ctx, batch); err != nil {
+			return inserted, err
+		}
+		inserted += len(batch)
+		if progress != nil {
+			progress(inserted)
+		}
+	}
+	return inserted, nil
+}
@@ -0,0 +1,117 @@
+package seed
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"karyawan-app/internal/region"
+	"karyawan-app/internal/repository/memory"
+)
+
+func newGenerator(t *testing.T, seed uint64) *Generator {
+	t.Helper()
+	regions, err := region.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGenerator(seed, regions)
+	if err != nil {
+		t.Fatalf("NewGenerator() error: %v", err)
+	}
+	return g
+}
+
+func TestDeterministic(t *testing.T) {
+	a, b, other := newGenerator(t, 42), newGenerator(t, 42), newGenerator(t, 43)
+	differs := false
+	for i := 0; i < 100; i++ {
+		ea, eb, eo := a.Next(), b.Next(), other.Next()
+		if ea.Name != eb.Name || ea.Email != eb.Email || ea.Phone != eb.Phone || ea.Alamat != eb.Alamat || ea.Position != eb.Position {
+			t.Fatalf("employee %d differs for the same seed:\n%+v\n%+v", i, ea, eb)
+		}
+		differs = differs || ea.Email != eo.Email
+	}
+	if !differs {
+		t.Error("seeds 42 and 43 generated the same employees")
+	}
+}
+
+var phonePattern = regexp.MustCompile(`^08[1-9][0-9]{8,9}$`)
+
+func TestGeneratedEmployeesAreValid(t *testing.T) {
+	regions, err := region.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newGenerator(t, 1)
+
+	emails := make(map[string]bool)
+	staff := make(map[string]int)
+	managers := make(map[string]bool)
+	for i := 0; i < 5000; i++ {
+		e := g.Next()
+		if e.Name == "" || e.Position == "" || e.Role == "" || e.Alamat == "" {
+			t.Fatalf("incomplete employee: %+v", e)
+		}
+		if !phonePattern.MatchString(e.Phone) {
+			t.Errorf("phone %q is not an Indonesian mobile number", e.Phone)
+		}
+		if emails[e.Email] {
+			t.Errorf("duplicate email %s", e.Email)
+		}
+		emails[e.Email] = true
+
+		addr := *e.Address
+		if err := regions.Normalize(&addr); err != nil || addr.Render() != e.Alamat {
+			t.Errorf("address %+v does not match the region dataset: %v", e.Address, err)
+		}
+
+		if dept, ok := strings.CutPrefix(e.Position, "Manajer "); ok {
+			managers[dept] = true
+		} else {
+			staff[departmentOf(e.Position)]++
+		}
+	}
+
+	for dept := range staff {
+		if !managers[dept] {
+			t.Errorf("department %s has staff but no manager", dept)
+		}
+	}
+}
+
+// departmentOf returns the department a position belongs to.
+func departmentOf(position string) string {
+	for _, d := range departments {
+		if position == "Supervisor "+d.name {
+			return d.name
+		}
+		for _, p := range d.positions {
+			if p == position {
+				return d.name
+			}
+		}
+	}
+	return position
+}
+
+func TestRunInsertsInBatches(t *testing.T) {
+	repo := memory.NewEmployeeRepository()
+	var reports []int
+	n, err := Run(context.Background(), repo, newGenerator(t, 7), 2500, 1000, func(inserted int) {
+		reports = append(reports, inserted)
+	})
+	if err != nil || n != 2500 {
+		t.Fatalf("Run() = %d, %v", n, err)
+	}
+	if len(reports) != 3 || reports[2] != 2500 {
+		t.Errorf("progress reports = %v, expected 1000, 2000, 2500", reports)
+	}
+
+	all, err := repo.FindAll(context.Background())
+	if err != nil || len(all) != 2500 {
+		t.Errorf("repository holds %d employees, %v", len(all), err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
+)
+
+// BulkEmployees checks every operation before any is applied, so in atomic
+// mode an invalid one costs no writes. Operations of one request may not
+// target the same employee twice nor claim the same email.
+func (s *employeeService) BulkEmployees(ctx context.Context, ops []domain.BulkOperation, atomic bool) ([]domain.BulkResult, error) {
+	results := make([]domain.BulkResult, len(ops))
+
+	// Targets of updates and deletes are looked up in one query
+	var ids []int
+	for _, op := range ops {
+		if op.Op != domain.BulkCreate && op.ID > 0 {
+			ids = append(ids, op.ID)
+		}
+	}
+	existing := make(map[int]domain.Employee, len(ids))
+	if len(ids) > 0 {
+		found, err := s.repo.FindByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range found {
+			existing[e.ID] = e
+		}
+	}
+
+	b := &bulkCheck{existing: existing, targets: make(map[int]int), emails: make(map[string]int)}
+	failed := false
+	for i, op := range ops {
+		if results[i].Err = s.checkBulkOperation(ctx, b, i, op); results[i].Err != nil {
+			failed = true
+		}
+	}
+	if failed && atomic {
+		return abortRest(results), nil
+	}
+
+	var apply []domain.BulkOperation
+	var indexes []int
+	for i, op := range ops {
+		if results[i].Err == nil {
+			apply = append(apply, op)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(apply) == 0 {
+		return results, nil
+	}
+	errs, err := s.repo.ApplyBatch(ctx, apply, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range indexes {
+		if results[i].Err = errs[j]; errs[j] != nil {
+			failed = true
+		}
+	}
+	if failed && atomic {
+		return abortRest(results), nil
+	}
+
+	for _, i := range indexes {
+		if results[i].Err != nil {
+			continue
+		}
+		switch op := ops[i]; op.Op {
+		case domain.BulkCreate:
+			results[i].Employee = op.Employee
+			s.record(ctx, op.Employee.ID, domain.AuditCreate, nil)
+		case domain.BulkUpdate:
+			before := existing[op.ID]
+			results[i].Employee = op.Employee
+			s.record(ctx, op.ID, domain.AuditUpdate, changedFields(&before, op.Employee))
+		case domain.BulkDelete:
+			s.record(ctx, op.ID, domain.AuditDelete, nil)
+		}
+	}
+	return results, nil
+}
+
+// bulkCheck is what checkBulkOperation knows of the rest of the request:
+// the stored targets and which operation claimed each target and email.
+type bulkCheck struct {
+	existing map[int]domain.Employee
+	targets  map[int]int
+	emails   map[string]int
+}
+
+// checkBulkOperation validates operation i the way the single-employee
+// methods do, normalizing its address in place.
+func (s *employeeService) checkBulkOperation(ctx context.Context, b *bulkCheck, i int, op domain.BulkOperation) error {
+	switch op.Op {
+	case domain.BulkCreate, domain.BulkUpdate, domain.BulkDelete:
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	if op.Op != domain.BulkCreate {
+		if op.ID <= 0 {
+			return errors.New("employee ID is required")
+		}
+		target, ok := b.existing[op.ID]
+		if !ok {
+			return domain.ErrEmployeeNotFound
+		}
+		if j, ok := b.targets[op.ID]; ok {
+			return fmt.Errorf("employee %d is already changed by operation %d", op.ID, j)
+		}
+		b.targets[op.ID] = i
+		if op.Op == domain.BulkDelete {
+			return nil
+		}
+		if target.AnonymizedAt != nil {
+			return domain.ErrEmployeeAnonymized
+		}
+	}
+
+	if op.Employee == nil {
+		return errors.New("employee is required")
+	}
+	op.Employee.ID = op.ID
+	if err := s.normalizeAddress(op.Employee); err != nil {
+		return err
+	}
+	if err := validateEmployee(op.Employee); err != nil {
+		return err
+	}
+	email := pii.NormalizeEmail(op.Employee.Email)
+	if j, ok := b.emails[email]; ok {
+		return fmt.Errorf("email is already used by operation %d", j)
+	}
+	if err := s.ensureEmailAvailable(ctx, op.Employee); err != nil {
+		return err
+	}
+	b.emails[email] = i
+	return nil
+}
+
+// abortRest marks every operation of a failed atomic request that did not
+// fail itself as not applied.
+func abortRest(results []domain.BulkResult) []domain.BulkResult {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = domain.BulkResult{Err: domain.ErrBulkAborted}
+		}
+	}
+	return results
+}
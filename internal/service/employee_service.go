@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 
 	"karyawan-app/internal/domain"
+	"karyawan-app/internal/region"
 )
 
 var (
@@ -13,40 +17,194 @@ var (
 )
 
 type employeeService struct {
-	repo domain.EmployeeRepository
+	repo    domain.EmployeeRepository
+	audit   domain.AuditRepository
+	regions *region.Directory
 }
 
-func NewEmployeeService(repo domain.EmployeeRepository) domain.EmployeeService {
-	return &employeeService{repo: repo}
+func NewEmployeeService(repo domain.EmployeeRepository, audit domain.AuditRepository, regions *region.Directory) domain.EmployeeService {
+	return &employeeService{repo: repo, audit: audit, regions: regions}
 }
 
-func (s *employeeService) GetAllEmployees() ([]domain.Employee, error) {
-	return s.repo.FindAll()
+func (s *employeeService) GetAllEmployees(ctx context.Context) ([]domain.Employee, error) {
+	return s.repo.FindAll(ctx)
 }
 
-func (s *employeeService) GetEmployee(id int) (*domain.Employee, error) {
-	return s.repo.FindByID(id)
+func (s *employeeService) ListEmployees(ctx context.Context, cursor, limit int) (*domain.EmployeePage, error) {
+	if limit < 1 || limit > domain.MaxPageSize {
+		return nil, fmt.Errorf("page size must be between 1 and %d", domain.MaxPageSize)
+	}
+	if cursor < 0 {
+		return nil, domain.ErrInvalidCursor
+	}
+	// One more than asked for tells whether another page follows
+	employees, err := s.repo.FindPage(ctx, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.EmployeePage{Employees: employees}
+	if len(employees) > limit {
+		page.Employees = employees[:limit]
+		page.Next = employees[limit-1].ID
+	}
+	return page, nil
+}
+
+func (s *employeeService) HeadcountByRole(ctx context.Context) (map[string]int, error) {
+	return s.repo.CountByRole(ctx)
+}
+
+func (s *employeeService) GetEmployee(ctx context.Context, id int) (*domain.Employee, error) {
+	return s.repo.FindByID(ctx, id)
 }
 
-func (s *employeeService) CreateEmployee(employee *domain.Employee) error {
+func (s *employeeService) GetEmployees(ctx context.Context, ids []int) ([]domain.Employee, error) {
+	return s.repo.FindByIDs(ctx, ids)
+}
+
+func (s *employeeService) GetEmployeeByEmail(ctx context.Context, email string) (*domain.Employee, error) {
+	return s.repo.FindByEmail(ctx, email)
+}
+
+func (s *employeeService) GetEmployeesByPhone(ctx context.Context, phone string) ([]domain.Employee, error) {
+	return s.repo.FindByPhone(ctx, phone)
+}
+
+func (s *employeeService) CreateEmployee(ctx context.Context, employee *domain.Employee) error {
+	if err := s.normalizeAddress(employee); err != nil {
+		return err
+	}
 	if err := validateEmployee(employee); err != nil {
 		return err
 	}
-	return s.repo.Create(employee)
+	if err := s.ensureEmailAvailable(ctx, employee); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, employee); err != nil {
+		return err
+	}
+	s.record(ctx, employee.ID, domain.AuditCreate, nil)
+	return nil
 }
 
-func (s *employeeService) UpdateEmployee(employee *domain.Employee) error {
+func (s *employeeService) UpdateEmployee(ctx context.Context, employee *domain.Employee) error {
 	if employee.ID == 0 {
 		return errors.New("employee ID is required")
 	}
+	if err := s.normalizeAddress(employee); err != nil {
+		return err
+	}
 	if err := validateEmployee(employee); err != nil {
 		return err
 	}
-	return s.repo.Update(employee)
+	if err := s.ensureEmailAvailable(ctx, employee); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.FindByID(ctx, employee.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return domain.ErrEmployeeNotFound
+	}
+	if existing.AnonymizedAt != nil {
+		return domain.ErrEmployeeAnonymized
+	}
+
+	if err := s.repo.Update(ctx, employee); err != nil {
+		return err
+	}
+	s.record(ctx, employee.ID, domain.AuditUpdate, changedFields(existing, employee))
+	return nil
+}
+
+func (s *employeeService) DeleteEmployee(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.record(ctx, id, domain.AuditDelete, nil)
+	return nil
+}
+
+// record appends an audit entry. A failure is logged rather than returned
+// because the change it describes has already been committed, and for the
+// same reason the entry is written even if ctx was cancelled meanwhile.
+func (s *employeeService) record(ctx context.Context, employeeID int, action string, fields []string) {
+	if s.audit == nil {
+		return
+	}
+	entry := &domain.AuditEntry{EmployeeID: employeeID, Action: action, Fields: fields}
+	if err := s.audit.Record(context.WithoutCancel(ctx), entry); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit entry", "action", action, "employee_id", employeeID, "error", err)
+	}
+}
+
+// changedFields names the JSON fields that differ between two versions of
+// an employee.
+func changedFields(before, after *domain.Employee) []string {
+	var fields []string
+	compare := []struct {
+		name       string
+		old, value string
+	}{
+		{"name", before.Name, after.Name},
+		{"email", before.Email, after.Email},
+		{"position", before.Position, after.Position},
+		{"role", before.Role, after.Role},
+		{"phone", before.Phone, after.Phone},
+		{"alamat", before.Alamat, after.Alamat},
+	}
+	for _, c := range compare {
+		if c.old != c.value {
+			fields = append(fields, c.name)
+		}
+	}
+
+	var oldAddr, newAddr domain.Address
+	if before.Address != nil {
+		oldAddr = *before.Address
+	}
+	if after.Address != nil {
+		newAddr = *after.Address
+	}
+	if oldAddr != newAddr {
+		fields = append(fields, "address")
+	}
+	return fields
 }
 
-func (s *employeeService) DeleteEmployee(id int) error {
-	return s.repo.Delete(id)
+// ensureEmailAvailable rejects an email already used by another employee.
+// Emails are encrypted at rest, so uniqueness is checked here through the
+// repository's blind index lookup rather than left to a column constraint.
+func (s *employeeService) ensureEmailAvailable(ctx context.Context, employee *domain.Employee) error {
+	existing, err := s.repo.FindByEmail(ctx, employee.Email)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != employee.ID {
+		return errors.New("email is already registered")
+	}
+	return nil
+}
+
+// normalizeAddress validates a structured address against the region
+// dataset and renders it into Alamat. Employees without a structured
+// address keep their free-text Alamat.
+func (s *employeeService) normalizeAddress(employee *domain.Employee) error {
+	if employee.Address == nil {
+		return nil
+	}
+	if strings.TrimSpace(employee.Address.Street) == "" {
+		return errors.New("address street is required")
+	}
+	if s.regions != nil {
+		if err := s.regions.Normalize(employee.Address); err != nil {
+			return err
+		}
+	}
+	employee.Alamat = employee.Address.Render()
+	return nil
 }
 
 func validateEmployee(employee *domain.Employee) error {
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"karyawan-app/internal/domain"
+)
+
+// ForgetErasedEmployees wraps svc so deleting or anonymizing an employee,
+// including through bulk requests, also deletes the responses stored in
+// store that hold the employee's data. Their keys are freed, so a retry
+// sent afterwards is processed anew. Data exports include the stored
+// responses that hold the employee's data.
+func ForgetErasedEmployees(svc domain.EmployeeService, store domain.IdempotencyStore) domain.EmployeeService {
+	return &forgetfulService{EmployeeService: svc, store: store}
+}
+
+type forgetfulService struct {
+	domain.EmployeeService
+	store domain.IdempotencyStore
+}
+
+func (s *forgetfulService) DeleteEmployee(ctx context.Context, id int) error {
+	if err := s.EmployeeService.DeleteEmployee(ctx, id); err != nil {
+		return err
+	}
+	s.purge(ctx, id)
+	return nil
+}
+
+func (s *forgetfulService) AnonymizeEmployee(ctx context.Context, id int) (*domain.Employee, error) {
+	employee, err := s.EmployeeService.AnonymizeEmployee(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.purge(ctx, id)
+	return employee, nil
+}
+
+// ExportEmployeeData adds the stored responses holding the employee's data.
+func (s *forgetfulService) ExportEmployeeData(ctx context.Context, id int) (*domain.DataExport, error) {
+	export, err := s.EmployeeService.ExportEmployeeData(ctx, id)
+	if err != nil || export == nil {
+		return export, err
+	}
+	if export.StoredResponses, err = s.store.FindByEmployee(ctx, id); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *forgetfulService) BulkEmployees(ctx context.Context, ops []domain.BulkOperation, atomic bool) ([]domain.BulkResult, error) {
+	results, err := s.EmployeeService.BulkEmployees(ctx, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if op.Op == domain.BulkDelete && results[i].Err == nil {
+			s.purge(ctx, op.ID)
+		}
+	}
+	return results, nil
+}
+
+// purge runs after the employee is gone, which its caller is told of even
+// when the purge fails, so failures are only logged.
+func (s *forgetfulService) purge(ctx context.Context, id int) {
+	if err := s.store.PurgeEmployee(context.WithoutCancel(ctx), id); err != nil {
+		slog.ErrorContext(ctx, "failed to purge idempotent responses of erased employee", "employee_id", id, "error", err)
+	}
+}
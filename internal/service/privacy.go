@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"karyawan-app/internal/domain"
+)
+
+// anonymizedName replaces the name of an erased employee.
+const anonymizedName = "Anonymized Employee"
+
+// ExportEmployeeData collects everything held about an employee for a data
+// subject access request under UU PDP. The export itself is audited.
+func (s *employeeService) ExportEmployeeData(ctx context.Context, id int) (*domain.DataExport, error) {
+	employee, err := s.repo.FindByID(ctx, id)
+	if err != nil || employee == nil {
+		return nil, err
+	}
+
+	s.record(ctx, id, domain.AuditDataExport, nil)
+
+	export := &domain.DataExport{
+		ExportedAt: time.Now().UTC(),
+		Employee:   *employee,
+		AuditLog:   []domain.AuditEntry{},
+		// Filled in by the services wrapping this one
+		Events:          []domain.EmployeeEvent{},
+		StoredResponses: []domain.StoredResponse{},
+	}
+	if s.audit != nil {
+		if export.AuditLog, err = s.audit.FindByEmployee(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+func (s *employeeService) GetAuditLogs(ctx context.Context, employeeIDs []int) (map[int][]domain.AuditEntry, error) {
+	logs := make(map[int][]domain.AuditEntry, len(employeeIDs))
+	if s.audit == nil {
+		return logs, nil
+	}
+	entries, err := s.audit.FindByEmployees(ctx, employeeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		logs[e.EmployeeID] = append(logs[e.EmployeeID], e)
+	}
+	return logs, nil
+}
+
+// AnonymizeEmployee irreversibly scrubs an employee's personal data. The
+// record, its ID, role, position and kota/provinsi are kept so headcount
+// reports and payroll history stay intact.
+func (s *employeeService) AnonymizeEmployee(ctx context.Context, id int) (*domain.Employee, error) {
+	employee, err := s.repo.FindByID(ctx, id)
+	if err != nil || employee == nil {
+		return nil, err
+	}
+	if employee.AnonymizedAt != nil {
+		return nil, domain.ErrEmployeeAnonymized
+	}
+
+	scrubbed := *employee
+	scrubbed.Name = anonymizedName
+	scrubbed.Email = fmt.Sprintf("anonymized-%d@anonymized.invalid", id)
+	scrubbed.Phone = ""
+	scrubbed.Address = nil
+	if employee.Address != nil {
+		scrubbed.Address = &domain.Address{
+			KotaKabupaten: employee.Address.KotaKabupaten,
+			Provinsi:      employee.Address.Provinsi,
+		}
+	}
+	scrubbed.Alamat = ""
+	if scrubbed.Address != nil {
+		scrubbed.Alamat = scrubbed.Address.Render()
+	}
+
+	if err := s.repo.Anonymize(ctx, &scrubbed); err != nil {
+		return nil, err
+	}
+	s.record(ctx, id, domain.AuditAnonymize, changedFields(employee, &scrubbed))
+
+	return s.repo.FindByID(ctx, id)
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/logging"
+)
+
+const tracerName = "karyawan-app/internal/tracing"
+
+// TraceEmployeeService wraps svc so every call runs in a span named
+// EmployeeService.<Method>. Arguments that are personal data (email,
+// phone) are not recorded.
+func TraceEmployeeService(svc domain.EmployeeService) domain.EmployeeService {
+	return &employeeService{next: svc}
+}
+
+type employeeService struct {
+	next domain.EmployeeService
+}
+
+func start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "EmployeeService."+method, trace.WithAttributes(attrs...))
+}
+
+// end records err on the span and ends it. Error texts can echo input
+// values, so emails and phone numbers are masked first.
+func end(span trace.Span, err error) {
+	if err != nil {
+		msg := logging.Redact(err.Error())
+		span.AddEvent("exception", trace.WithAttributes(attribute.String("exception.message", msg)))
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+func employeeID(id int) attribute.KeyValue {
+	return attribute.Int("employee.id", id)
+}
+
+func (s *employeeService) GetAllEmployees(ctx context.Context) (employees []domain.Employee, err error) {
+	ctx, span := start(ctx, "GetAllEmployees")
+	defer func() {
+		span.SetAttributes(attribute.Int("employee.count", len(employees)))
+		end(span, err)
+	}()
+	return s.next.GetAllEmployees(ctx)
+}
+
+func (s *employeeService) ListEmployees(ctx context.Context, cursor, limit int) (page *domain.EmployeePage, err error) {
+	ctx, span := start(ctx, "ListEmployees", attribute.Int("page.cursor", cursor), attribute.Int("page.limit", limit))
+	defer func() {
+		if page != nil {
+			span.SetAttributes(attribute.Int("employee.count", len(page.Employees)))
+		}
+		end(span, err)
+	}()
+	return s.next.ListEmployees(ctx, cursor, limit)
+}
+
+func (s *employeeService) GetEmployee(ctx context.Context, id int) (_ *domain.Employee, err error) {
+	ctx, span := start(ctx, "GetEmployee", employeeID(id))
+	defer func() { end(span, err) }()
+	return s.next.GetEmployee(ctx, id)
+}
+
+func (s *employeeService) GetEmployees(ctx context.Context, ids []int) (employees []domain.Employee, err error) {
+	ctx, span := start(ctx, "GetEmployees", attribute.Int("employee.requested", len(ids)))
+	defer func() {
+		span.SetAttributes(attribute.Int("employee.count", len(employees)))
+		end(span, err)
+	}()
+	return s.next.GetEmployees(ctx, ids)
+}
+
+func (s *employeeService) GetEmployeeByEmail(ctx context.Context, email string) (_ *domain.Employee, err error) {
+	ctx, span := start(ctx, "GetEmployeeByEmail")
+	defer func() { end(span, err) }()
+	return s.next.GetEmployeeByEmail(ctx, email)
+}
+
+func (s *employeeService) GetEmployeesByPhone(ctx context.Context, phone string) (_ []domain.Employee, err error) {
+	ctx, span := start(ctx, "GetEmployeesByPhone")
+	defer func() { end(span, err) }()
+	return s.next.GetEmployeesByPhone(ctx, phone)
+}
+
+func (s *employeeService) CreateEmployee(ctx context.Context, employee *domain.Employee) (err error) {
+	ctx, span := start(ctx, "CreateEmployee")
+	defer func() {
+		span.SetAttributes(employeeID(employee.ID))
+		end(span, err)
+	}()
+	return s.next.CreateEmployee(ctx, employee)
+}
+
+func (s *employeeService) UpdateEmployee(ctx context.Context, employee *domain.Employee) (err error) {
+	ctx, span := start(ctx, "UpdateEmployee", employeeID(employee.ID))
+	defer func() { end(span, err) }()
+	return s.next.UpdateEmployee(ctx, employee)
+}
+
+func (s *employeeService) DeleteEmployee(ctx context.Context, id int) (err error) {
+	ctx, span := start(ctx, "DeleteEmployee", employeeID(id))
+	defer func() { end(span, err) }()
+	return s.next.DeleteEmployee(ctx, id)
+}
+
+func (s *employeeService) BulkEmployees(ctx context.Context, ops []domain.BulkOperation, atomic bool) (results []domain.BulkResult, err error) {
+	ctx, span := start(ctx, "BulkEmployees", attribute.Int("bulk.operations", len(ops)), attribute.Bool("bulk.atomic", atomic))
+	defer func() {
+		failed := 0
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+			}
+		}
+		span.SetAttributes(attribute.Int("bulk.failed", failed))
+		end(span, err)
+	}()
+	return s.next.BulkEmployees(ctx, ops, atomic)
+}
+
+func (s *employeeService) ExportEmployeeData(ctx context.Context, id int) (_ *domain.DataExport, err error) {
+	ctx, span := start(ctx, "ExportEmployeeData", employeeID(id))
+	defer func() { end(span, err) }()
+	return s.next.ExportEmployeeData(ctx, id)
+}
+
+func (s *employeeService) GetAuditLogs(ctx context.Context, employeeIDs []int) (_ map[int][]domain.AuditEntry, err error) {
+	ctx, span := start(ctx, "GetAuditLogs", attribute.Int("employee.requested", len(employeeIDs)))
+	defer func() { end(span, err) }()
+	return s.next.GetAuditLogs(ctx, employeeIDs)
+}
+
+func (s *employeeService) AnonymizeEmployee(ctx context.Context, id int) (_ *domain.Employee, err error) {
+	ctx, span := start(ctx, "AnonymizeEmployee", employeeID(id))
+	defer func() { end(span, err) }()
+	return s.next.AnonymizeEmployee(ctx, id)
+}
+
+func (s *employeeService) HeadcountByRole(ctx context.Context) (_ map[string]int, err error) {
+	ctx, span := start(ctx, "HeadcountByRole")
+	defer func() { end(span, err) }()
+	return s.next.HeadcountByRole(ctx)
+}
@@ -0,0 +1,112 @@
+// Package spantest captures the spans of a test through the stdout
+// exporter, so tests exercise the same export path as OTEL_TRACES_EXPORTER=stdout.
+package spantest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"karyawan-app/internal/tracing"
+)
+
+// Span is the subset of an exported span tests assert on.
+type Span struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Attributes   map[string]interface{}
+}
+
+// Recorder collects the spans exported while it is installed.
+type Recorder struct {
+	t        *testing.T
+	buf      bytes.Buffer
+	provider *sdktrace.TracerProvider
+}
+
+// Install makes a stdout exporting provider and the W3C propagator global
+// for the rest of the test. Tests using it must not run in parallel.
+func Install(t *testing.T) *Recorder {
+	t.Helper()
+
+	r := &Recorder{t: t}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(&r.buf))
+	if err != nil {
+		t.Fatalf("stdouttrace.New() error: %v", err)
+	}
+	r.provider, err = tracing.NewProvider(exporter)
+	if err != nil {
+		t.Fatalf("NewProvider() error: %v", err)
+	}
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(r.provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		r.provider.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return r
+}
+
+// Spans flushes the provider and returns every span exported so far.
+func (r *Recorder) Spans() []Span {
+	r.t.Helper()
+
+	if err := r.provider.ForceFlush(context.Background()); err != nil {
+		r.t.Fatalf("ForceFlush() error: %v", err)
+	}
+
+	var spans []Span
+	dec := json.NewDecoder(bytes.NewReader(r.buf.Bytes()))
+	for {
+		var raw struct {
+			Name        string
+			SpanContext struct{ TraceID, SpanID string }
+			Parent      struct{ SpanID string }
+			Attributes  []struct {
+				Key   string
+				Value struct{ Value interface{} }
+			}
+		}
+		if err := dec.Decode(&raw); err == io.EOF {
+			return spans
+		} else if err != nil {
+			r.t.Fatalf("failed to decode exported span: %v", err)
+		}
+
+		span := Span{
+			Name:       raw.Name,
+			TraceID:    raw.SpanContext.TraceID,
+			SpanID:     raw.SpanContext.SpanID,
+			Attributes: make(map[string]interface{}),
+		}
+		if raw.Parent.SpanID != "0000000000000000" {
+			span.ParentSpanID = raw.Parent.SpanID
+		}
+		for _, a := range raw.Attributes {
+			span.Attributes[a.Key] = a.Value.Value
+		}
+		spans = append(spans, span)
+	}
+}
+
+// Find returns the first span with the given name, or nil.
+func Find(spans []Span, name string) *Span {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
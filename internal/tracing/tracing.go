@@ -0,0 +1,74 @@
+// Package tracing configures OpenTelemetry for the server. Spans are
+// created by the handler, service and database layers through the global
+// tracer provider, which stays a no-op until Setup installs an exporter.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const serviceName = "karyawan-app"
+
+// Setup installs the tracer provider for the named exporter:
+//
+//   - otlp: OTLP over HTTP, configured by the standard
+//     OTEL_EXPORTER_OTLP_* variables (default http://localhost:4318)
+//   - stdout: one JSON document per span, written to w
+//   - none or empty: tracing disabled
+//
+// W3C trace context propagation is enabled in every case. The returned
+// function flushes and stops the exporter.
+func Setup(ctx context.Context, w io.Writer, exporterName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q (expected otlp, stdout or none)", exporterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporterName, err)
+	}
+
+	tp, err := NewProvider(exporter)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// NewProvider returns a tracer provider batching spans to exporter. The
+// service name defaults to karyawan-app and can be overridden with
+// OTEL_SERVICE_NAME; sampling follows OTEL_TRACES_SAMPLER.
+func NewProvider(exporter sdktrace.SpanExporter, opts ...sdktrace.TracerProviderOption) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+	opts = append([]sdktrace.TracerProviderOption{sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)}, opts...)
+	return sdktrace.NewTracerProvider(opts...), nil
+}
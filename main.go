@@ -2,9 +2,10 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
@@ -13,6 +14,9 @@ import (
 	"time"
 
 	"karyawan-app/config"
+	"karyawan-app/internal/domain"
+	"karyawan-app/internal/pii"
+	"karyawan-app/internal/repository"
 
 	_ "github.com/go-sql-driver/mysql"
 	"golang.org/x/time/rate"
@@ -35,17 +39,43 @@ type ErrorResponse struct {
 	Code    int    `json:"code"`
 }
 
-// Rate limiter: configurable requests per minute per IP
-var limiter *rate.Limiter
+// employees is shared with cmd/server, so rows written here are encrypted
+// and blind indexed the same way. It is nil while the database is down.
+var employees domain.EmployeeRepository
+
+// legacyEmployee returns the fields of e this server has always served.
+func legacyEmployee(e domain.Employee) Employee {
+	return Employee{
+		ID:        e.ID,
+		Name:      e.Name,
+		Email:     e.Email,
+		Position:  e.Position,
+		Role:      e.Role,
+		Phone:     e.Phone,
+		Alamat:    e.Alamat,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
 
-func initRateLimiter() {
-	requestsPerMinute := 100 // Default
-	if envRateLimit := os.Getenv("RATE_LIMIT_REQUESTS"); envRateLimit != "" {
-		if rate, err := strconv.Atoi(envRateLimit); err == nil && rate > 0 {
-			requestsPerMinute = rate
-		}
+func writeError(w http.ResponseWriter, code int, title, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: title, Message: message, Code: code})
+}
+
+// emailTaken reports whether email belongs to an employee other than id.
+func emailTaken(r *http.Request, email string, id int) (bool, error) {
+	existing, err := employees.FindByEmail(r.Context(), email)
+	if err != nil || existing == nil {
+		return false, err
 	}
+	return existing.ID != id, nil
+}
+
+// Rate limiter: configurable requests per minute per IP
+var limiter *rate.Limiter
 
+func initRateLimiter(requestsPerMinute int) {
 	windowSeconds := 60 // Default 1 minute
 	if envWindow := os.Getenv("RATE_LIMIT_WINDOW"); envWindow != "" {
 		if window, err := strconv.Atoi(envWindow); err == nil && window > 0 {
@@ -124,14 +154,29 @@ func errorLoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 }
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
 	// Initialize rate limiter
-	initRateLimiter()
+	initRateLimiter(cfg.RateLimit.Requests)
 
 	// Initialize database connection
-	if err := config.ConnectDB(); err != nil {
+	if err := config.ConnectDB(cfg); err != nil {
 		log.Printf("Warning: Database connection failed: %v", err)
 		log.Println("Starting server without database...")
 		// Continue without database for demo purposes
+	} else {
+		// Employees are read and written like cmd/server does, on its schema
+		if err := repository.Migrate(config.DB, repository.MySQL); err != nil {
+			log.Fatalf("Error migrating database: %v", err)
+		}
+		keys, err := pii.LoadKeyring(cfg.PII)
+		if err != nil {
+			log.Fatalf("Error loading PII keys: %v", err)
+		}
+		employees = repository.NewEmployeeRepository(config.DB, repository.MySQL, keys)
 	}
 
 	// Serve static files with proper MIME types
@@ -177,130 +222,93 @@ func main() {
 		}
 	}))))
 
-	// Get server configuration from environment
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8083" // Default port
+	// Health probes: the server keeps running without a database, so
+	// readiness reports whether it can actually serve the API
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	})
+	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if config.DB == nil || config.DB.PingContext(r.Context()) != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"status": "not ready", "database": "unavailable"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+	})
+
+	// The legacy server keeps its own defaults for settings left unset
+	port := "8083"
+	if cfg.IsSet("server.port") {
+		port = strconv.Itoa(cfg.Server.Port)
 	}
 
-	host := os.Getenv("HOST")
-	if host == "" {
-		host = "127.0.0.1" // Default host
+	host := "127.0.0.1"
+	if cfg.IsSet("server.host") {
+		host = cfg.Server.Host
 	}
 
-	serverAddr := host + ":" + port
+	serverAddr := net.JoinHostPort(host, port)
 	log.Printf("Server berjalan di http://%s", serverAddr)
 	log.Fatal(http.ListenAndServe(serverAddr, nil))
 }
 
 func getEmployees(w http.ResponseWriter, r *http.Request) {
-	if config.DB == nil {
-		errorResponse := ErrorResponse{
-			Error:   "Database not available",
-			Message: "Database connection is not available",
-			Code:    http.StatusServiceUnavailable,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(errorResponse)
+	if employees == nil {
+		writeError(w, http.StatusServiceUnavailable, "Database not available", "Database connection is not available")
 		return
 	}
 
-	rows, err := config.DB.Query("SELECT id, name, email, position, role, phone, alamat, created_at FROM employees WHERE deleted_at IS NULL")
+	all, err := employees.FindAll(r.Context())
 	if err != nil {
 		log.Printf("Database query error: %v", err)
-		errorResponse := ErrorResponse{
-			Error:   "Database error",
-			Message: "Failed to fetch employees",
-			Code:    http.StatusInternalServerError,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(errorResponse)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to fetch employees")
 		return
 	}
-	defer rows.Close()
-
-	var employees []Employee
-	for rows.Next() {
-		var e Employee
-		if err := rows.Scan(&e.ID, &e.Name, &e.Email, &e.Position, &e.Role, &e.Phone, &e.Alamat, &e.CreatedAt); err != nil {
-			log.Printf("Row scan error: %v", err)
-			errorResponse := ErrorResponse{
-				Error:   "Data processing error",
-				Message: "Failed to process employee data",
-				Code:    http.StatusInternalServerError,
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(errorResponse)
-			return
-		}
-		employees = append(employees, e)
-	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("Rows iteration error: %v", err)
-		errorResponse := ErrorResponse{
-			Error:   "Data iteration error",
-			Message: "Failed to iterate through employee data",
-			Code:    http.StatusInternalServerError,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(errorResponse)
-		return
+	var list []Employee
+	for _, e := range all {
+		list = append(list, legacyEmployee(e))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(employees)
+	json.NewEncoder(w).Encode(list)
 }
 
 func getEmployee(w http.ResponseWriter, r *http.Request, id int) {
-	row := config.DB.QueryRow("SELECT id, name, email, position, role, phone, alamat, created_at FROM employees WHERE id = ? AND deleted_at IS NULL", id)
-
-	var e Employee
-	err := row.Scan(&e.ID, &e.Name, &e.Email, &e.Position, &e.Role, &e.Phone, &e.Alamat, &e.CreatedAt)
-	if err == sql.ErrNoRows {
-		errorResponse := ErrorResponse{
-			Error:   "Employee not found",
-			Message: "Karyawan tidak ditemukan",
-			Code:    http.StatusNotFound,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(errorResponse)
+	if employees == nil {
+		writeError(w, http.StatusServiceUnavailable, "Database not available", "Database connection is not available")
+		return
+	}
+
+	e, err := employees.FindByID(r.Context(), id)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to fetch employee")
+		return
+	}
+	if e == nil {
+		writeError(w, http.StatusNotFound, "Employee not found", "Karyawan tidak ditemukan")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(e)
+	json.NewEncoder(w).Encode(legacyEmployee(*e))
 }
 
-func createEmployee(w http.ResponseWriter, r *http.Request) {
+// decodeEmployee reads, sanitizes and validates the body of a create or
+// update, answering the request itself when it is invalid.
+func decodeEmployee(w http.ResponseWriter, r *http.Request, requireAlamat bool) (*Employee, bool) {
 	if r.Header.Get("Content-Type") != "application/json" {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid content type",
-			Message: "Content-Type must be application/json",
-			Code:    http.StatusUnsupportedMediaType,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnsupportedMediaType)
-		json.NewEncoder(w).Encode(errorResponse)
-		return
+		writeError(w, http.StatusUnsupportedMediaType, "Invalid content type", "Content-Type must be application/json")
+		return nil, false
 	}
 
 	var emp Employee
 	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid JSON format",
-			Message: "Request body must be valid JSON",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
-		return
+		writeError(w, http.StatusBadRequest, "Invalid JSON format", "Request body must be valid JSON")
+		return nil, false
 	}
 
 	// Sanitize and validate input
@@ -312,72 +320,49 @@ func createEmployee(w http.ResponseWriter, r *http.Request) {
 
 	// Enhanced validation
 	if emp.Name == "" || len(emp.Name) < 2 {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid name",
-			Message: "Name must be at least 2 characters long",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
-		return
+		writeError(w, http.StatusBadRequest, "Invalid name", "Name must be at least 2 characters long")
+		return nil, false
 	}
-
 	if !isValidEmail(emp.Email) {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid email",
-			Message: "Please provide a valid email address",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
-		return
+		writeError(w, http.StatusBadRequest, "Invalid email", "Please provide a valid email address")
+		return nil, false
 	}
+	if requireAlamat && (emp.Alamat == "" || len(emp.Alamat) < 10) {
+		writeError(w, http.StatusBadRequest, "Invalid address", "Address must be at least 10 characters long")
+		return nil, false
+	}
+	return &emp, true
+}
 
-	if emp.Alamat == "" || len(emp.Alamat) < 10 {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid address",
-			Message: "Address must be at least 10 characters long",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
+func createEmployee(w http.ResponseWriter, r *http.Request) {
+	if employees == nil {
+		writeError(w, http.StatusServiceUnavailable, "Database not available", "Database connection is not available")
+		return
+	}
+	emp, ok := decodeEmployee(w, r, true)
+	if !ok {
 		return
 	}
 
-	res, err := config.DB.Exec(
-		"INSERT INTO employees (name, email, position, role, phone, alamat) VALUES (?, ?, ?, ?, ?, ?)",
-		emp.Name, emp.Email, emp.Position, emp.Role, emp.Phone, emp.Alamat,
-	)
+	// The unique index on the email blind index backs this check up
+	taken, err := emailTaken(r, emp.Email, 0)
 	if err != nil {
-		log.Printf("Insert error: %v", err)
-		errorResponse := ErrorResponse{
-			Error:   "Database error",
-			Message: "Failed to create employee",
-			Code:    http.StatusInternalServerError,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(errorResponse)
+		log.Printf("Email lookup error: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to create employee")
+		return
+	}
+	if taken {
+		writeError(w, http.StatusBadRequest, "Invalid email", "Email is already registered")
 		return
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		log.Printf("LastInsertId error: %v", err)
-		errorResponse := ErrorResponse{
-			Error:   "Database error",
-			Message: "Failed to get created employee ID",
-			Code:    http.StatusInternalServerError,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(errorResponse)
+	e := domain.Employee{Name: emp.Name, Email: emp.Email, Position: emp.Position, Role: emp.Role, Phone: emp.Phone, Alamat: emp.Alamat}
+	if err := employees.Create(r.Context(), &e); err != nil {
+		log.Printf("Insert error: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to create employee")
 		return
 	}
-	emp.ID = int(id)
+	emp.ID = e.ID
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -385,77 +370,41 @@ func createEmployee(w http.ResponseWriter, r *http.Request) {
 }
 
 func updateEmployee(w http.ResponseWriter, r *http.Request, id int) {
-	if r.Header.Get("Content-Type") != "application/json" {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid content type",
-			Message: "Content-Type must be application/json",
-			Code:    http.StatusUnsupportedMediaType,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnsupportedMediaType)
-		json.NewEncoder(w).Encode(errorResponse)
+	if employees == nil {
+		writeError(w, http.StatusServiceUnavailable, "Database not available", "Database connection is not available")
 		return
 	}
-
-	var emp Employee
-	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid JSON format",
-			Message: "Request body must be valid JSON",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
+	emp, ok := decodeEmployee(w, r, false)
+	if !ok {
 		return
 	}
 
-	// Sanitize and validate input
-	emp.Name = sanitizeInput(emp.Name)
-	emp.Email = sanitizeInput(emp.Email)
-	emp.Role = sanitizeInput(emp.Role)
-	emp.Phone = sanitizeInput(emp.Phone)
-	emp.Alamat = sanitizeInput(emp.Alamat)
-
-	// Enhanced validation
-	if emp.Name == "" || len(emp.Name) < 2 {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid name",
-			Message: "Name must be at least 2 characters long",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
+	e, err := employees.FindByID(r.Context(), id)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to update employee")
 		return
 	}
-
-	if !isValidEmail(emp.Email) {
-		errorResponse := ErrorResponse{
-			Error:   "Invalid email",
-			Message: "Please provide a valid email address",
-			Code:    http.StatusBadRequest,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(errorResponse)
+	if e == nil {
+		writeError(w, http.StatusNotFound, "Employee not found", "Karyawan tidak ditemukan")
 		return
 	}
-
-	_, err := config.DB.Exec(
-		"UPDATE employees SET name=?, email=?, position=?, role=?, phone=?, alamat=? WHERE id=? AND deleted_at IS NULL",
-		emp.Name, emp.Email, emp.Position, emp.Role, emp.Phone, emp.Alamat, id,
-	)
+	taken, err := emailTaken(r, emp.Email, id)
 	if err != nil {
+		log.Printf("Email lookup error: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to update employee")
+		return
+	}
+	if taken {
+		writeError(w, http.StatusBadRequest, "Invalid email", "Email is already registered")
+		return
+	}
+
+	// Fields this server does not know, like the structured address, are kept
+	e.Name, e.Email, e.Position, e.Role, e.Phone, e.Alamat = emp.Name, emp.Email, emp.Position, emp.Role, emp.Phone, emp.Alamat
+	if err := employees.Update(r.Context(), e); err != nil {
 		log.Printf("Update error: %v", err)
-		errorResponse := ErrorResponse{
-			Error:   "Database error",
-			Message: "Failed to update employee",
-			Code:    http.StatusInternalServerError,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(errorResponse)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to update employee")
 		return
 	}
 
@@ -465,18 +414,18 @@ func updateEmployee(w http.ResponseWriter, r *http.Request, id int) {
 }
 
 func deleteEmployee(w http.ResponseWriter, r *http.Request, id int) {
-	// Soft delete implementation
-	_, err := config.DB.Exec("UPDATE employees SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL", id)
-	if err != nil {
+	if employees == nil {
+		writeError(w, http.StatusServiceUnavailable, "Database not available", "Database connection is not available")
+		return
+	}
+
+	// Deleted like cmd/server does: the shared schema has no deleted_at
+	if err := employees.Delete(r.Context(), id); errors.Is(err, domain.ErrEmployeeNotFound) {
+		writeError(w, http.StatusNotFound, "Employee not found", "Karyawan tidak ditemukan")
+		return
+	} else if err != nil {
 		log.Printf("Delete error: %v", err)
-		errorResponse := ErrorResponse{
-			Error:   "Database error",
-			Message: "Failed to delete employee",
-			Code:    http.StatusInternalServerError,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(errorResponse)
+		writeError(w, http.StatusInternalServerError, "Database error", "Failed to delete employee")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+
+	_ "karyawan-app/internal/database" // registers the SQL drivers
+	"karyawan-app/internal/pii"
+	"karyawan-app/internal/repository"
 )
 
 func TestSanitizeInput(t *testing.T) {
@@ -155,6 +164,59 @@ func TestRateLimiting(t *testing.T) {
 	}
 }
 
+// The legacy handlers share the employees table, so they must encrypt,
+// blind index and keep emails unique like cmd/server.
+func TestEmployeeHandlersUseRepository(t *testing.T) {
+	db, err := sql.Open(repository.SQLite.DriverName(), "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := repository.Migrate(db, repository.SQLite); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := pii.NewKeyring("test", map[string][]byte{"test": bytes.Repeat([]byte{1}, 32)}, bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	employees = repository.NewEmployeeRepository(db, repository.SQLite, keys)
+	defer func() { employees = nil }()
+
+	post := func(email string) *httptest.ResponseRecorder {
+		body := `{"name":"Dewi","email":"` + email + `","role":"HR","phone":"081234567890","alamat":"Jl. Sudirman No. 1"}`
+		req := httptest.NewRequest("POST", "/api/employees", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		createEmployee(w, req)
+		return w
+	}
+	w := post("dewi@example.com")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", w.Code, w.Body)
+	}
+	var created Employee
+	json.NewDecoder(w.Body).Decode(&created)
+	if w := post("DEWI@example.com"); w.Code != http.StatusBadRequest {
+		t.Errorf("create with a taken email = %d, expected 400", w.Code)
+	}
+
+	var raw string
+	if err := db.QueryRow("SELECT email FROM employees WHERE id = ?", created.ID).Scan(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !pii.IsEncrypted(raw) {
+		t.Errorf("stored email = %q, expected ciphertext", raw)
+	}
+
+	w = httptest.NewRecorder()
+	getEmployee(w, httptest.NewRequest("GET", "/api/employees/"+strconv.Itoa(created.ID), nil), created.ID)
+	var got Employee
+	json.NewDecoder(w.Body).Decode(&got)
+	if w.Code != http.StatusOK || got.Email != "dewi@example.com" {
+		t.Errorf("get = %d %+v, expected the email decrypted", w.Code, got)
+	}
+}
+
 // Benchmark tests
 func BenchmarkSanitizeInput(b *testing.B) {
 	input := "<script>alert('xss')</script>"
@@ -0,0 +1,15 @@
+-- Add structured address columns to employees table.
+-- `alamat` is kept and holds the rendered display string of the address.
+ALTER TABLE employees
+ADD COLUMN address_street VARCHAR(255) NULL AFTER alamat,
+ADD COLUMN address_rt VARCHAR(3) NULL AFTER address_street,
+ADD COLUMN address_rw VARCHAR(3) NULL AFTER address_rt,
+ADD COLUMN address_kelurahan VARCHAR(100) NULL AFTER address_rw,
+ADD COLUMN address_kecamatan VARCHAR(100) NULL AFTER address_kelurahan,
+ADD COLUMN address_kota VARCHAR(100) NULL AFTER address_kecamatan,
+ADD COLUMN address_provinsi VARCHAR(100) NULL AFTER address_kota,
+ADD COLUMN address_kode_pos VARCHAR(5) NULL AFTER address_provinsi;
+
+-- Indexes for reporting by city and province
+CREATE INDEX idx_employees_address_kota ON employees(address_kota);
+CREATE INDEX idx_employees_address_provinsi ON employees(address_provinsi);
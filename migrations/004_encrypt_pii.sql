@@ -0,0 +1,18 @@
+-- Prepare employees table for field-level encryption of PII.
+-- Encrypted values are longer than plaintext, and email uniqueness and
+-- equality lookups move to HMAC blind index columns.
+ALTER TABLE employees
+MODIFY COLUMN email VARCHAR(512) NOT NULL,
+MODIFY COLUMN phone VARCHAR(512) NOT NULL,
+MODIFY COLUMN address_street TEXT NULL,
+DROP INDEX email,
+ADD COLUMN email_bidx CHAR(64) NULL AFTER email,
+ADD COLUMN phone_bidx CHAR(64) NULL AFTER phone;
+
+CREATE UNIQUE INDEX idx_employees_email_bidx ON employees(email_bidx);
+CREATE INDEX idx_employees_phone_bidx ON employees(phone_bidx);
+
+-- Existing rows stay readable as plaintext. The server fills their blind
+-- indexes at startup, keyed or, without PII keys, as plain SHA-256, so the
+-- unique index keeps covering every email. Encrypt them with:
+-- go run ./cmd/rotate-keys
@@ -0,0 +1,14 @@
+-- Audit log of operations on employee records. Only field names are stored,
+-- never values, so the log holds no personal data.
+CREATE TABLE IF NOT EXISTS employee_audit_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    employee_id INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    fields TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_employee_audit_log_employee (employee_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Marks employees whose personal data was erased on request (UU PDP)
+ALTER TABLE employees
+ADD COLUMN anonymized_at TIMESTAMP NULL DEFAULT NULL;
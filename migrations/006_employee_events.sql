@@ -0,0 +1,13 @@
+-- Log of employee changes read by GET /api/employees/stream and the gRPC
+-- WatchEmployees call. Every replica appends to it and watchers poll it,
+-- so they see the changes made through all of them; event IDs are the
+-- Last-Event-ID clients resume from. Rows older than EVENT_RETENTION are
+-- pruned by the server.
+CREATE TABLE IF NOT EXISTS employee_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    employee_id INT NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_employee_events_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
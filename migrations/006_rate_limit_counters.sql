@@ -0,0 +1,10 @@
+-- Request counters shared by every server replica when RATE_LIMIT_STORE=sql.
+-- One row per client bucket and fixed window; the limiter weighs the
+-- previous window to approximate a sliding window.
+CREATE TABLE IF NOT EXISTS rate_limit_counters (
+    bucket VARCHAR(255) NOT NULL,
+    window_start BIGINT NOT NULL,
+    count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket, window_start),
+    INDEX idx_rate_limit_counters_window (window_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- Responses of POST and PATCH requests sent with an Idempotency-Key,
+-- replayed to retries until expires_at (Unix milliseconds). Rows with
+-- status 0 are requests still being processed. Bodies hold employee data
+-- and are encrypted with the PII keys when they are configured.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idem_key VARCHAR(64) NOT NULL PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    status INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    body MEDIUMBLOB,
+    expires_at BIGINT NOT NULL,
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+-- Employees whose data each stored Idempotency-Key response holds, so the
+-- responses are deleted when an employee is deleted or anonymized.
+CREATE TABLE IF NOT EXISTS idempotency_key_employees (
+    idem_key VARCHAR(64) NOT NULL,
+    employee_id INT NOT NULL,
+    PRIMARY KEY (idem_key, employee_id),
+    INDEX idx_idempotency_key_employees_employee_id (employee_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;